@@ -1,14 +1,19 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 	"github.com/tsarlewey/proof-cli/pkg/sdk/business"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
 )
 
 // ptr returns a pointer to the given value (helper for optional SDK fields)
@@ -24,6 +29,49 @@ func ptrIfNotEmpty(s string) *string {
 	return &s
 }
 
+// parseEnum converts a flag value to a generated enum type, rejecting values the
+// API does not define. An empty value yields nil so the field is omitted.
+func parseEnum[T interface {
+	~string
+	Valid() bool
+}](flag, value string, allowed ...T) (*T, error) {
+	if value == "" {
+		return nil, nil
+	}
+	v := T(value)
+	if !v.Valid() {
+		names := make([]string, len(allowed))
+		for i, a := range allowed {
+			names[i] = string(a)
+		}
+		return nil, clierr.Input("invalid --%s %q (allowed: %s)", flag, value, strings.Join(names, ", "))
+	}
+	return &v, nil
+}
+
+// parseISOTime validates an ISO-8601 timestamp flag and returns it in RFC 3339
+// form, normalized to UTC. Dates without a time are taken as midnight UTC.
+func parseISOTime(flag, value string) (*string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return ptr(t.UTC().Format(time.RFC3339)), nil
+		}
+	}
+	return nil, clierr.Input("invalid --%s %q: expected an ISO-8601 time such as 2024-01-31T17:00:00Z", flag, value)
+}
+
+// checkTimeOrder rejects an end time that is not after the start time. Both
+// are values returned by parseISOTime, so they compare as strings.
+func checkTimeOrder(startFlag string, start *string, endFlag string, end *string) error {
+	if start != nil && end != nil && *end <= *start {
+		return clierr.Input("--%s must be after --%s", endFlag, startFlag)
+	}
+	return nil
+}
+
 // businessCmd represents the business command
 var businessCmd = &cobra.Command{
 	Use:     "business",
@@ -41,12 +89,13 @@ var bizTransactionsCmd = &cobra.Command{
 }
 
 var bizListTransactionsCmd = &cobra.Command{
-	Use:        "list",
-	Aliases:    []string{"ls", "show"},
-	SuggestFor: []string{"lst", "lsit", "lists"},
-	Short:      "List business transactions",
-	Long:       `List all transactions for your organization`,
-	PreRun:     initializeForAPICall,
+	Use:         "list",
+	Aliases:     []string{"ls", "show"},
+	SuggestFor:  []string{"lst", "lsit", "lists"},
+	Short:       "List business transactions",
+	Long:        `List all transactions for your organization`,
+	Annotations: map[string]string{annotationColumns: "id,transaction_name,detailed_status,signer_info.email,date_created"},
+	PreRun:      initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
@@ -54,10 +103,8 @@ var bizListTransactionsCmd = &cobra.Command{
 		dateStart, _ := cmd.Flags().GetString("created-start")
 		dateEnd, _ := cmd.Flags().GetString("created-end")
 
-		// Build query parameters
+		// Build query parameters; paging is set per request
 		params := &business.GetAllTransactionsParams{
-			Limit:              ptr(limit),
-			Offset:             ptr(offset),
 			DocumentUrlVersion: ptr(business.GetAllTransactionsParamsDocumentUrlVersionV2),
 		}
 
@@ -70,8 +117,7 @@ var bizListTransactionsCmd = &cobra.Command{
 		if dateStart != "" {
 			t, err := time.Parse("2006-01-02", dateStart)
 			if err != nil {
-				fmt.Printf("Error parsing created-start date: %v\n", err)
-				os.Exit(1)
+				clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to parse created-start date"))
 			}
 			params.CreatedDateStart = &t
 		}
@@ -79,21 +125,27 @@ var bizListTransactionsCmd = &cobra.Command{
 		if dateEnd != "" {
 			t, err := time.Parse("2006-01-02", dateEnd)
 			if err != nil {
-				fmt.Printf("Error parsing created-end date: %v\n", err)
-				os.Exit(1)
+				clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to parse created-end date"))
 			}
 			params.CreatedDateEnd = &t
 		}
 
-		// Make API call using SDK
 		client := getBusinessClient()
-		resp, err := client.GetAllTransactionsWithResponse(context.Background(), params)
-		if err != nil {
-			fmt.Println("Error fetching transactions:", err)
-			os.Exit(1)
-		}
-
-		PrintResponse(resp.Body)
+		runList(cmd, listPages{
+			style:       utils.PageOffset,
+			first:       utils.PageRequest{Offset: offset, Limit: limit},
+			maxPageSize: 1000,
+			fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				pageParams := *params
+				pageParams.Limit = ptr(page.Limit)
+				pageParams.Offset = ptr(page.Offset)
+				resp, err := client.GetAllTransactionsWithResponse(ctx, &pageParams)
+				if err := responseError(resp, err, "failed to fetch transactions"); err != nil {
+					return nil, err
+				}
+				return resp.Body, nil
+			},
+		})
 	},
 }
 
@@ -114,10 +166,7 @@ var bizGetTransactionCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.GetTransactionWithResponse(context.Background(), transactionID, params)
-		if err != nil {
-			fmt.Println("Error fetching transaction:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to fetch transaction")
 
 		PrintResponse(resp.Body)
 	},
@@ -128,58 +177,229 @@ var bizCreateTransactionCmd = &cobra.Command{
 	Aliases:    []string{"c"},
 	SuggestFor: []string{"creat", "craete", "make"},
 	Short:      "Create a business transaction",
-	Long:       `Create a new transaction with signers and documents`,
-	PreRun:     initializeForAPICall,
+	Long: `Create a new transaction with signers and documents.
+
+The primary signer is given with --email and the name flags, or as the first
+--signer. --signer and --document may be repeated:
+
+  proof business transactions create \
+    --signer email=jane@example.com,first=Jane,last=Doe,phone=5555550123 \
+    --signer email=john@example.com,first=John,last=Doe \
+    --document deed.pdf \
+    --document disclosure.pdf,requirement=esign \
+    --cc agent@example.com \
+    --notary-instruction "Signers are married; verify both IDs"
+
+Alternatively describe the whole request in a YAML or JSON manifest with -f.
+Documents may be local paths (relative to the manifest), URLs or base64:
+
+  signer:
+    email: jane@example.com
+  documents:
+    - deed.pdf
+    - resource: https://example.com/disclosure.pdf
+      requirement: esign`,
+	PreRun: initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
+		queryParams := &business.CreateTransactionParams{
+			DocumentUrlVersion: ptr(business.CreateTransactionParamsDocumentUrlVersionV2),
+		}
+
+		if manifestPath, _ := cmd.Flags().GetString("file"); manifestPath != "" {
+			if err := manifestFlagConflict(cmd); err != nil {
+				clierr.Exit(err)
+			}
+			payload, err := loadManifest(manifestPath, businessManifest)
+			if err != nil {
+				clierr.Exit(err)
+			}
+
+			client := getBusinessClient()
+			resp, err := client.CreateTransactionWithBodyWithResponse(context.Background(), queryParams, "application/json", bytes.NewReader(payload))
+			checkResponse(resp, err, "failed to create transaction")
+
+			PrintResponse(resp.Body)
+			return
+		}
+
 		email, _ := cmd.Flags().GetString("email")
 		firstName, _ := cmd.Flags().GetString("first-name")
 		lastName, _ := cmd.Flags().GetString("last-name")
-		documentPath, _ := cmd.Flags().GetString("document")
+		signerSpecs, _ := cmd.Flags().GetStringArray("signer")
+		documentSpecs, _ := cmd.Flags().GetStringArray("document")
+		cosignerSpec, _ := cmd.Flags().GetString("cosigner")
+		ccEmails, _ := cmd.Flags().GetStringSlice("cc")
+		notaryNotes, _ := cmd.Flags().GetStringArray("notary-instruction")
+		notaryID, _ := cmd.Flags().GetString("notary-id")
+		redirectURL, _ := cmd.Flags().GetString("redirect-url")
+		redirectMessage, _ := cmd.Flags().GetString("redirect-message")
 		transactionName, _ := cmd.Flags().GetString("name")
 		draft, _ := cmd.Flags().GetBool("draft")
 		transactionType, _ := cmd.Flags().GetString("type")
+		middleName, _ := cmd.Flags().GetString("middle-name")
+		phoneNumber, _ := cmd.Flags().GetString("phone-number")
+		messageToSigner, _ := cmd.Flags().GetString("message-to-signer")
+		messageSubject, _ := cmd.Flags().GetString("message-subject")
+		activationTime, _ := cmd.Flags().GetString("activation-time")
+		expiry, _ := cmd.Flags().GetString("expiry")
+		authRequirement, _ := cmd.Flags().GetString("auth-requirement")
+		payer, _ := cmd.Flags().GetString("payer")
+		externalID, _ := cmd.Flags().GetString("external-id")
+		suppressEmail, _ := cmd.Flags().GetBool("suppress-email")
+		requireSecondaryID, _ := cmd.Flags().GetBool("require-secondary-photo-id")
+
+		if (email == "" && len(signerSpecs) == 0) || len(documentSpecs) == 0 {
+			clierr.Exit(clierr.Input("a signer (--email or --signer) and at least one --document are required, or a manifest with --file"))
+		}
+		if err := checkPrimarySignerFlags(cmd.Flags()); err != nil {
+			clierr.Exit(err)
+		}
+
+		// Validate everything before touching the network
+		var signers []signerSpec
+		for _, spec := range signerSpecs {
+			signer, err := parseSignerSpec(spec)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			signers = append(signers, signer)
+		}
+
+		// The primary signer comes from --email, or else the first --signer
+		var primary business.Signer
+		if email != "" {
+			primary = business.Signer{
+				Email:       email,
+				FirstName:   ptrIfNotEmpty(firstName),
+				MiddleName:  ptrIfNotEmpty(middleName),
+				LastName:    ptrIfNotEmpty(lastName),
+				PhoneNumber: ptrIfNotEmpty(phoneNumber),
+			}
+		} else {
+			primary = signers[0].businessSigner()
+			signers = signers[1:]
+		}
 
-		if email == "" || documentPath == "" {
-			fmt.Println("Error: email and document are required")
-			os.Exit(1)
+		var documents []documentSpec
+		for _, spec := range documentSpecs {
+			document, err := parseDocumentSpec(spec)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			documents = append(documents, document)
 		}
 
-		// Read the document file
-		documentData, err := os.ReadFile(documentPath)
+		cosigner, err := parseCosignerSpec(cosignerSpec)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		ccParam, err := parseCCEmails(ccEmails)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		instructions, err := parseNotaryInstructions(notaryNotes)
 		if err != nil {
-			fmt.Println("Error reading document file:", err)
-			os.Exit(1)
+			clierr.Exit(err)
 		}
 
-		// Encode document to base64
-		documentBase64 := base64.StdEncoding.EncodeToString(documentData)
+		authParam, err := parseEnum("auth-requirement", authRequirement,
+			business.TransactionCreateParamsAuthenticationRequirementSms,
+			business.TransactionCreateParamsAuthenticationRequirementNone)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		if authParam != nil && *authParam == business.TransactionCreateParamsAuthenticationRequirementSms {
+			if primary.PhoneNumber == nil {
+				clierr.Exit(clierr.Input("--phone-number is required when --auth-requirement is sms"))
+			}
+			for _, signer := range signers {
+				if signer.Phone == "" {
+					clierr.Exit(clierr.Input("signer %s needs a phone when --auth-requirement is sms", signer.Email))
+				}
+			}
+		}
 
-		// Build query parameters
-		queryParams := &business.CreateTransactionParams{
-			DocumentUrlVersion: ptr(business.CreateTransactionParamsDocumentUrlVersionV2),
+		payerParam, err := parseEnum("payer", payer,
+			business.TransactionCreateParamsPayerSigner,
+			business.TransactionCreateParamsPayerSender)
+		if err != nil {
+			clierr.Exit(err)
+		}
+
+		activationParam, err := parseISOTime("activation-time", activationTime)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		expiryParam, err := parseISOTime("expiry", expiry)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		if err := checkTimeOrder("activation-time", activationParam, "expiry", expiryParam); err != nil {
+			clierr.Exit(err)
+		}
+
+		// Read local documents and encode them to base64
+		resolved := make([]business.DocumentCreationParams, 0, len(documents))
+		for _, document := range documents {
+			params, err := resolveDocument(document)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			resolved = append(resolved, params)
 		}
 
 		// Build request body
-		body := business.CreateTransactionJSONRequestBody{
-			Signer: business.Signer{
-				Email:     email,
-				FirstName: ptrIfNotEmpty(firstName),
-				LastName:  ptrIfNotEmpty(lastName),
+		body := transactionCreateBody{
+			TransactionCreateParams: business.TransactionCreateParams{
+				Signer:                    primary,
+				Cosigner:                  cosigner,
+				CcRecipientEmails:         ccParam,
+				NotaryInstructions:        instructions,
+				NotaryId:                  ptrIfNotEmpty(notaryID),
+				Draft:                     ptr(draft),
+				TransactionName:           ptrIfNotEmpty(transactionName),
+				TransactionType:           ptrIfNotEmpty(transactionType),
+				MessageToSigner:           ptrIfNotEmpty(messageToSigner),
+				MessageSubject:            ptrIfNotEmpty(messageSubject),
+				ActivationTime:            activationParam,
+				Expiry:                    expiryParam,
+				AuthenticationRequirement: authParam,
+				Payer:                     payerParam,
+				ExternalId:                ptrIfNotEmpty(externalID),
+				OrganizationId:            ptrIfNotEmpty(organizationFlag(cmd, "organization-id")),
 			},
-			Documents:       ptr([]string{documentBase64}),
-			Draft:           ptr(draft),
-			TransactionName: ptrIfNotEmpty(transactionName),
-			TransactionType: ptrIfNotEmpty(transactionType),
+			Documents: resolved,
+		}
+		if len(signers) > 0 {
+			additional := make([]business.Signers, len(signers))
+			for i, signer := range signers {
+				additional[i] = signer.businessSigners()
+			}
+			body.Signers = &additional
+		}
+		if redirectURL != "" || redirectMessage != "" {
+			body.Redirect = &business.Redirect{
+				Url:     ptrIfNotEmpty(redirectURL),
+				Message: ptrIfNotEmpty(redirectMessage),
+			}
+		}
+		if cmd.Flags().Changed("suppress-email") {
+			body.SuppressEmail = ptr(suppressEmail)
+		}
+		if cmd.Flags().Changed("require-secondary-photo-id") {
+			body.RequireSecondaryPhotoId = ptr(requireSecondaryID)
 		}
 
-		// Make API call using SDK
-		client := getBusinessClient()
-		resp, err := client.CreateTransactionWithResponse(context.Background(), queryParams, body)
+		payload, err := json.Marshal(body)
 		if err != nil {
-			fmt.Println("Error creating transaction:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.Wrap(clierr.KindGeneral, err, "failed to encode request"))
 		}
 
+		// Make API call using SDK
+		client := getBusinessClient()
+		resp, err := client.CreateTransactionWithBodyWithResponse(context.Background(), queryParams, "application/json", bytes.NewReader(payload))
+		checkResponse(resp, err, "failed to create transaction")
+
 		PrintResponse(resp.Body)
 	},
 }
@@ -196,14 +416,9 @@ var bizDeleteTransactionCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.DeleteTransactionWithResponse(context.Background(), transactionID)
-		if err != nil {
-			fmt.Println("Error deleting transaction:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to delete transaction")
 
-		if resp.StatusCode() >= 200 && resp.StatusCode() < 300 {
-			fmt.Println("Transaction deleted successfully")
-		}
+		printSuccess("Transaction deleted successfully")
 		PrintVerbose(string(resp.Body))
 	},
 }
@@ -224,10 +439,7 @@ var bizActivateTransactionCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.ActivateDraftTransactionWithResponse(context.Background(), transactionID, params)
-		if err != nil {
-			fmt.Println("Error activating transaction:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to activate transaction")
 
 		PrintResponse(resp.Body)
 	},
@@ -253,10 +465,7 @@ var bizRecallTransactionCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.RecallTransactionWithResponse(context.Background(), transactionID, params)
-		if err != nil {
-			fmt.Println("Error recalling transaction:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to recall transaction")
 
 		PrintResponse(resp.Body)
 	},
@@ -282,10 +491,7 @@ var bizResendEmailCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.ResendTransactionEmailWithResponse(context.Background(), transactionID, params)
-		if err != nil {
-			fmt.Println("Error resending email:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to resend email")
 
 		PrintResponse(resp.Body)
 	},
@@ -311,10 +517,7 @@ var bizResendSMSCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.ResendTransactionSMSWithResponse(context.Background(), transactionID, params, business.ResendTransactionSMSJSONRequestBody{})
-		if err != nil {
-			fmt.Println("Error resending SMS:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to resend SMS")
 
 		PrintResponse(resp.Body)
 	},
@@ -332,10 +535,7 @@ var bizGetEligibleNotariesCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.GetAllEligibleNotariesWithResponse(context.Background(), transactionID)
-		if err != nil {
-			fmt.Println("Error getting eligible notaries:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get eligible notaries")
 
 		PrintResponse(resp.Body)
 	},
@@ -378,8 +578,7 @@ var bizAddDocumentCmd = &cobra.Command{
 		// Read the file
 		fileContent, err := os.ReadFile(filePath)
 		if err != nil {
-			fmt.Println("Error reading file:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to read file"))
 		}
 
 		// Encode document to base64
@@ -415,10 +614,7 @@ var bizAddDocumentCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.AddDocumentWithResponse(context.Background(), transactionID, queryParams, body)
-		if err != nil {
-			fmt.Println("Error adding document:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to add document")
 
 		PrintResponse(resp.Body)
 	},
@@ -446,10 +642,7 @@ var bizGetDocumentCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.GetDocumentWithResponse(context.Background(), transactionID, documentID, params)
-		if err != nil {
-			fmt.Println("Error fetching document:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to fetch document")
 
 		PrintResponse(resp.Body)
 	},
@@ -467,14 +660,9 @@ var bizDeleteDocumentCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getBusinessClient()
 		resp, err := client.DeleteDocumentWithResponse(context.Background(), documentID)
-		if err != nil {
-			fmt.Println("Error deleting document:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to delete document")
 
-		if resp.StatusCode() >= 200 && resp.StatusCode() < 300 {
-			fmt.Println("Document deleted successfully")
-		}
+		printSuccess("Document deleted successfully")
 		PrintVerbose(string(resp.Body))
 	},
 }
@@ -497,29 +685,24 @@ var bizGetWebhookCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.GetWebhookURLWithResponse(context.Background())
-		if err != nil {
-			fmt.Println("Error getting webhook:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get webhook")
 
 		PrintResponse(resp.Body)
 	},
 }
 
 var bizListWebhooksCmd = &cobra.Command{
-	Use:    "list",
-	Short:  "List webhooks v2",
-	Long:   `List all webhooks v2 for your organization`,
-	PreRun: initializeForAPICall,
+	Use:         "list",
+	Short:       "List webhooks v2",
+	Long:        `List all webhooks v2 for your organization`,
+	Annotations: map[string]string{annotationColumns: "id,url,enabled,subscriptions"},
+	PreRun:      initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
 		PrintVerbose("Fetching webhooks v2 list")
 
 		client := getBusinessClient()
 		resp, err := client.GetAllWebhooksV2WithResponse(context.Background())
-		if err != nil {
-			fmt.Println("Error listing webhooks:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to list webhooks")
 
 		PrintResponse(resp.Body)
 	},
@@ -538,10 +721,7 @@ var bizGetWebhookV2Cmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.GetWebhookV2WithResponse(context.Background(), webhookID)
-		if err != nil {
-			fmt.Println("Error getting webhook v2:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get webhook v2")
 
 		PrintResponse(resp.Body)
 	},
@@ -558,8 +738,7 @@ var bizCreateWebhookCmd = &cobra.Command{
 		header, _ := cmd.Flags().GetString("header")
 
 		if url == "" {
-			fmt.Println("Error: url is required")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("url is required"))
 		}
 
 		body := business.CreateWebhookV2JSONRequestBody{
@@ -574,10 +753,7 @@ var bizCreateWebhookCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.CreateWebhookV2WithResponse(context.Background(), body)
-		if err != nil {
-			fmt.Println("Error creating webhook v2:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to create webhook v2")
 
 		PrintResponse(resp.Body)
 	},
@@ -609,10 +785,7 @@ var bizUpdateWebhookCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.UpdateWebhookV2WithResponse(context.Background(), webhookID, body)
-		if err != nil {
-			fmt.Println("Error updating webhook v2:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to update webhook v2")
 
 		PrintResponse(resp.Body)
 	},
@@ -631,14 +804,9 @@ var bizDeleteWebhookCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.DeleteWebhookV2WithResponse(context.Background(), webhookID)
-		if err != nil {
-			fmt.Println("Error deleting webhook v2:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to delete webhook v2")
 
-		if resp.StatusCode() >= 200 && resp.StatusCode() < 300 {
-			fmt.Println("Webhook v2 deleted successfully")
-		}
+		printSuccess("Webhook v2 deleted successfully")
 	},
 }
 
@@ -653,14 +821,29 @@ var bizGetWebhookEventsCmd = &cobra.Command{
 
 		PrintVerbose("Fetching webhook v2 events: " + webhookID)
 
-		client := getBusinessClient()
-		resp, err := client.GetWebhookEventsV2WithResponse(context.Background(), webhookID, nil)
-		if err != nil {
-			fmt.Println("Error getting webhook v2 events:", err)
-			os.Exit(1)
-		}
+		limit, _ := cmd.Flags().GetInt("limit")
+		offset, _ := cmd.Flags().GetInt("offset")
 
-		PrintResponse(resp.Body)
+		client := getBusinessClient()
+		runList(cmd, listPages{
+			style:       utils.PageOffset,
+			first:       utils.PageRequest{Offset: offset, Limit: limit},
+			maxPageSize: 100,
+			fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				params := &business.GetWebhookEventsV2Params{}
+				if page.Limit > 0 {
+					params.Limit = ptr(page.Limit)
+				}
+				if page.Offset > 0 {
+					params.Offset = ptr(page.Offset)
+				}
+				resp, err := client.GetWebhookEventsV2WithResponse(ctx, webhookID, params)
+				if err := responseError(resp, err, "failed to get webhook v2 events"); err != nil {
+					return nil, err
+				}
+				return resp.Body, nil
+			},
+		})
 	},
 }
 
@@ -674,10 +857,7 @@ var bizGetWebhookSubscriptionsCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.GetWebhookSubscriptionsV2WithResponse(context.Background())
-		if err != nil {
-			fmt.Println("Error getting webhook v2 subscriptions:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get webhook v2 subscriptions")
 
 		PrintResponse(resp.Body)
 	},
@@ -691,12 +871,13 @@ var bizNotariesCmd = &cobra.Command{
 }
 
 var bizListNotariesCmd = &cobra.Command{
-	Use:    "list",
-	Short:  "List notaries",
-	Long:   `List all notaries for your organization`,
-	PreRun: initializeForAPICall,
+	Use:         "list",
+	Short:       "List notaries",
+	Long:        `List all notaries for your organization`,
+	Annotations: map[string]string{annotationColumns: "id,first_name,last_name,email,us_state_abbr,status"},
+	PreRun:      initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
-		orgID, _ := cmd.Flags().GetString("org-id")
+		orgID := organizationFlag(cmd, "org-id")
 		state, _ := cmd.Flags().GetString("state")
 
 		params := &business.GetAllNotariesParams{}
@@ -711,10 +892,7 @@ var bizListNotariesCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.GetAllNotariesWithResponse(context.Background(), params)
-		if err != nil {
-			fmt.Println("Error listing notaries:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to list notaries")
 
 		PrintResponse(resp.Body)
 	},
@@ -733,10 +911,7 @@ var bizGetNotaryCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.GetNotaryWithResponse(context.Background(), notaryID)
-		if err != nil {
-			fmt.Println("Error getting notary:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get notary")
 
 		PrintResponse(resp.Body)
 	},
@@ -755,8 +930,7 @@ var bizCreateNotaryCmd = &cobra.Command{
 		state, _ := cmd.Flags().GetString("state")
 
 		if email == "" || firstName == "" || lastName == "" || state == "" {
-			fmt.Println("Error: email, first-name, last-name, and state are required")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("email, first-name, last-name, and state are required"))
 		}
 
 		body := business.CreateNotaryJSONRequestBody{
@@ -773,10 +947,7 @@ var bizCreateNotaryCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.CreateNotaryWithResponse(context.Background(), body)
-		if err != nil {
-			fmt.Println("Error creating notary:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to create notary")
 
 		PrintResponse(resp.Body)
 	},
@@ -795,14 +966,9 @@ var bizDeleteNotaryCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.DeleteNotaryWithResponse(context.Background(), notaryID)
-		if err != nil {
-			fmt.Println("Error deleting notary:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to delete notary")
 
-		if resp.StatusCode() >= 200 && resp.StatusCode() < 300 {
-			fmt.Println("Notary deleted successfully")
-		}
+		printSuccess("Notary deleted successfully")
 	},
 }
 
@@ -814,32 +980,37 @@ var bizTemplatesCmd = &cobra.Command{
 }
 
 var bizListTemplatesCmd = &cobra.Command{
-	Use:    "list",
-	Short:  "List templates",
-	Long:   `List all document templates for your organization`,
-	PreRun: initializeForAPICall,
+	Use:         "list",
+	Short:       "List templates",
+	Long:        `List all document templates for your organization`,
+	Annotations: map[string]string{annotationColumns: "id,name,created_at,updated_at"},
+	PreRun:      initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
 
-		params := &business.GetAllTemplatesParams{}
-		if limit > 0 {
-			params.Limit = ptr(limit)
-		}
-		if offset > 0 {
-			params.Offset = ptr(offset)
-		}
-
 		PrintVerbose("Fetching templates")
 
 		client := getBusinessClient()
-		resp, err := client.GetAllTemplatesWithResponse(context.Background(), params)
-		if err != nil {
-			fmt.Println("Error listing templates:", err)
-			os.Exit(1)
-		}
-
-		PrintResponse(resp.Body)
+		runList(cmd, listPages{
+			style:       utils.PageOffset,
+			first:       utils.PageRequest{Offset: offset, Limit: limit},
+			maxPageSize: 1000,
+			fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				params := &business.GetAllTemplatesParams{}
+				if page.Limit > 0 {
+					params.Limit = ptr(page.Limit)
+				}
+				if page.Offset > 0 {
+					params.Offset = ptr(page.Offset)
+				}
+				resp, err := client.GetAllTemplatesWithResponse(ctx, params)
+				if err := responseError(resp, err, "failed to list templates"); err != nil {
+					return nil, err
+				}
+				return resp.Body, nil
+			},
+		})
 	},
 }
 
@@ -858,13 +1029,12 @@ var bizCreateReferralCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("name")
 		coverPayment, _ := cmd.Flags().GetBool("cover-payment")
-		organizationID, _ := cmd.Flags().GetString("organization-id")
+		organizationID := organizationFlag(cmd, "organization-id")
 		redirectURL, _ := cmd.Flags().GetString("redirect-url")
 		useBranding, _ := cmd.Flags().GetBool("use-branding")
 
 		if name == "" {
-			fmt.Println("Error: name is required")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("name is required"))
 		}
 
 		body := business.CreateReferralJSONRequestBody{
@@ -877,10 +1047,7 @@ var bizCreateReferralCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.CreateReferralWithResponse(context.Background(), body)
-		if err != nil {
-			fmt.Println("Error creating referral campaign:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to create referral campaign")
 
 		PrintResponse(resp.Body)
 	},
@@ -903,10 +1070,7 @@ var bizGenerateReferralCodeCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.GenerateReferralCodeWithResponse(context.Background(), referralCampaignID, body)
-		if err != nil {
-			fmt.Println("Error generating referral code:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to generate referral code")
 
 		PrintResponse(resp.Body)
 	},
@@ -926,13 +1090,12 @@ var bizCreateIntegrationCmd = &cobra.Command{
 	PreRun: initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
 		name, _ := cmd.Flags().GetString("name")
-		orgID, _ := cmd.Flags().GetString("org-id")
+		orgID := organizationFlag(cmd, "org-id")
 		accountID, _ := cmd.Flags().GetString("account-id")
 		environment, _ := cmd.Flags().GetString("environment")
 
 		if name == "" || orgID == "" {
-			fmt.Println("Error: name and org-id are required")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("name and org-id are required"))
 		}
 
 		// Validate integration name
@@ -943,8 +1106,7 @@ var bizCreateIntegrationCmd = &cobra.Command{
 		case "DOCUTECH":
 			integrationName = business.DOCUTECH
 		default:
-			fmt.Println("Error: name must be one of: ADOBE, DOCUTECH")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("name must be one of: ADOBE, DOCUTECH"))
 		}
 
 		body := business.CreateIntegrationJSONRequestBody{
@@ -967,10 +1129,7 @@ var bizCreateIntegrationCmd = &cobra.Command{
 
 		client := getBusinessClient()
 		resp, err := client.CreateIntegrationWithResponse(context.Background(), body)
-		if err != nil {
-			fmt.Println("Error creating integration:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to create integration")
 
 		PrintResponse(resp.Body)
 	},
@@ -1038,11 +1197,20 @@ func init() {
 	bizListTransactionsCmd.Flags().String("created-end", "", "Filter by created date end (YYYY-MM-DD)")
 	bizListTransactionsCmd.Flags().String("last-updated-start", "", "Filter by last updated date start (YYYY-MM-DD)")
 	bizListTransactionsCmd.Flags().String("last-updated-end", "", "Filter by last updated date end (YYYY-MM-DD)")
+	addPaginationFlags(bizListTransactionsCmd)
 
-	bizCreateTransactionCmd.Flags().String("email", "", "Signer's email address (required)")
+	bizCreateTransactionCmd.Flags().StringP("file", "f", "", "YAML or JSON manifest describing the transaction (- for stdin)")
+	bizCreateTransactionCmd.Flags().String("email", "", "Primary signer's email address (required unless --signer is given)")
 	bizCreateTransactionCmd.Flags().String("first-name", "", "Signer's first name")
 	bizCreateTransactionCmd.Flags().String("last-name", "", "Signer's last name")
-	bizCreateTransactionCmd.Flags().String("document", "", "Path to document file (required)")
+	bizCreateTransactionCmd.Flags().StringArray("document", nil, "Document file path or URL, optionally with ,requirement=esign and ,name=File.pdf (repeatable, required)")
+	bizCreateTransactionCmd.Flags().StringArray("signer", nil, "Additional signer as email=...,first=...,last=...,phone=...[,middle=,order=,requirement=,external-id=] (repeatable)")
+	bizCreateTransactionCmd.Flags().String("cosigner", "", "Cosigner as first=...,last=...[,requirement=esign|identify|verify]")
+	bizCreateTransactionCmd.Flags().StringSlice("cc", nil, "Email address to CC on the transaction (repeatable or comma-separated)")
+	bizCreateTransactionCmd.Flags().StringArray("notary-instruction", nil, "Instruction for the notary, up to 500 characters (repeatable)")
+	bizCreateTransactionCmd.Flags().String("notary-id", "", "User ID (us_...) of the notary to assign")
+	bizCreateTransactionCmd.Flags().String("redirect-url", "", "URL to send the signer to once the meeting is complete")
+	bizCreateTransactionCmd.Flags().String("redirect-message", "", "Message shown to the signer with the redirect")
 	bizCreateTransactionCmd.Flags().String("name", "", "Transaction name")
 	bizCreateTransactionCmd.Flags().String("type", "", "Transaction type")
 	bizCreateTransactionCmd.Flags().Bool("draft", false, "Create transaction as draft")
@@ -1057,6 +1225,7 @@ func init() {
 	bizCreateTransactionCmd.Flags().Bool("require-secondary-photo-id", false, "Require two forms of photo ID")
 	bizCreateTransactionCmd.Flags().String("payer", "", "Who pays for the transaction (signer or sender)")
 	bizCreateTransactionCmd.Flags().String("external-id", "", "External system ID")
+	bizCreateTransactionCmd.Flags().String("organization-id", "", "Child organization to create the transaction in (default: the profile's organization)")
 
 	// Add flags for document commands
 	bizAddDocumentCmd.Flags().String("filename", "", "Plain language name for the document")
@@ -1087,8 +1256,12 @@ func init() {
 	bizUpdateWebhookCmd.Flags().StringSlice("events", []string{}, "Event subscriptions to subscribe to")
 	bizUpdateWebhookCmd.Flags().String("header", "", "Header value to pass through every request (e.g. X-Custom-Header:X-Custom-Key)")
 
+	bizGetWebhookEventsCmd.Flags().Int("limit", 0, "How many events to return (default: 20, max: 100)")
+	bizGetWebhookEventsCmd.Flags().Int("offset", 0, "Number of events to skip for pagination")
+	addPaginationFlags(bizGetWebhookEventsCmd)
+
 	// Add flags for notary commands
-	bizListNotariesCmd.Flags().String("org-id", "", "Organization ID")
+	bizListNotariesCmd.Flags().String("org-id", "", "Organization ID (default: the profile's organization)")
 	bizListNotariesCmd.Flags().String("state", "", "Two-letter state abbreviation")
 
 	bizCreateNotaryCmd.Flags().String("email", "", "Notary's email address")
@@ -1100,11 +1273,12 @@ func init() {
 	// Add flags for template commands
 	bizListTemplatesCmd.Flags().Int("limit", 0, "How many results to return (default: 100, max: 1000)")
 	bizListTemplatesCmd.Flags().Int("offset", 0, "Number of results to skip for pagination")
+	addPaginationFlags(bizListTemplatesCmd)
 
 	// Add flags for referral commands
 	bizCreateReferralCmd.Flags().String("name", "", "Name of the new campaign (required)")
 	bizCreateReferralCmd.Flags().Bool("cover-payment", false, "Will the organization pay for these referred transactions?")
-	bizCreateReferralCmd.Flags().String("organization-id", "", "ID of organization to create the campaign for (child orgs only)")
+	bizCreateReferralCmd.Flags().String("organization-id", "", "ID of organization to create the campaign for (child orgs only; default: the profile's organization)")
 	bizCreateReferralCmd.Flags().String("redirect-url", "", "URL that customers will be sent to from the referral")
 	bizCreateReferralCmd.Flags().Bool("use-branding", false, "Will the referred transactions display the orgs branding?")
 
@@ -1112,7 +1286,7 @@ func init() {
 
 	// Add flags for integration commands
 	bizCreateIntegrationCmd.Flags().String("name", "", "Integration name (ADOBE or DOCUTECH)")
-	bizCreateIntegrationCmd.Flags().String("org-id", "", "Organization ID")
+	bizCreateIntegrationCmd.Flags().String("org-id", "", "Organization ID (default: the profile's organization)")
 	bizCreateIntegrationCmd.Flags().String("account-id", "", "Integration account ID")
 	bizCreateIntegrationCmd.Flags().String("environment", "", "Integration environment")
 
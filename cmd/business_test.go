@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/business"
 )
 
 func TestPtr_Int(t *testing.T) {
@@ -152,3 +155,72 @@ func TestPtr_Map(t *testing.T) {
 	assert.Equal(t, input["one"], (*result)["one"])
 	assert.Equal(t, input["two"], (*result)["two"])
 }
+
+func TestParseEnum(t *testing.T) {
+	allowed := []business.TransactionCreateParamsPayer{
+		business.TransactionCreateParamsPayerSigner,
+		business.TransactionCreateParamsPayerSender,
+	}
+
+	value, err := parseEnum("payer", "", allowed...)
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	value, err = parseEnum("payer", "sender", allowed...)
+	require.NoError(t, err)
+	assert.Equal(t, business.TransactionCreateParamsPayerSender, *value)
+
+	_, err = parseEnum("payer", "notary", allowed...)
+	require.Error(t, err)
+	assert.Equal(t, `invalid --payer "notary" (allowed: signer, sender)`, err.Error())
+
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
+
+	auth, err := parseEnum[business.TransactionCreateParamsAuthenticationRequirement]("auth-requirement", "sms")
+	require.NoError(t, err)
+	assert.Equal(t, business.TransactionCreateParamsAuthenticationRequirementSms, *auth)
+}
+
+func TestParseISOTime(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"2024-01-31T17:00:00Z", "2024-01-31T17:00:00Z"},
+		{"2024-01-31T12:00:00-05:00", "2024-01-31T17:00:00Z"},
+		{"2024-01-31T12:00:00-0500", "2024-01-31T17:00:00Z"},
+		{"2024-01-31T17:00Z", "2024-01-31T17:00:00Z"},
+		{"2024-01-31", "2024-01-31T00:00:00Z"},
+	}
+
+	for _, tc := range testCases {
+		value, err := parseISOTime("expiry", tc.input)
+		require.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, *value, tc.input)
+	}
+
+	value, err := parseISOTime("expiry", "")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	for _, input := range []string{"tomorrow", "31/01/2024", "2024-13-01"} {
+		_, err := parseISOTime("expiry", input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestCheckTimeOrder(t *testing.T) {
+	start, _ := parseISOTime("activation-time", "2024-01-31T12:00:00-05:00")
+	later, _ := parseISOTime("expiration-time", "2024-02-01")
+	same, _ := parseISOTime("expiration-time", "2024-01-31T17:00:00Z")
+
+	assert.NoError(t, checkTimeOrder("activation-time", start, "expiration-time", later))
+	assert.NoError(t, checkTimeOrder("activation-time", nil, "expiration-time", later))
+	assert.NoError(t, checkTimeOrder("activation-time", start, "expiration-time", nil))
+
+	err := checkTimeOrder("activation-time", start, "expiration-time", same)
+	requireInputError(t, err)
+	assert.EqualError(t, err, "--expiration-time must be after --activation-time")
+}
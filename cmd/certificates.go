@@ -0,0 +1,534 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/certificates"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
+)
+
+// maxSignDigests is the most digests the sign endpoint accepts in one request
+const maxSignDigests = 25
+
+// certificateRequest is the body of the certificate create endpoints. The
+// generated SDK types use the specification's "common_name *required" and
+// "csr *required" property names, so the body is encoded here instead.
+type certificateRequest struct {
+	CertificateProfile string `json:"certificate_profile,omitempty"`
+	CommonName         string `json:"common_name,omitempty"`
+	CSR                string `json:"csr,omitempty"`
+}
+
+// certificateResult is the envelope of single-certificate responses
+type certificateResult struct {
+	Result *certificates.OrganizationCertificateFullResponse `json:"result"`
+}
+
+// certificatesCmd represents the certificates command
+var certificatesCmd = &cobra.Command{
+	Use:     "certificates",
+	Aliases: []string{"certs"},
+	Short:   "Organization certificate operations",
+	Long: `Commands for managing your organization's document-signing certificates.
+
+The assurance level of a new certificate is chosen with --cert-profile, since
+--profile already selects the CLI configuration profile.`,
+}
+
+var certsListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List organization certificates",
+	Long:        `List your organization's certificates, including revoked ones, most recent first`,
+	Annotations: map[string]string{annotationColumns: "id,subject,serial_number,valid_to,revoked_at"},
+	PreRun:      initializeForAPICall,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		offset, _ := cmd.Flags().GetInt("offset")
+
+		client := getCertificatesClient()
+		runList(cmd, listPages{
+			style:       utils.PageOffset,
+			first:       utils.PageRequest{Offset: offset, Limit: limit},
+			maxPageSize: 100,
+			fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				params := &certificates.GetV1CertificatesParams{}
+				if page.Limit > 0 {
+					params.Limit = ptr(strconv.Itoa(page.Limit))
+				}
+				if page.Offset > 0 {
+					params.Offset = ptr(strconv.Itoa(page.Offset))
+				}
+				resp, err := client.GetV1CertificatesWithResponse(ctx, params)
+				if err := responseError(resp, err, "failed to list certificates"); err != nil {
+					return nil, err
+				}
+				return resp.Body, nil
+			},
+		})
+	},
+}
+
+var certsGetCmd = &cobra.Command{
+	Use:    "get <certificate-id>",
+	Short:  "Get an organization certificate",
+	Long:   `Get details of a certificate, optionally writing its PEM certificate chain to a file`,
+	Args:   cobra.ExactArgs(1),
+	PreRun: initializeForAPICall,
+	Run: func(cmd *cobra.Command, args []string) {
+		out, _ := cmd.Flags().GetString("out")
+
+		client := getCertificatesClient()
+		resp, err := client.GetV1CertificatesIdWithResponse(context.Background(), args[0])
+		checkResponse(resp, err, "failed to fetch certificate")
+
+		if out != "" {
+			if err := writeCertificateChain(resp.Body, out, 0644); err != nil {
+				clierr.Exit(err)
+			}
+		}
+		PrintResponse(resp.Body)
+	},
+}
+
+var certsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Issue an organization certificate",
+	Long: `Issue a new organization certificate.
+
+With --common-name, Proof generates and keeps the private key. With --csr, the
+certificate is issued for a PEM certificate signing request whose key you keep;
+its subject Country and Organization must match your organization's records.
+
+With --generate-key, an ECDSA P-256 key and a CSR for it are created locally
+and the CSR is submitted; the private key never leaves this machine. The key is
+written to --key-out (default: --out with a .key extension) before the request
+is sent, and the certificate chain to --out, both readable only by you. The
+subject is taken from --common-name, --organization and --country; the
+organization defaults to your organization's name, and the common name to
+"<organization> Signing Authority".
+
+--cert-profile selects the assurance level (al1 to al4) your organization is
+compliant with. The global --profile flag selects the CLI configuration profile.`,
+	Example: `  proof certificates create --common-name "Acme Signing Authority" --cert-profile al2 --out acme.pem
+  proof certificates create --csr request.csr --cert-profile al3 --out acme.pem
+  proof certificates create --generate-key --country US --cert-profile al2 --out acme.pem`,
+	PreRun: initializeForAPICall,
+	Run: func(cmd *cobra.Command, args []string) {
+		commonName, _ := cmd.Flags().GetString("common-name")
+		csrPath, _ := cmd.Flags().GetString("csr")
+		certProfile, _ := cmd.Flags().GetString("cert-profile")
+		out, _ := cmd.Flags().GetString("out")
+		generateKey, _ := cmd.Flags().GetBool("generate-key")
+
+		if generateKey {
+			createWithGeneratedKey(cmd)
+			return
+		}
+		if (commonName == "") == (csrPath == "") {
+			clierr.Exit(clierr.Input("specify exactly one of --common-name, --csr or --generate-key"))
+		}
+		body := certificateRequest{CommonName: commonName}
+		if certProfile != "" {
+			profile, err := parseCertificateProfile(certProfile)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			body.CertificateProfile = profile
+		}
+		if csrPath != "" {
+			csr, err := readCSR(csrPath)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			body.CSR = csr
+		}
+
+		payload, err := json.Marshal(body)
+		if err != nil {
+			clierr.Exit(clierr.Wrap(clierr.KindGeneral, err, "failed to encode request"))
+		}
+
+		client := getCertificatesClient()
+		var respBody []byte
+		if body.CSR != "" {
+			resp, err := client.PostV2CertificatesWithBodyWithResponse(context.Background(), "application/json", bytes.NewReader(payload))
+			checkResponse(resp, err, "failed to issue certificate")
+			respBody = resp.Body
+		} else {
+			resp, err := client.PostV1CertificatesWithBodyWithResponse(context.Background(), "application/json", bytes.NewReader(payload))
+			checkResponse(resp, err, "failed to issue certificate")
+			respBody = resp.Body
+		}
+
+		if out != "" {
+			if err := writeCertificateChain(respBody, out, 0644); err != nil {
+				clierr.Exit(err)
+			}
+		}
+		PrintResponse(respBody)
+	},
+}
+
+// createWithGeneratedKey issues a certificate for a key generated on this
+// machine, for certificates create --generate-key
+func createWithGeneratedKey(cmd *cobra.Command) {
+	commonName, _ := cmd.Flags().GetString("common-name")
+	organization, _ := cmd.Flags().GetString("organization")
+	country, _ := cmd.Flags().GetString("country")
+	certProfile, _ := cmd.Flags().GetString("cert-profile")
+	out, _ := cmd.Flags().GetString("out")
+	keyOut, _ := cmd.Flags().GetString("key-out")
+
+	if cmd.Flags().Changed("csr") {
+		clierr.Exit(clierr.Input("--generate-key creates its own CSR and cannot be used with --csr"))
+	}
+	if out == "" {
+		clierr.Exit(clierr.Input("--generate-key requires --out for the issued certificate"))
+	}
+	if keyOut == "" {
+		keyOut = strings.TrimSuffix(out, filepath.Ext(out)) + ".key"
+	}
+	if keyOut == out {
+		clierr.Exit(clierr.Input("--key-out must differ from --out"))
+	}
+	body := certificateRequest{}
+	if certProfile != "" {
+		profile, err := parseCertificateProfile(certProfile)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		body.CertificateProfile = profile
+	}
+
+	var organizationInfo []byte
+	if organization == "" || country == "" {
+		resp, err := getBusinessClient().GetOrganizationInformationWithResponse(context.Background())
+		checkResponse(resp, err, "failed to fetch organization information for the certificate subject")
+		organizationInfo = resp.Body
+	}
+	if dryRun || asCurl {
+		// The lookup was printed instead of sent, so stand in for its answer.
+		// XX is a user-assigned ISO 3166 code that no country uses.
+		if organization == "" {
+			organization = "Organization"
+		}
+		if country == "" {
+			country = "XX"
+		}
+	}
+	subject, err := certificateSubject(commonName, organization, country, organizationInfo)
+	if err != nil {
+		clierr.Exit(err)
+	}
+
+	key, csr, err := generateCSR(subject)
+	if err != nil {
+		clierr.Exit(err)
+	}
+	// Save the key before the request is sent, so an issued certificate is never
+	// left without it. A dry run sends nothing, so it needs no key.
+	if !dryRun && !asCurl {
+		if err := writePrivateKey(key, keyOut); err != nil {
+			clierr.Exit(err)
+		}
+	}
+	body.CSR = csr
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		clierr.Exit(clierr.Wrap(clierr.KindGeneral, err, "failed to encode request"))
+	}
+	resp, err := getCertificatesClient().PostV2CertificatesWithBodyWithResponse(context.Background(), "application/json", bytes.NewReader(payload))
+	checkResponse(resp, err, "failed to issue certificate")
+
+	if err := writeCertificateChain(resp.Body, out, 0600); err != nil {
+		clierr.Exit(err)
+	}
+	PrintResponse(resp.Body)
+}
+
+var certsRevokeCmd = &cobra.Command{
+	Use:   "revoke <certificate-id>",
+	Short: "Revoke an organization certificate",
+	Long: `Revoke a certificate. --reason records why, using an RFC 5280 reason such as
+keyCompromise, affiliationChanged, superseded or cessationOfOperation.`,
+	Args:   cobra.ExactArgs(1),
+	PreRun: initializeForAPICall,
+	Run: func(cmd *cobra.Command, args []string) {
+		reason, _ := cmd.Flags().GetString("reason")
+
+		params := &certificates.DeleteV1CertificatesIdParams{}
+		if reason != "" {
+			params.Reason = ptr(reason)
+		}
+
+		client := getCertificatesClient()
+		resp, err := client.DeleteV1CertificatesIdWithResponse(context.Background(), args[0], params)
+		checkResponse(resp, err, "failed to revoke certificate")
+
+		PrintResponse(resp.Body)
+	},
+}
+
+var certsSignCmd = &cobra.Command{
+	Use:   "sign <certificate-id>",
+	Short: "Sign digests with an organization certificate",
+	Long: fmt.Sprintf(`Sign SHA-256 digests with a certificate whose private key Proof keeps, using
+ECDSA with SHA-256. Give base64-encoded digests with --digest, or files with
+--file to sign their SHA-256 digest. At most %d digests are signed per request.
+Signatures are returned in the order the digests were given.`, maxSignDigests),
+	Example: `  proof certificates sign <certificate-id> --file contract.pdf
+  proof certificates sign <certificate-id> --digest 47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=`,
+	Args:   cobra.ExactArgs(1),
+	PreRun: initializeForAPICall,
+	Run: func(cmd *cobra.Command, args []string) {
+		digests, _ := cmd.Flags().GetStringSlice("digest")
+		files, _ := cmd.Flags().GetStringSlice("file")
+
+		for _, path := range files {
+			digest, err := fileDigest(path)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			digests = append(digests, digest)
+		}
+		if err := validateDigests(digests); err != nil {
+			clierr.Exit(err)
+		}
+
+		body := certificates.PostV1CertificatesIdSignJSONRequestBody{
+			AlgorithmOid: ptr(certificates.N1284010045432),
+			Digests:      &digests,
+		}
+		client := getCertificatesClient()
+		resp, err := client.PostV1CertificatesIdSignWithResponse(context.Background(), args[0], body)
+		checkResponse(resp, err, "failed to sign digests")
+
+		PrintResponse(resp.Body)
+	},
+}
+
+// parseCertificateProfile accepts a certificate profile by its full name or
+// its assurance level, such as al2
+func parseCertificateProfile(value string) (string, error) {
+	profile := strings.ToLower(value)
+	if !strings.HasPrefix(profile, "organization_authenticity_") {
+		profile = "organization_authenticity_" + profile
+	}
+	if !certificates.PostV1CertificatesJSONBodyCertificateProfile(profile).Valid() {
+		return "", clierr.Input("invalid --cert-profile %q: use al1, al2, al3 or al4", value)
+	}
+	return profile, nil
+}
+
+// readCSR reads a PEM certificate signing request from path
+func readCSR(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", clierr.Wrap(clierr.KindInput, err, "failed to read CSR")
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return "", clierr.Input("%s is not a PEM certificate signing request", path)
+	}
+	if _, err := x509.ParseCertificateRequest(block.Bytes); err != nil {
+		return "", clierr.Wrap(clierr.KindInput, err, "invalid CSR "+path)
+	}
+	return string(data), nil
+}
+
+// certificateSubject builds the subject of a generated CSR from the flags,
+// filling in the organization name and country from organizationInfo, the
+// response of GetOrganizationInformation, where a flag was not given
+func certificateSubject(commonName, organization, country string, organizationInfo []byte) (pkix.Name, error) {
+	if len(organizationInfo) > 0 {
+		var info map[string]any
+		if err := json.Unmarshal(organizationInfo, &info); err != nil {
+			return pkix.Name{}, clierr.Wrap(clierr.KindGeneral, err, "failed to parse organization information")
+		}
+		if organization == "" {
+			organization, _ = info["name"].(string)
+		}
+		if country == "" {
+			// The organization record carries no country field today; use one
+			// if it is added, directly or as part of an address
+			for _, path := range []string{"country", "address.country"} {
+				if value, ok := utils.LookupPath(info, path); ok {
+					if s, ok := value.(string); ok && s != "" {
+						country = s
+						break
+					}
+				}
+			}
+		}
+	}
+
+	if organization == "" {
+		return pkix.Name{}, clierr.Input("--organization is required: the organization has no name on record")
+	}
+	if country == "" {
+		return pkix.Name{}, clierr.Input("--country is required: the organization has no country on record")
+	}
+	country = strings.ToUpper(country)
+	if len(country) != 2 || strings.Trim(country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return pkix.Name{}, clierr.Input("invalid --country %q: use a two-letter country code such as US", country)
+	}
+	if commonName == "" {
+		commonName = organization + " Signing Authority"
+	}
+	return pkix.Name{
+		Country:      []string{country},
+		Organization: []string{organization},
+		CommonName:   commonName,
+	}, nil
+}
+
+// generateCSR creates an ECDSA P-256 key and a PEM certificate signing request
+// for it with subject
+func generateCSR(subject pkix.Name) (*ecdsa.PrivateKey, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", clierr.Wrap(clierr.KindGeneral, err, "failed to generate key")
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:            subject,
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+	}, key)
+	if err != nil {
+		return nil, "", clierr.Wrap(clierr.KindGeneral, err, "failed to create CSR")
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
+
+// writePrivateKey writes key to path as a PKCS #8 PEM file readable only by
+// the owner. An existing file is never overwritten, since it may hold the key
+// of an issued certificate.
+func writePrivateKey(key *ecdsa.PrivateKey, path string) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to encode private key")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		return clierr.Input("%s already exists; choose another --key-out", path)
+	}
+	if err != nil {
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to write private key")
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		f.Close()
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to write private key")
+	}
+	if err := f.Close(); err != nil {
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to write private key")
+	}
+	fmt.Fprintf(os.Stderr, "Private key written to %s\n", path)
+	return nil
+}
+
+// writeCertificateChain writes the PEM certificate chain from a certificate
+// response to path with permissions perm
+func writeCertificateChain(body []byte, path string, perm os.FileMode) error {
+	if dryRun || asCurl {
+		// Nothing was issued, so there is no chain to save
+		return nil
+	}
+	var resp certificateResult
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to parse certificate response")
+	}
+	if resp.Result == nil || resp.Result.CertificateChain == nil {
+		return clierr.New(clierr.KindGeneral, "the response has no certificate chain")
+	}
+	chain := *resp.Result.CertificateChain
+	if block, _ := pem.Decode([]byte(chain)); block == nil {
+		return clierr.New(clierr.KindGeneral, "the certificate chain is not PEM-encoded")
+	}
+	if !strings.HasSuffix(chain, "\n") {
+		chain += "\n"
+	}
+	if err := os.WriteFile(path, []byte(chain), perm); err != nil {
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to write certificate chain")
+	}
+	// WriteFile keeps the permissions of an existing file
+	if err := os.Chmod(path, perm); err != nil {
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to write certificate chain")
+	}
+	fmt.Fprintf(os.Stderr, "Certificate chain written to %s\n", path)
+	return nil
+}
+
+// fileDigest returns the base64-encoded SHA-256 digest of the file at path
+func fileDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", clierr.Wrap(clierr.KindInput, err, "failed to read file to sign")
+	}
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// validateDigests checks that digests are base64-encoded SHA-256 digests and
+// fit in one sign request
+func validateDigests(digests []string) error {
+	if len(digests) == 0 {
+		return clierr.Input("specify at least one --digest or --file")
+	}
+	if len(digests) > maxSignDigests {
+		return clierr.Input("at most %d digests can be signed at once, got %d", maxSignDigests, len(digests))
+	}
+	for _, digest := range digests {
+		decoded, err := base64.StdEncoding.DecodeString(digest)
+		if err != nil || len(decoded) != sha256.Size {
+			return clierr.Input("invalid digest %q: expected a base64-encoded SHA-256 digest", digest)
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(certificatesCmd)
+
+	certificatesCmd.AddCommand(certsListCmd)
+	certificatesCmd.AddCommand(certsGetCmd)
+	certificatesCmd.AddCommand(certsCreateCmd)
+	certificatesCmd.AddCommand(certsRevokeCmd)
+	certificatesCmd.AddCommand(certsSignCmd)
+
+	certsListCmd.Flags().Int("limit", 10, "Maximum number of certificates to return")
+	certsListCmd.Flags().Int("offset", 0, "Number of certificates to skip")
+	addPaginationFlags(certsListCmd)
+
+	certsGetCmd.Flags().String("out", "", "Write the PEM certificate chain to this file")
+
+	certsCreateCmd.Flags().String("common-name", "", "Subject common name of a certificate whose key Proof keeps, or of the CSR with --generate-key")
+	certsCreateCmd.Flags().String("csr", "", "PEM certificate signing request file for a certificate whose key you keep")
+	certsCreateCmd.Flags().String("cert-profile", "", "Certificate profile (assurance level): al1, al2, al3 or al4")
+	certsCreateCmd.Flags().String("out", "", "Write the PEM certificate chain to this file")
+	certsCreateCmd.Flags().Bool("generate-key", false, "Generate an ECDSA P-256 key and CSR locally and issue a certificate for it")
+	certsCreateCmd.Flags().String("key-out", "", "File for the generated private key (default: --out with a .key extension)")
+	certsCreateCmd.Flags().String("organization", "", "Subject organization of the generated CSR (default: your organization's name)")
+	certsCreateCmd.Flags().String("country", "", "Two-letter subject country of the generated CSR, e.g. US")
+
+	certsRevokeCmd.Flags().String("reason", "", "RFC 5280 revocation reason, e.g. keyCompromise or superseded")
+
+	certsSignCmd.Flags().StringSlice("digest", nil, "Base64-encoded SHA-256 digest to sign (repeatable)")
+	certsSignCmd.Flags().StringSlice("file", nil, "File whose SHA-256 digest to sign (repeatable)")
+}
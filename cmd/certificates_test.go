@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+)
+
+// requireInputError asserts that err is a CLI input error
+func requireInputError(t *testing.T, err error, msgAndArgs ...any) {
+	t.Helper()
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr, msgAndArgs...)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind, msgAndArgs...)
+}
+
+func TestCertificateRequest_PropertyNames(t *testing.T) {
+	payload, err := json.Marshal(certificateRequest{CertificateProfile: "organization_authenticity_al2", CommonName: "Acme"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"certificate_profile":"organization_authenticity_al2","common_name":"Acme"}`, string(payload))
+}
+
+func TestParseCertificateProfile(t *testing.T) {
+	for _, value := range []string{"al2", "AL2", "organization_authenticity_al2"} {
+		profile, err := parseCertificateProfile(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, "organization_authenticity_al2", profile)
+	}
+	_, err := parseCertificateProfile("al5")
+	requireInputError(t, err)
+}
+
+func TestReadCSR(t *testing.T) {
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "Acme"}}, key)
+	require.NoError(t, err)
+	csrPath := filepath.Join(dir, "request.csr")
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	require.NoError(t, os.WriteFile(csrPath, csrPEM, 0600))
+
+	csr, err := readCSR(csrPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(csrPEM), csr)
+
+	notCSR := filepath.Join(dir, "cert.pem")
+	require.NoError(t, os.WriteFile(notCSR, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	_, err = readCSR(notCSR)
+	requireInputError(t, err)
+}
+
+func TestWriteCertificateChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.pem")
+	chain := "-----BEGIN CERTIFICATE-----\nMAA=\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\nMAA=\n-----END CERTIFICATE-----"
+	body, err := json.Marshal(map[string]any{"result": map[string]any{"id": "c1", "certificate_chain": chain}})
+	require.NoError(t, err)
+
+	require.NoError(t, writeCertificateChain(body, path, 0644))
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, chain+"\n", string(written))
+
+	assert.Error(t, writeCertificateChain([]byte(`{"result":{"id":"c1"}}`), path, 0644))
+	assert.Error(t, writeCertificateChain([]byte(`{"result":{"certificate_chain":"not pem"}}`), path, 0644))
+}
+
+func TestValidateDigests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+	digest, err := fileDigest(path)
+	require.NoError(t, err)
+	assert.Equal(t, "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", digest)
+	assert.NoError(t, validateDigests([]string{digest}))
+
+	requireInputError(t, validateDigests(nil))
+	requireInputError(t, validateDigests([]string{"bm90IGEgZGlnZXN0"}), "wrong length")
+	requireInputError(t, validateDigests(strings.Split(strings.Repeat(digest+",", 26), ",")[:26]), "too many")
+}
+
+func TestCertificateSubject(t *testing.T) {
+	organizationInfo := []byte(`{"id":"org_1","name":"Acme Banking Co","email":"it@acme.example"}`)
+
+	subject, err := certificateSubject("", "", "us", organizationInfo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"US"}, subject.Country)
+	assert.Equal(t, []string{"Acme Banking Co"}, subject.Organization)
+	assert.Equal(t, "Acme Banking Co Signing Authority", subject.CommonName)
+
+	subject, err = certificateSubject("Signer", "Acme", "", []byte(`{"name":"Acme Banking Co","address":{"country":"CA"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CA"}, subject.Country, "a country on record is used")
+	assert.Equal(t, []string{"Acme"}, subject.Organization, "flags win over the record")
+	assert.Equal(t, "Signer", subject.CommonName)
+
+	_, err = certificateSubject("", "", "", organizationInfo)
+	requireInputError(t, err, "the record has no country")
+	_, err = certificateSubject("", "Acme", "USA", nil)
+	requireInputError(t, err, "country codes have two letters")
+}
+
+func TestGenerateCSR(t *testing.T) {
+	subject, err := certificateSubject("", "Acme", "US", nil)
+	require.NoError(t, err)
+	key, csrPEM, err := generateCSR(subject)
+	require.NoError(t, err)
+
+	block, _ := pem.Decode([]byte(csrPEM))
+	require.NotNil(t, block)
+	assert.Equal(t, "CERTIFICATE REQUEST", block.Type)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(t, err)
+	require.NoError(t, csr.CheckSignature())
+	assert.Equal(t, x509.ECDSAWithSHA256, csr.SignatureAlgorithm)
+	assert.Equal(t, elliptic.P256(), key.Curve)
+	assert.True(t, key.PublicKey.Equal(csr.PublicKey), "the CSR is for the generated key")
+	assert.Equal(t, "CN=Acme Signing Authority,O=Acme,C=US", csr.Subject.String())
+
+	path := filepath.Join(t.TempDir(), "acme.key")
+	require.NoError(t, writePrivateKey(key, path))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	block, _ = pem.Decode(written)
+	require.NotNil(t, block)
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(parsed))
+
+	requireInputError(t, writePrivateKey(key, path), "an existing key is never overwritten")
+}
+
+func TestWriteCertificateChain_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not enforced on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "chain.pem")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+	body := []byte(`{"result":{"certificate_chain":"-----BEGIN CERTIFICATE-----\nMAA=\n-----END CERTIFICATE-----\n"}}`)
+
+	require.NoError(t, writeCertificateChain(body, path, 0600))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "an existing file is narrowed too")
+}
@@ -3,9 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 	"github.com/tsarlewey/proof-cli/pkg/utils"
 )
 
@@ -18,29 +22,65 @@ var configCmd = &cobra.Command{
 
 // configGetCmd represents the config get command
 var configGetCmd = &cobra.Command{
-	Use:    "get",
-	Short:  "Get configuration",
-	Long:   `Get the current configuration settings.`,
-	PreRun: initializeForAPICall,
+	Use:   "get",
+	Short: "Get configuration",
+	Long: `Get the effective configuration settings.
+
+Each setting comes from the first source that sets it: command-line flags, environment
+variables, the active profile, the top-level settings of the config file, then the
+built-in defaults. Use --show-source to see which one each setting came from.`,
+	PreRun: toggleDebug,
 	Run: func(cmd *cobra.Command, args []string) {
-		config, err := utils.LoadConfig()
+		showSource, _ := cmd.Flags().GetBool("show-source")
+
+		config, sources, err := utils.ResolveConfig()
 		if err != nil {
-			fmt.Println("Error loading config:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.From(err, "failed to load config"))
+		}
+
+		// show prints a setting, followed by where it came from with --show-source
+		show := func(label string, value any, source string) {
+			if !showSource {
+				fmt.Printf("%s: %v\n", label, value)
+				return
+			}
+			fmt.Printf("%s: %v (%s)\n", label, value, source)
+		}
+
+		if showSource {
+			path, _, err := utils.ConfigFilePath()
+			if err != nil {
+				clierr.Exit(clierr.From(err, "failed to locate config file"))
+			}
+			fmt.Println("Config File:", path)
+		}
+		show("Profile", config.Profile, settingSource(sources, "profile"))
+		show("API Endpoint", config.APIEndpoint, settingSource(sources, "api_endpoint"))
+		for _, api := range utils.APINames {
+			show(apiLabels[api]+" Endpoint", config.Endpoint(api), sources.EndpointSource(api))
+		}
+		show("Timeout", config.Timeout, settingSource(sources, "timeout"))
+		retry := config.RetryPolicy()
+		show("Max Retries", retry.MaxRetries, settingSource(sources, "retry.max_retries"))
+		show("Retry Wait", retry.Wait, settingSource(sources, "retry.wait"))
+		limit := config.RequestLimits()
+		show("Rate Limit", describeRateLimit(limit), settingSource(sources, "rate_limit.rps", "rate_limit.burst", "rate_limit.concurrency"))
+		if config.OrganizationID != "" {
+			show("Organization ID", config.OrganizationID, settingSource(sources, "organization_id"))
 		}
-		fmt.Println("API Endpoint:", config.APIEndpoint)
-		fmt.Println("Timeout:", config.Timeout)
+
+		show("Credential Store", config.Credentials, settingSource(sources, "credential_store"))
 
 		// Show API Key status
 		if config.APIKey != "" {
-			fmt.Println("API Key: configured")
+			show("API Key", "configured", settingSource(sources, "api_key"))
 		} else {
-			fmt.Println("API Key: not configured")
+			show("API Key", "not configured", settingSource(sources, "api_key"))
 		}
 
 		// Show OAuth configuration
 		if config.OAuth != nil {
-			fmt.Println("OAuth Enabled:", config.OAuth.Enabled)
+			show("OAuth Enabled", config.OAuth.Enabled, settingSource(sources, "oauth"))
 			if config.OAuth.Enabled {
 				fmt.Println("OAuth Client ID:", config.OAuth.ClientID)
 				if config.OAuth.Scope != "" {
@@ -58,35 +98,80 @@ var configGetCmd = &cobra.Command{
 				}
 			}
 		} else {
-			fmt.Println("OAuth Enabled: false")
+			show("OAuth Enabled", false, settingSource(sources, "oauth"))
 		}
 	},
 }
 
+// apiLabels are the display names of the APIs with their own base URL
+var apiLabels = map[string]string{
+	utils.APIBusiness:     "Business",
+	utils.APIRealEstate:   "Real Estate",
+	utils.APISCIM:         "SCIM",
+	utils.APILogs:         "Logs",
+	utils.APICertificates: "Certificates",
+}
+
+// settingSource describes where a displayed value came from. A value built
+// from several settings lists each distinct source other than the defaults.
+func settingSource(sources utils.Sources, settings ...string) string {
+	var found []string
+	for _, setting := range settings {
+		source := sources[setting]
+		if source == "" || source == "default" {
+			continue
+		}
+		if !slices.Contains(found, source) {
+			found = append(found, source)
+		}
+	}
+	if len(found) == 0 {
+		return "default"
+	}
+	return strings.Join(found, ", ")
+}
+
 // configSetEndpointCmd represents the config set-endpoint command
 var configSetEndpointCmd = &cobra.Command{
-	Use:    "set-endpoint [endpoint]",
-	Short:  "Set API endpoint",
-	Long:   `Set the API endpoint for the CLI.`,
-	PreRun: initializeForAPICall,
+	Use:   "set-endpoint [endpoint]",
+	Short: "Set API endpoint",
+	Long: `Set the API endpoint for the CLI.
+
+With --api, set the base URL of one API instead. By default the business, real estate,
+logs and certificates APIs use the API endpoint, and SCIM uses the API endpoint
+followed by /scim/v1/organizations. An empty URL restores the default.`,
+	Example: `  proof config set-endpoint https://api.fairfax.proof.com
+  proof config set-endpoint --api logs https://api.proof.com
+  proof config set-endpoint --api logs ""`,
+	PreRun: toggleDebug,
 	Args:   cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		endpoint := args[0]
+		api, _ := cmd.Flags().GetString("api")
 
-		config, err := utils.LoadConfig()
-		if err != nil {
-			fmt.Println("Error loading config:", err)
-			os.Exit(1)
+		if api == "" && endpoint == "" {
+			clierr.Exit(clierr.Input("endpoint must not be empty"))
 		}
 
-		config.APIEndpoint = endpoint
-
-		if err := utils.SaveConfig(config); err != nil {
-			fmt.Println("Error saving config:", err)
-			os.Exit(1)
+		err := utils.UpdateConfig(func(config *utils.Config) error {
+			if api != "" {
+				return config.SetEndpoint(api, endpoint)
+			}
+			config.APIEndpoint = endpoint
+			return nil
+		})
+		if err != nil {
+			clierr.Exit(clierr.From(err, "failed to save config"))
 		}
 
-		fmt.Println("API endpoint set to:", endpoint)
+		switch {
+		case api == "":
+			fmt.Println("API endpoint set to:", endpoint)
+		case endpoint == "":
+			fmt.Printf("%s endpoint reset to the default\n", apiLabels[api])
+		default:
+			fmt.Printf("%s endpoint set to: %s\n", apiLabels[api], endpoint)
+		}
 	},
 }
 
@@ -95,29 +180,144 @@ var configSetTimeoutCmd = &cobra.Command{
 	Use:    "set-timeout [timeout]",
 	Short:  "Set timeout",
 	Long:   `Set the timeout for API requests in seconds.`,
-	PreRun: initializeForAPICall,
+	PreRun: toggleDebug,
 	Args:   cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var timeout int
 		if _, err := fmt.Sscanf(args[0], "%d", &timeout); err != nil {
-			fmt.Println("Error: timeout must be a number")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("timeout must be a number"))
 		}
 
-		config, err := utils.LoadConfig()
+		err := utils.UpdateConfig(func(config *utils.Config) error {
+			config.Timeout = time.Duration(timeout) * time.Second
+			return nil
+		})
 		if err != nil {
-			fmt.Println("Error loading config:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.From(err, "failed to save config"))
 		}
 
-		config.Timeout = time.Duration(timeout) * time.Second
+		fmt.Println("Timeout set to:", timeout, "seconds")
+	},
+}
+
+// configSetRetriesCmd represents the config set-retries command
+var configSetRetriesCmd = &cobra.Command{
+	Use:   "set-retries <max-retries> [wait]",
+	Short: "Set retry behavior",
+	Long: `Set how many times transient failures are retried and the base delay between attempts.
+The delay doubles on every attempt, with jitter. Use 0 retries to disable retrying.`,
+	Example: `  proof config set-retries 5 1s`,
+	Args:    cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var retries int
+		if _, err := fmt.Sscanf(args[0], "%d", &retries); err != nil || retries < 0 {
+			clierr.Exit(clierr.Input("max-retries must be a non-negative number"))
+		}
 
-		if err := utils.SaveConfig(config); err != nil {
-			fmt.Println("Error saving config:", err)
-			os.Exit(1)
+		var wait time.Duration
+		if len(args) == 2 {
+			var err error
+			wait, err = time.ParseDuration(args[1])
+			if err != nil || wait <= 0 {
+				clierr.Exit(clierr.Input("wait must be a positive duration such as 500ms or 2s"))
+			}
 		}
 
-		fmt.Println("Timeout set to:", timeout, "seconds")
+		var policy utils.RetryPolicy
+		err := utils.UpdateConfig(func(config *utils.Config) error {
+			if config.Retry == nil {
+				config.Retry = &utils.RetryConfig{}
+			}
+			config.Retry.MaxRetries = &retries
+			if wait > 0 {
+				config.Retry.Wait = wait
+			}
+			policy = config.RetryPolicy()
+			return nil
+		})
+		if err != nil {
+			clierr.Exit(clierr.From(err, "failed to save config"))
+		}
+
+		fmt.Printf("Retries set to: %d (wait %s)\n", policy.MaxRetries, policy.Wait)
+	},
+}
+
+// configSetRateLimitCmd represents the config set-rate-limit command
+var configSetRateLimitCmd = &cobra.Command{
+	Use:   "set-rate-limit <requests-per-second> [concurrency]",
+	Short: "Set client-side rate limits",
+	Long: `Limit how fast the CLI calls the API, to stay under the API's throttling when scripting many calls.
+Requests are paced with a token bucket; concurrency caps how many are in flight at once. Use 0 for no limit.`,
+	Example: `  proof config set-rate-limit 5
+  proof config set-rate-limit 10 4 --burst 10`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var limit utils.RateLimit
+		if _, err := fmt.Sscanf(args[0], "%g", &limit.RPS); err != nil || limit.RPS < 0 {
+			clierr.Exit(clierr.Input("requests-per-second must be a non-negative number"))
+		}
+		if len(args) == 2 {
+			if _, err := fmt.Sscanf(args[1], "%d", &limit.Concurrency); err != nil || limit.Concurrency < 0 {
+				clierr.Exit(clierr.Input("concurrency must be a non-negative number"))
+			}
+		}
+		limit.Burst, _ = cmd.Flags().GetInt("burst")
+		if limit.Burst < 0 {
+			clierr.Exit(clierr.Input("--burst must not be negative"))
+		}
+
+		err := utils.UpdateConfig(func(config *utils.Config) error {
+			config.RateLimit = &limit
+			if limit == (utils.RateLimit{}) {
+				config.RateLimit = nil
+			}
+			return nil
+		})
+		if err != nil {
+			clierr.Exit(clierr.From(err, "failed to save config"))
+		}
+
+		fmt.Println("Rate limit set to:", describeRateLimit(limit))
+	},
+}
+
+// describeRateLimit renders rate limits for display
+func describeRateLimit(limit utils.RateLimit) string {
+	var parts []string
+	if limit.RPS > 0 {
+		parts = append(parts, fmt.Sprintf("%g requests/s (burst %d)", limit.RPS, max(limit.Burst, 1)))
+	}
+	if limit.Concurrency > 0 {
+		parts = append(parts, fmt.Sprintf("%d concurrent", limit.Concurrency))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// configSetOrganizationCmd represents the config set-organization command
+var configSetOrganizationCmd = &cobra.Command{
+	Use:   "set-organization <organization-id>",
+	Short: "Set the default organization",
+	Long: `Set the organization used by commands that accept an organization ID when none is given,
+such as creating transactions in a child organization. Use "" to clear it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := utils.UpdateConfig(func(config *utils.Config) error {
+			config.OrganizationID = args[0]
+			return nil
+		})
+		if err != nil {
+			clierr.Exit(clierr.From(err, "failed to save config"))
+		}
+
+		if args[0] == "" {
+			fmt.Println("Default organization cleared")
+			return
+		}
+		fmt.Println("Default organization set to:", args[0])
 	},
 }
 
@@ -127,13 +327,12 @@ var configSetAPIKeyCmd = &cobra.Command{
 	Short:  "Set API key",
 	Long:   `Set the API key for the CLI.`,
 	Args:   cobra.ExactArgs(1),
-	PreRun: initializeForAPICall,
+	PreRun: toggleDebug,
 	Run: func(cmd *cobra.Command, args []string) {
 		apiKey := args[0]
 
 		if err := utils.SaveAPIKey(apiKey); err != nil {
-			fmt.Println("Error saving API key:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.From(err, "failed to save API key"))
 		}
 
 		fmt.Println("API key set successfully")
@@ -151,25 +350,20 @@ var configSetOAuthCmd = &cobra.Command{
 		clientSecret := args[1]
 		scope, _ := cmd.Flags().GetString("scope")
 
-		config, err := utils.LoadConfig()
-		if err != nil {
-			fmt.Println("Error loading config:", err)
-			os.Exit(1)
-		}
-
-		// Initialize OAuth config if it doesn't exist
-		if config.OAuth == nil {
-			config.OAuth = &utils.OAuthConfig{}
-		}
-
-		config.OAuth.Enabled = true
-		config.OAuth.ClientID = clientID
-		config.OAuth.ClientSecret = clientSecret
-		config.OAuth.Scope = scope
+		err := utils.UpdateConfig(func(config *utils.Config) error {
+			// Initialize OAuth config if it doesn't exist
+			if config.OAuth == nil {
+				config.OAuth = &utils.OAuthConfig{}
+			}
 
-		if err := utils.SaveConfig(config); err != nil {
-			fmt.Println("Error saving config:", err)
-			os.Exit(1)
+			config.OAuth.Enabled = true
+			config.OAuth.ClientID = clientID
+			config.OAuth.ClientSecret = clientSecret
+			config.OAuth.Scope = scope
+			return nil
+		})
+		if err != nil {
+			clierr.Exit(clierr.From(err, "failed to save config"))
 		}
 
 		fmt.Println("OAuth credentials configured successfully")
@@ -186,19 +380,14 @@ var configDisableOAuthCmd = &cobra.Command{
 	Short: "Disable OAuth authentication",
 	Long:  `Disable OAuth authentication and fall back to API key`,
 	Run: func(cmd *cobra.Command, args []string) {
-		config, err := utils.LoadConfig()
+		err := utils.UpdateConfig(func(config *utils.Config) error {
+			if config.OAuth != nil {
+				config.OAuth.Enabled = false
+			}
+			return nil
+		})
 		if err != nil {
-			fmt.Println("Error loading config:", err)
-			os.Exit(1)
-		}
-
-		if config.OAuth != nil {
-			config.OAuth.Enabled = false
-		}
-
-		if err := utils.SaveConfig(config); err != nil {
-			fmt.Println("Error saving config:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.From(err, "failed to save config"))
 		}
 
 		fmt.Println("OAuth authentication disabled")
@@ -213,27 +402,23 @@ var configTestOAuthCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		config, err := utils.LoadConfig()
 		if err != nil {
-			fmt.Println("Error loading config:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.Wrap(clierr.KindGeneral, err, "failed to load config"))
 		}
 
 		if config.OAuth == nil || !config.OAuth.Enabled {
-			fmt.Println("OAuth is not enabled. Use 'proof config set-oauth' to configure OAuth credentials.")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("OAuth is not enabled. Use 'proof config set-oauth' to configure OAuth credentials."))
 		}
 
 		// Create a client to test OAuth (this will automatically attempt OAuth)
 		client, err := utils.NewProofClient()
 		if err != nil {
-			fmt.Println("OAuth authentication failed:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.Wrap(clierr.KindAuth, err, "OAuth authentication failed"))
 		}
 
 		// Test OAuth by forcing a token refresh through the client
 		token, err := client.TestOAuthAuthentication()
 		if err != nil {
-			fmt.Println("OAuth authentication failed:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.Wrap(clierr.KindAuth, err, "OAuth authentication failed"))
 		}
 
 		fmt.Println("OAuth authentication successful!")
@@ -245,15 +430,210 @@ var configTestOAuthCmd = &cobra.Command{
 	},
 }
 
+// configProfilesCmd represents the config profiles command
+var configProfilesCmd = &cobra.Command{
+	Use:     "profiles",
+	Aliases: []string{"profile"},
+	Short:   "Manage configuration profiles",
+	Long: `Manage named profiles, each with its own endpoint, credentials, timeout, OAuth token,
+default organization, retry and rate limit settings.
+
+The settings at the top level of the config file form the "default" profile. The active
+profile is chosen by --profile, then PROOF_PROFILE, then 'proof config profiles use'.
+Other config commands, such as set-api-key, change the active profile.`,
+}
+
+// configProfilesAddCmd represents the config profiles add command
+var configProfilesAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a profile",
+	Long: `Add a named profile. Settings that are not given are inherited from the top-level
+settings of the config file, then the built-in defaults. Credentials are never inherited:
+a profile uses only its own API key or OAuth client.`,
+	Example: `  proof config profiles add sandbox --endpoint https://api.fairfax.proof.com --api-key $SANDBOX_KEY
+  proof config profiles add child-org --api-key $PROOF_API_KEY --organization-id org_123 --use`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		endpoint, _ := cmd.Flags().GetString("endpoint")
+		apiKey, _ := cmd.Flags().GetString("api-key")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		organizationID, _ := cmd.Flags().GetString("organization-id")
+		clientID, _ := cmd.Flags().GetString("client-id")
+		clientSecret, _ := cmd.Flags().GetString("client-secret")
+		scope, _ := cmd.Flags().GetString("scope")
+		use, _ := cmd.Flags().GetBool("use")
+
+		if timeout < 0 {
+			clierr.Exit(clierr.Input("--timeout must not be negative"))
+		}
+		if (clientID == "") != (clientSecret == "") {
+			clierr.Exit(clierr.Input("--client-id and --client-secret must be given together"))
+		}
+
+		config := &utils.Config{
+			APIEndpoint:    endpoint,
+			Timeout:        timeout,
+			APIKey:         apiKey,
+			OrganizationID: organizationID,
+		}
+		if clientID != "" {
+			config.OAuth = &utils.OAuthConfig{
+				Enabled:      true,
+				ClientID:     clientID,
+				ClientSecret: clientSecret,
+				Scope:        scope,
+			}
+		}
+
+		if err := utils.AddProfile(name, config); err != nil {
+			clierr.Exit(clierr.From(err, "failed to add profile"))
+		}
+		fmt.Println("Profile added:", name)
+
+		if use {
+			if err := utils.UseProfile(name); err != nil {
+				clierr.Exit(clierr.From(err, "failed to switch profile"))
+			}
+			fmt.Println("Now using profile:", name)
+		}
+	},
+}
+
+// configProfilesListCmd represents the config profiles list command
+var configProfilesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Long:  `List the profiles with their endpoints and default organizations. The active profile is marked with *.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		names, active, err := utils.ListProfiles()
+		if err != nil {
+			clierr.Exit(clierr.From(err, "failed to list profiles"))
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "\tPROFILE\tENDPOINT\tORGANIZATION")
+		for _, name := range names {
+			config, err := utils.LoadProfile(name)
+			if err != nil {
+				clierr.Exit(clierr.From(err, "failed to load profile"))
+			}
+			marker := ""
+			if name == active {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, name, config.APIEndpoint, config.OrganizationID)
+		}
+		w.Flush()
+	},
+}
+
+// configProfilesUseCmd represents the config profiles use command
+var configProfilesUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch the current profile",
+	Long:  `Make a profile current for every later command. --profile and PROOF_PROFILE still take precedence.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := utils.UseProfile(args[0]); err != nil {
+			clierr.Exit(clierr.From(err, "failed to switch profile"))
+		}
+		fmt.Println("Now using profile:", args[0])
+	},
+}
+
+// configProfilesDeleteCmd represents the config profiles delete command
+var configProfilesDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a profile",
+	Long:  `Delete a named profile and its credentials. If it was current, the default profile becomes current.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := utils.DeleteProfile(args[0]); err != nil {
+			clierr.Exit(clierr.From(err, "failed to delete profile"))
+		}
+		fmt.Println("Profile deleted:", args[0])
+	},
+}
+
+// configCredentialsCmd represents the config credentials command
+var configCredentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Manage where credentials are stored",
+	Long: `Manage where the API key, OAuth client secret and OAuth token of each profile are kept.
+
+Stores, chosen by credential_store in the config file or PROOF_CREDENTIAL_STORE:
+  config  in the config file in plain text (the default)
+  file    in ~/.proof-cli/credentials.enc, encrypted with a key derived from
+          PROOF_CREDENTIAL_PASSPHRASE; works on headless machines
+  helper  in an external command, run as '<command> get|store|erase <key>' with
+          secrets on standard input and output, like git credential helpers
+  env     from PROOF_API_KEY and PROOF_OAUTH_CLIENT_SECRET only; OAuth tokens are
+          kept in memory and nothing is written`,
+}
+
+// configCredentialsMigrateCmd represents the config credentials migrate command
+var configCredentialsMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move credentials into a credential store",
+	Long: `Move the credentials of every profile out of the config file, or the store currently
+in use, into another store, and make it the store for later commands.`,
+	Example: `  PROOF_CREDENTIAL_PASSPHRASE=... proof config credentials migrate --store file
+  proof config credentials migrate --store helper --helper "pass-proof"
+  proof config credentials migrate --store config`,
+	PreRun: toggleDebug,
+	Args:   cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, _ := cmd.Flags().GetString("store")
+		helper, _ := cmd.Flags().GetString("helper")
+
+		if helper != "" && store != utils.StoreHelper {
+			clierr.Exit(clierr.Input("--helper is only used with --store helper"))
+		}
+
+		moved, err := utils.MigrateCredentials(store, helper)
+		if err != nil {
+			clierr.Exit(clierr.From(err, "failed to migrate credentials"))
+		}
+		fmt.Printf("Moved %d credentials to the %s store\n", moved, store)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configGetCmd)
 	configCmd.AddCommand(configSetEndpointCmd)
 	configCmd.AddCommand(configSetTimeoutCmd)
+	configCmd.AddCommand(configSetRetriesCmd)
+	configCmd.AddCommand(configSetRateLimitCmd)
+	configCmd.AddCommand(configSetOrganizationCmd)
 	configCmd.AddCommand(configSetAPIKeyCmd)
 	configCmd.AddCommand(configSetOAuthCmd)
 	configCmd.AddCommand(configDisableOAuthCmd)
 	configCmd.AddCommand(configTestOAuthCmd)
-
+	configCmd.AddCommand(configProfilesCmd)
+	configProfilesCmd.AddCommand(configProfilesAddCmd)
+	configProfilesCmd.AddCommand(configProfilesListCmd)
+	configProfilesCmd.AddCommand(configProfilesUseCmd)
+	configProfilesCmd.AddCommand(configProfilesDeleteCmd)
+	configCmd.AddCommand(configCredentialsCmd)
+	configCredentialsCmd.AddCommand(configCredentialsMigrateCmd)
+
+	configGetCmd.Flags().Bool("show-source", false, "Show where each setting came from")
+	configSetEndpointCmd.Flags().String("api", "", "Set the base URL of one API: "+strings.Join(utils.APINames, ", "))
 	configSetOAuthCmd.Flags().String("scope", "", "OAuth scope (optional)")
+	configSetRateLimitCmd.Flags().Int("burst", 1, "Requests that may be sent at once after a quiet period")
+
+	configProfilesAddCmd.Flags().String("endpoint", "", "API endpoint (default: https://api.proof.com)")
+	configProfilesAddCmd.Flags().String("api-key", "", "API key")
+	configProfilesAddCmd.Flags().Duration("timeout", 0, "Request timeout, e.g. 60s (default: 30s)")
+	configProfilesAddCmd.Flags().String("organization-id", "", "Default organization for commands that accept one")
+	configProfilesAddCmd.Flags().String("client-id", "", "OAuth client ID")
+	configProfilesAddCmd.Flags().String("client-secret", "", "OAuth client secret")
+	configProfilesAddCmd.Flags().String("scope", "", "OAuth scope (optional)")
+	configProfilesAddCmd.Flags().Bool("use", false, "Make the new profile current")
+
+	configCredentialsMigrateCmd.Flags().String("store", "", "Store to move credentials to: "+strings.Join(utils.StoreNames, ", "))
+	configCredentialsMigrateCmd.Flags().String("helper", "", "Credential helper command, for --store helper")
+	configCredentialsMigrateCmd.MarkFlagRequired("store")
 }
@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/tsarlewey/proof-cli/pkg/sdk/business"
@@ -39,10 +38,7 @@ var exampleListBusinessTransactionsCmd = &cobra.Command{
 		fmt.Println("Fetching business transactions...")
 		client := getBusinessClient()
 		resp, err := client.GetAllTransactionsWithResponse(context.Background(), params)
-		if err != nil {
-			fmt.Println("Error fetching transactions:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to fetch transactions")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -69,10 +65,7 @@ This example creates a notary for demonstration purposes.`,
 		fmt.Println("Creating notary...")
 		client := getBusinessClient()
 		resp, err := client.CreateNotaryWithResponse(context.Background(), body)
-		if err != nil {
-			fmt.Println("Error creating notary:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to create notary")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -100,10 +93,7 @@ var exampleListRealEstateTransactionsCmd = &cobra.Command{
 		fmt.Println("Fetching real estate transactions...")
 		client := getRealEstateClient()
 		resp, err := client.GetAllMortgageTransactionsWithResponse(context.Background(), params)
-		if err != nil {
-			fmt.Println("Error fetching real estate transactions:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to fetch real estate transactions")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -136,10 +126,7 @@ var exampleVerifyAddressCmd = &cobra.Command{
 		fmt.Println("Verifying address...")
 		client := getRealEstateClient()
 		resp, err := client.GetRecordingLocationsWithResponse(context.Background(), params)
-		if err != nil {
-			fmt.Println("Error verifying address:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to verify address")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -170,10 +157,7 @@ var exampleListSCIMUsersCmd = &cobra.Command{
 		fmt.Printf("Fetching SCIM users for organization %s...\n", organizationID)
 		client := getSCIMClient()
 		resp, err := client.RetrieveResourceTypesCopyWithResponse(context.Background(), organizationID, params)
-		if err != nil {
-			fmt.Println("Error listing users:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to list users")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -194,10 +178,7 @@ var exampleGetSCIMSchemaCmd = &cobra.Command{
 		fmt.Printf("Fetching SCIM user schema for organization %s...\n", organizationID)
 		client := getSCIMClient()
 		resp, err := client.RetrieveUsersSchemaWithResponse(context.Background(), organizationID)
-		if err != nil {
-			fmt.Println("Error getting user schema:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get user schema")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -15,7 +16,12 @@ func (f *PlainFormatter) Format(entry *log.Entry) ([]byte, error) {
 }
 
 func toggleDebug(cmd *cobra.Command, args []string) {
-	if verbose {
+	if debug {
+		// Wire traces span several lines, which the text formatter would quote
+		log.SetLevel(log.DebugLevel)
+		log.SetFormatter(new(PlainFormatter))
+		log.SetOutput(os.Stderr)
+	} else if verbose {
 		log.Info("Debug logs enabled")
 		log.SetLevel(log.DebugLevel)
 		log.SetFormatter(&log.TextFormatter{})
@@ -0,0 +1,450 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/logs"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
+)
+
+// securityEventRetention is how far back the logs API accepts a since time
+const securityEventRetention = 90 * 24 * time.Hour
+
+// logsCmd represents the logs command
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Logs API operations",
+	Long:  `Commands for interacting with the Proof Logs API`,
+}
+
+var logsEventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Security event operations",
+	Long:  `Commands for reading your organization's OCSF security events`,
+}
+
+var logsEventsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List security events",
+	Long: `List your organization's security events.
+
+--since accepts an RFC 3339 timestamp, a date such as 2026-01-31, or an age
+such as 24h or 7d. The API keeps 90 days of events. With --all, every page is
+fetched by following the cursor the API returns.`,
+	Example: `  proof logs events list --since 24h
+  proof logs events list --since 7d --severity-id 4 --all
+  proof logs events list --class-uid 3002 --output json`,
+	Annotations: map[string]string{annotationColumns: "class_name,activity_name,severity,time"},
+	PreRun:      initializeForAPICall,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit < 1 || limit > 1000 {
+			clierr.Exit(clierr.Input("--limit must be between 1 and 1000"))
+		}
+
+		params, err := securityEventFilters(cmd, time.Now())
+		if err != nil {
+			clierr.Exit(err)
+		}
+
+		client := getLogsClient()
+		runList(cmd, listPages{
+			style:       utils.PageCursor,
+			first:       utils.PageRequest{Limit: limit},
+			maxPageSize: 1000,
+			fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				body, err := fetchSecurityEvents(ctx, client, *params, page)
+				if err != nil {
+					return nil, err
+				}
+				if outputFormat == utils.FormatTable {
+					return readableEventTimes(body), nil
+				}
+				return body, nil
+			},
+		})
+	},
+}
+
+var logsEventsTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Follow new security events",
+	Long: `Poll for new security events and print each one as a line of JSON (NDJSON)
+as it arrives. The tail runs until interrupted.
+
+With --checkpoint, the position reached is saved to a file after every poll,
+and a restarted tail resumes from it instead of --since, so no event is skipped
+or printed twice. Without a saved position the tail starts at --since, or at
+the current time when --since is not given; the start time then moves up to
+the newest event printed. A checkpoint is tied to the
+--class-uid and --severity-id filters it was written with.
+
+Events are printed before the checkpoint is saved: if the process is killed
+between the two, the last page is printed again on restart. Stopping the tail
+with Ctrl-C or SIGTERM finishes the current poll first.`,
+	Example: `  proof logs events tail --since 24h
+  proof logs events tail --checkpoint /var/lib/proof/events.checkpoint --interval 1m`,
+	PreRun: initializeForAPICall,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		checkpointPath, _ := cmd.Flags().GetString("checkpoint")
+
+		if limit < 1 || limit > 1000 {
+			clierr.Exit(clierr.Input("--limit must be between 1 and 1000"))
+		}
+		if interval <= 0 {
+			clierr.Exit(clierr.Input("--interval must be positive"))
+		}
+		if outputFormat != "" && outputFormat != utils.FormatNDJSON {
+			clierr.Exit(clierr.Input("tail prints NDJSON; --output %s is not supported", outputFormat))
+		}
+
+		now := time.Now()
+		params, err := securityEventFilters(cmd, now)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		state, err := tailStartingPoint(checkpointPath, params, now)
+		if err != nil {
+			clierr.Exit(err)
+		}
+
+		outputFormat = utils.FormatNDJSON
+		out, err := newRecordOutput()
+		if err != nil {
+			clierr.Exit(err)
+		}
+
+		client := getLogsClient()
+		tail := &eventTail{
+			state:      state,
+			checkpoint: checkpointPath,
+			limit:      limit,
+			interval:   interval,
+			fetch: func(ctx context.Context, since string, page utils.PageRequest) ([]byte, error) {
+				filters := *params
+				filters.Since = ptr(since)
+				return fetchSecurityEvents(ctx, client, filters, page)
+			},
+			write: out.write,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if dryRun || asCurl {
+			// A dry run shows the first poll and leaves the checkpoint untouched
+			tail.checkpoint = ""
+			if _, err := tail.poll(ctx); err != nil {
+				clierr.Exit(err)
+			}
+			return
+		}
+		if err := tail.run(ctx); err != nil {
+			clierr.Exit(err)
+		}
+	},
+}
+
+// tailStartingPoint returns the position a tail starts from: the checkpoint at
+// path when one was saved, otherwise the --since time in params or now
+func tailStartingPoint(path string, params *logs.ListSecurityEventsParams, now time.Time) (*utils.Checkpoint, error) {
+	if path != "" {
+		saved, err := utils.LoadCheckpoint(path)
+		if err != nil {
+			return nil, clierr.Wrap(clierr.KindInput, err, "")
+		}
+		if saved != nil {
+			if !sameFilter(saved.ClassUID, params.ClassUid) || !sameFilter(saved.SeverityID, params.SeverityId) {
+				return nil, clierr.Input("checkpoint %s was saved with different --class-uid or --severity-id filters; use a new checkpoint file", path)
+			}
+			// Until the API returns a cursor the tail reads from the saved start time
+			params.Since = ptr(saved.Since)
+			return saved, nil
+		}
+	}
+
+	if params.Since == nil {
+		params.Since = ptr(now.UTC().Format(time.RFC3339))
+	}
+	return &utils.Checkpoint{
+		Since:      *params.Since,
+		ClassUID:   params.ClassUid,
+		SeverityID: params.SeverityId,
+	}, nil
+}
+
+// sameFilter reports whether two optional filter values are equal
+func sameFilter(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// eventTail polls for security events, printing each one once and saving its
+// position after every poll
+type eventTail struct {
+	state *utils.Checkpoint
+	// checkpoint is the file the position is saved to; empty to not save it
+	checkpoint string
+	limit      int
+	interval   time.Duration
+	// fetch gets the page at page.Cursor, or starting at since without a cursor
+	fetch func(ctx context.Context, since string, page utils.PageRequest) ([]byte, error)
+	write func(records []any) error
+}
+
+// run polls until ctx is cancelled. Pages are fetched back to back while the
+// API reports more events, then once per interval. Failures that may clear up
+// on their own are reported and retried at the next interval.
+func (t *eventTail) run(ctx context.Context) error {
+	for {
+		caughtUp, err := t.poll(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			if !retryableTailError(err) {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v; retrying in %s\n", err, t.interval)
+			caughtUp = true
+		}
+		if !caughtUp {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(t.interval):
+		}
+	}
+}
+
+// poll fetches the page at the current position, prints the events on it not
+// printed before and advances the position. It reports whether the tail has
+// caught up with the newest event.
+func (t *eventTail) poll(ctx context.Context) (bool, error) {
+	body, err := t.fetch(ctx, t.state.Since, utils.PageRequest{Limit: t.limit, Cursor: t.state.Cursor})
+	if err != nil {
+		return false, err
+	}
+	if len(body) == 0 {
+		// A dry run prints the request and gets no page back
+		return true, nil
+	}
+
+	var page any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&page); err != nil {
+		return false, clierr.Wrap(clierr.KindGeneral, err, "failed to parse security events")
+	}
+	events := utils.Records(page)
+	meta, _ := utils.LookupPath(page, "meta")
+	cursor, _ := utils.LookupPath(meta, "next_cursor")
+	next, _ := cursor.(string)
+	hasMore, _ := utils.LookupPath(meta, "has_more")
+
+	seen := make(map[string]bool, len(t.state.Seen))
+	for _, key := range t.state.Seen {
+		seen[key] = true
+	}
+	var fresh []any
+	for _, event := range events {
+		if !seen[eventKey(event)] {
+			fresh = append(fresh, event)
+		}
+	}
+	if len(fresh) > 0 {
+		if err := t.write(fresh); err != nil {
+			return false, err
+		}
+	}
+
+	advanced := next != "" && next != t.state.Cursor
+	if advanced {
+		t.state.Cursor = next
+		t.state.Seen = nil
+	} else {
+		if t.state.Cursor == "" {
+			// Without a cursor the tail reads from Since, so move it up to
+			// the newest event printed
+			since, err := time.Parse(time.RFC3339, t.state.Since)
+			if err != nil {
+				return false, clierr.Wrap(clierr.KindGeneral, err, "invalid checkpoint start time")
+			}
+			for _, event := range fresh {
+				if at, ok := eventTimestamp(event); ok && at.After(since) {
+					since = at.Truncate(time.Second)
+				}
+			}
+			t.state.Since = since.UTC().Format(time.RFC3339)
+		}
+		// The same position is fetched again. Since filters on ingestion
+		// time rather than event time, so any event on this page may come
+		// back; the page is bounded by --limit, so remember all of it.
+		t.state.Seen = nil
+		for _, event := range events {
+			t.state.Seen = append(t.state.Seen, eventKey(event))
+		}
+	}
+	if t.checkpoint != "" {
+		if err := utils.SaveCheckpoint(t.checkpoint, t.state); err != nil {
+			return false, clierr.Wrap(clierr.KindGeneral, err, "")
+		}
+	}
+
+	return !advanced || hasMore != true, nil
+}
+
+// eventKey identifies an event so it is printed once: by its OCSF metadata.uid,
+// or by a digest of its content when it has none
+func eventKey(event any) string {
+	if uid, ok := utils.LookupPath(event, "metadata.uid"); ok && uid != nil {
+		return fmt.Sprint(uid)
+	}
+	encoded, _ := json.Marshal(event)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:16])
+}
+
+// eventTimestamp returns the time of an event, reporting false when it has none
+func eventTimestamp(event any) (time.Time, bool) {
+	value, _ := utils.LookupPath(event, "time")
+	n, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	epoch, err := n.Int64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return eventTime(epoch), true
+}
+
+// retryableTailError reports whether a failed poll is worth retrying
+func retryableTailError(err error) bool {
+	var cliErr *clierr.Error
+	if !errors.As(err, &cliErr) {
+		return false
+	}
+	return slices.Contains([]clierr.Kind{clierr.KindNetwork, clierr.KindServer, clierr.KindRateLimited}, cliErr.Kind)
+}
+
+// securityEventFilters builds the event filters set by --since, --class-uid and --severity-id
+func securityEventFilters(cmd *cobra.Command, now time.Time) (*logs.ListSecurityEventsParams, error) {
+	params := &logs.ListSecurityEventsParams{}
+
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		t, err := utils.ParseSince(since, now)
+		if err != nil {
+			return nil, err
+		}
+		if t.Before(now.Add(-securityEventRetention)) {
+			return nil, clierr.Input("--since must be within the last 90 days")
+		}
+		params.Since = ptr(t.UTC().Format(time.RFC3339))
+	}
+	if cmd.Flags().Changed("class-uid") {
+		classUID, _ := cmd.Flags().GetInt("class-uid")
+		params.ClassUid = ptr(classUID)
+	}
+	if cmd.Flags().Changed("severity-id") {
+		severityID, _ := cmd.Flags().GetInt("severity-id")
+		params.SeverityId = ptr(severityID)
+	}
+	return params, nil
+}
+
+// fetchSecurityEvents fetches one page of security events matching params
+func fetchSecurityEvents(ctx context.Context, client *logs.ClientWithResponses, params logs.ListSecurityEventsParams, page utils.PageRequest) ([]byte, error) {
+	if page.Limit > 0 {
+		params.Limit = ptr(page.Limit)
+	}
+	if page.Cursor != "" {
+		params.Cursor = ptr(page.Cursor)
+	}
+	resp, err := client.ListSecurityEventsWithResponse(ctx, &params)
+	if err := responseError(resp, err, "failed to list security events"); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// readableEventTimes rewrites the epoch time of each event in a page as an
+// RFC 3339 timestamp. Bodies that are not a page of events are returned as-is.
+func readableEventTimes(body []byte) []byte {
+	var page map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&page); err != nil {
+		return body
+	}
+	events, ok := page["data"].([]any)
+	if !ok {
+		return body
+	}
+	for _, event := range events {
+		fields, ok := event.(map[string]any)
+		if !ok {
+			continue
+		}
+		epoch, ok := fields["time"].(json.Number)
+		if !ok {
+			continue
+		}
+		if value, err := epoch.Int64(); err == nil {
+			fields["time"] = eventTime(value).UTC().Format(time.RFC3339)
+		}
+	}
+	rewritten, err := json.Marshal(page)
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
+
+// eventTime converts an event's epoch time, which OCSF defines in milliseconds
+// and which is read as seconds when too small to be a recent time in milliseconds
+func eventTime(epoch int64) time.Time {
+	if epoch < 1e11 {
+		return time.Unix(epoch, 0)
+	}
+	return time.UnixMilli(epoch)
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.AddCommand(logsEventsCmd)
+	logsEventsCmd.AddCommand(logsEventsListCmd)
+	logsEventsCmd.AddCommand(logsEventsTailCmd)
+
+	logsEventsListCmd.Flags().String("since", "", "Only events after this time: RFC 3339, a date, or an age such as 24h or 7d")
+	logsEventsListCmd.Flags().Int("class-uid", 0, "Only events of this OCSF class, such as 3002 (Authentication)")
+	logsEventsListCmd.Flags().Int("severity-id", 0, "Only events of this OCSF severity, from 0 (Unknown) to 6 (Fatal)")
+	logsEventsListCmd.Flags().Int("limit", 100, "Maximum number of events per page (1-1000)")
+	addPaginationFlags(logsEventsListCmd)
+
+	logsEventsTailCmd.Flags().String("since", "", "Start at this time when there is no saved checkpoint (default: now)")
+	logsEventsTailCmd.Flags().Int("class-uid", 0, "Only events of this OCSF class, such as 3002 (Authentication)")
+	logsEventsTailCmd.Flags().Int("severity-id", 0, "Only events of this OCSF severity, from 0 (Unknown) to 6 (Fatal)")
+	logsEventsTailCmd.Flags().Int("limit", 100, "Maximum number of events per request (1-1000)")
+	logsEventsTailCmd.Flags().Duration("interval", 30*time.Second, "Time to wait between polls once caught up")
+	logsEventsTailCmd.Flags().String("checkpoint", "", "File to save the position to and resume from")
+}
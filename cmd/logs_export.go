@@ -0,0 +1,456 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/logs"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
+)
+
+// Security event export formats
+const (
+	exportCEF    = "cef"
+	exportLEEF   = "leef"
+	exportSyslog = "syslog-rfc5424"
+	exportOCSF   = "ocsf-ndjson"
+)
+
+// exportFormats lists the formats accepted by logs events export
+var exportFormats = []string{exportCEF, exportLEEF, exportSyslog, exportOCSF}
+
+// syslogDialTimeout bounds connecting to the syslog receiver
+const syslogDialTimeout = 10 * time.Second
+
+// OCSF severity_id values are 0 Unknown, 1 Informational, 2 Low, 3 Medium,
+// 4 High, 5 Critical, 6 Fatal and 99 Other.
+var (
+	// cefSeverity maps OCSF severities onto CEF and LEEF's 0-10 scale
+	cefSeverity = map[int]int{0: 0, 1: 1, 2: 3, 3: 5, 4: 7, 5: 9, 6: 10, 99: 5}
+	// syslogSeverity maps OCSF severities onto RFC 5424 severities, where 0 is
+	// Emergency and 7 is Debug
+	syslogSeverity = map[int]int{0: 5, 1: 6, 2: 5, 3: 4, 4: 3, 5: 2, 6: 1, 99: 5}
+)
+
+const (
+	// syslogFacility is the RFC 5424 "log audit" facility
+	syslogFacility = 13
+	// syslogSDID names the structured data element carrying the OCSF identifiers.
+	// 32473 is the enterprise number RFC 5612 reserves for documentation use.
+	syslogSDID = "ocsf@32473"
+)
+
+var logsEventsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export security events to a SIEM format",
+	Long: `Export security events as CEF, LEEF, RFC 5424 syslog or OCSF NDJSON, one
+event per line, following every page of results.
+
+The OCSF class, activity and severity of each event are mapped onto the
+format's own fields: the CEF and LEEF event ID is the OCSF type_uid, and the
+OCSF severity sets the CEF and LEEF severity (0-10) and the syslog severity.
+
+Events are written to standard output, appended to --file, or sent to a syslog
+receiver with --syslog udp://host:port or tcp://host:port (port 514 by
+default). Over TCP, syslog messages are framed with their length as RFC 6587
+describes; the other formats are sent one per line.`,
+	Example: `  proof logs events export --format cef --since 24h --file events.cef
+  proof logs events export --format syslog-rfc5424 --since 7d --syslog udp://localhost:514
+  proof logs events export --format leef --severity-id 4 --syslog tcp://127.0.0.1:5140`,
+	PreRun: initializeForAPICall,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+		syslogAddress, _ := cmd.Flags().GetString("syslog")
+		limit, _ := cmd.Flags().GetInt("limit")
+		maxItems, _ := cmd.Flags().GetInt("max-items")
+
+		if limit < 1 || limit > 1000 {
+			clierr.Exit(clierr.Input("--limit must be between 1 and 1000"))
+		}
+		if maxItems < 0 {
+			clierr.Exit(clierr.Input("--max-items must not be negative"))
+		}
+		formatEvent, err := newEventFormatter(format, syslogHostname())
+		if err != nil {
+			clierr.Exit(err)
+		}
+		params, err := securityEventFilters(cmd, time.Now())
+		if err != nil {
+			clierr.Exit(err)
+		}
+
+		sink, destination, err := openEventSink(file, syslogAddress, format)
+		if err != nil {
+			clierr.Exit(err)
+		}
+
+		client := getLogsClient()
+		paginator := utils.Paginator{
+			Style:    utils.PageCursor,
+			First:    utils.PageRequest{Limit: limit},
+			MaxItems: maxItems,
+			Fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				return fetchSecurityEvents(ctx, client, *params, page)
+			},
+		}
+		exported := 0
+		err = paginator.Each(context.Background(), func(records []any) error {
+			for _, record := range records {
+				line, err := formatRecord(formatEvent, record)
+				if err != nil {
+					return err
+				}
+				if err := sink.send(line); err != nil {
+					return clierr.Wrap(clierr.KindGeneral, err, "failed to deliver event to "+destination)
+				}
+				exported++
+			}
+			return nil
+		})
+		if closeErr := sink.Close(); err == nil && closeErr != nil {
+			err = clierr.Wrap(clierr.KindGeneral, closeErr, "failed to deliver events to "+destination)
+		}
+		if err != nil {
+			clierr.Exit(err)
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "Exported %d events to %s\n", exported, destination)
+		}
+	},
+}
+
+// eventFormatter renders one security event; raw is the event as the API returned it
+type eventFormatter func(event *logs.SecurityEventObject, raw []byte) string
+
+// newEventFormatter returns the formatter for an export format. hostname is
+// the HOSTNAME field of syslog messages.
+func newEventFormatter(format, hostname string) (eventFormatter, error) {
+	switch format {
+	case exportCEF:
+		return formatCEF, nil
+	case exportLEEF:
+		return formatLEEF, nil
+	case exportSyslog:
+		return func(event *logs.SecurityEventObject, raw []byte) string {
+			return formatSyslog(event, raw, hostname)
+		}, nil
+	case exportOCSF:
+		return func(_ *logs.SecurityEventObject, raw []byte) string {
+			return string(raw)
+		}, nil
+	default:
+		return nil, clierr.Input("unsupported export format %q: use one of %s", format, strings.Join(exportFormats, ", "))
+	}
+}
+
+// formatRecord renders a record from a page of security events
+func formatRecord(formatEvent eventFormatter, record any) (string, error) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return "", clierr.Wrap(clierr.KindGeneral, err, "failed to encode security event")
+	}
+	var event logs.SecurityEventObject
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return "", clierr.Wrap(clierr.KindGeneral, err, "failed to parse security event")
+	}
+	return formatEvent(&event, raw), nil
+}
+
+// formatCEF renders an event in ArcSight Common Event Format
+func formatCEF(event *logs.SecurityEventObject, _ []byte) string {
+	vendor, product, version := eventProduct(event)
+	header := []string{
+		"CEF:0",
+		cefHeaderEscape(vendor),
+		cefHeaderEscape(product),
+		cefHeaderEscape(version),
+		eventTypeUID(event),
+		cefHeaderEscape(eventName(event)),
+		strconv.Itoa(cefSeverity[intValue(event.SeverityId)]),
+	}
+
+	var extension []string
+	add := func(key, value string) {
+		if value != "" {
+			extension = append(extension, key+"="+cefExtensionEscape(value))
+		}
+	}
+	if event.Time != nil {
+		add("rt", strconv.FormatInt(eventTime(int64(*event.Time)).UnixMilli(), 10))
+	}
+	add("cat", stringValue(event.CategoryName))
+	addLabeled := func(key, label, value string) {
+		if value != "" {
+			add(key, value)
+			add(key+"Label", label)
+		}
+	}
+	addLabeled("cn1", "ocsfClassUid", optionalInt(event.ClassUid))
+	addLabeled("cs1", "ocsfClassName", stringValue(event.ClassName))
+	addLabeled("cn2", "ocsfActivityId", optionalInt(event.ActivityId))
+	addLabeled("cs2", "ocsfActivityName", stringValue(event.ActivityName))
+	addLabeled("cn3", "ocsfSeverityId", optionalInt(event.SeverityId))
+	addLabeled("cs3", "ocsfSeverity", stringValue(event.Severity))
+
+	return strings.Join(header, "|") + "|" + strings.Join(extension, " ")
+}
+
+// formatLEEF renders an event in IBM QRadar's Log Event Extended Format 1.0
+func formatLEEF(event *logs.SecurityEventObject, _ []byte) string {
+	vendor, product, version := eventProduct(event)
+	header := []string{
+		"LEEF:1.0",
+		cefHeaderEscape(vendor),
+		cefHeaderEscape(product),
+		cefHeaderEscape(version),
+		eventTypeUID(event),
+	}
+
+	var attributes []string
+	add := func(key, value string) {
+		if value != "" {
+			attributes = append(attributes, key+"="+leefValue(value))
+		}
+	}
+	if event.Time != nil {
+		add("devTime", eventTime(int64(*event.Time)).UTC().Format("Jan 02 2006 15:04:05"))
+		add("devTimeFormat", "MMM dd yyyy HH:mm:ss")
+	}
+	add("sev", strconv.Itoa(max(1, cefSeverity[intValue(event.SeverityId)])))
+	add("cat", stringValue(event.CategoryName))
+	add("classUid", optionalInt(event.ClassUid))
+	add("className", stringValue(event.ClassName))
+	add("activityId", optionalInt(event.ActivityId))
+	add("activityName", stringValue(event.ActivityName))
+	add("severityId", optionalInt(event.SeverityId))
+	add("severity", stringValue(event.Severity))
+
+	return strings.Join(header, "|") + "|" + strings.Join(attributes, "\t")
+}
+
+// formatSyslog renders an event as an RFC 5424 syslog message whose
+// structured data holds the OCSF identifiers and whose message is the event JSON
+func formatSyslog(event *logs.SecurityEventObject, raw []byte, hostname string) string {
+	priority := syslogFacility*8 + syslogSeverity[intValue(event.SeverityId)]
+	timestamp := "-"
+	if event.Time != nil {
+		timestamp = eventTime(int64(*event.Time)).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	}
+	msgID := eventTypeUID(event)
+	if msgID == "" {
+		msgID = "-"
+	}
+
+	params := []string{syslogSDID}
+	add := func(name, value string) {
+		if value != "" {
+			params = append(params, fmt.Sprintf(`%s="%s"`, name, syslogParamEscape(value)))
+		}
+	}
+	add("class_uid", optionalInt(event.ClassUid))
+	add("activity_id", optionalInt(event.ActivityId))
+	add("category_uid", optionalInt(event.CategoryUid))
+	add("severity_id", optionalInt(event.SeverityId))
+	add("type_uid", optionalInt(event.TypeUid))
+
+	return fmt.Sprintf("<%d>1 %s %s proof - %s [%s] %s", priority, timestamp, hostname, msgID, strings.Join(params, " "), raw)
+}
+
+// eventProduct returns the vendor, product and version reported in an event's
+// metadata. The API reports no product version, so the OCSF schema version is used.
+func eventProduct(event *logs.SecurityEventObject) (vendor, product, version string) {
+	vendor, product = "Proof", "Proof"
+	if event.Metadata == nil {
+		return vendor, product, ""
+	}
+	if p := event.Metadata.Product; p != nil {
+		if v := stringValue(p.VendorName); v != "" {
+			vendor = v
+		}
+		if n := stringValue(p.Name); n != "" {
+			product = n
+		}
+	}
+	return vendor, product, stringValue(event.Metadata.Version)
+}
+
+// eventTypeUID returns the OCSF type_uid of an event, derived from its class
+// and activity when the API leaves it out
+func eventTypeUID(event *logs.SecurityEventObject) string {
+	if event.TypeUid != nil {
+		return strconv.Itoa(*event.TypeUid)
+	}
+	if event.ClassUid != nil && event.ActivityId != nil {
+		return strconv.Itoa(*event.ClassUid*100 + *event.ActivityId)
+	}
+	return ""
+}
+
+// eventName describes an event by its class and activity, such as "Authentication: Logon"
+func eventName(event *logs.SecurityEventObject) string {
+	var parts []string
+	for _, part := range []string{stringValue(event.ClassName), stringValue(event.ActivityName)} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+// cefHeaderEscape escapes a CEF or LEEF header field
+func cefHeaderEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r", " ", "\n", " ").Replace(value)
+}
+
+// cefExtensionEscape escapes a CEF extension value
+func cefExtensionEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r", `\r`, "\n", `\n`).Replace(value)
+}
+
+// leefValue keeps a LEEF attribute value from breaking the tab-separated attribute list
+func leefValue(value string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(value)
+}
+
+// syslogParamEscape escapes an RFC 5424 structured data parameter value
+func syslogParamEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "]", `\]`).Replace(value)
+}
+
+// syslogHostname returns the HOSTNAME field of syslog messages
+func syslogHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "-"
+	}
+	return hostname
+}
+
+// intValue returns the value of an optional integer, or zero
+func intValue(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// optionalInt formats an optional integer, or returns "" when it is unset
+func optionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+// stringValue returns the value of an optional string, or ""
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// eventSink delivers formatted events to a file, standard output or a syslog receiver
+type eventSink struct {
+	w      io.Writer
+	closer io.Closer
+	// frame prepares a message for the transport
+	frame func(message string) string
+}
+
+// send delivers one message
+func (s *eventSink) send(message string) error {
+	_, err := io.WriteString(s.w, s.frame(message))
+	return err
+}
+
+// Close releases the file or connection behind the sink
+func (s *eventSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// newlineFrame terminates each message with a newline
+func newlineFrame(message string) string {
+	return message + "\n"
+}
+
+// openEventSink opens the destination selected by --file or --syslog, returning
+// the sink and a description of the destination
+func openEventSink(file, syslogAddress, format string) (*eventSink, string, error) {
+	if file != "" && syslogAddress != "" {
+		return nil, "", clierr.Input("use either --file or --syslog, not both")
+	}
+
+	if syslogAddress != "" {
+		network, address, err := parseSyslogAddress(syslogAddress)
+		if err != nil {
+			return nil, "", err
+		}
+		conn, err := net.DialTimeout(network, address, syslogDialTimeout)
+		if err != nil {
+			return nil, "", clierr.Wrap(clierr.KindNetwork, err, "failed to connect to syslog receiver")
+		}
+		frame := newlineFrame
+		switch {
+		case network == "udp":
+			// Each write is one datagram, which needs no framing
+			frame = func(message string) string { return message }
+		case format == exportSyslog:
+			frame = func(message string) string { return fmt.Sprintf("%d %s", len(message), message) }
+		}
+		return &eventSink{w: conn, closer: conn, frame: frame}, syslogAddress, nil
+	}
+
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, "", clierr.Wrap(clierr.KindInput, err, "failed to open export file")
+		}
+		return &eventSink{w: f, closer: f, frame: newlineFrame}, file, nil
+	}
+
+	return &eventSink{w: os.Stdout, frame: newlineFrame}, "standard output", nil
+}
+
+// parseSyslogAddress parses a syslog receiver given as udp://host:port or
+// tcp://host:port, where the port defaults to 514
+func parseSyslogAddress(value string) (network, address string, err error) {
+	u, err := url.Parse(value)
+	if err != nil || !slices.Contains([]string{"udp", "tcp"}, u.Scheme) || u.Host == "" {
+		return "", "", clierr.Input("invalid --syslog %q: use udp://host:port or tcp://host:port", value)
+	}
+	address = u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "514")
+	}
+	return u.Scheme, address, nil
+}
+
+func init() {
+	logsEventsCmd.AddCommand(logsEventsExportCmd)
+
+	logsEventsExportCmd.Flags().String("format", "", "Export format: "+strings.Join(exportFormats, ", ")+" (required)")
+	logsEventsExportCmd.Flags().String("file", "", "Append the events to this file instead of printing them")
+	logsEventsExportCmd.Flags().String("syslog", "", "Send the events to a syslog receiver, e.g. udp://localhost:514 or tcp://localhost:514")
+	logsEventsExportCmd.Flags().String("since", "", "Only events after this time: RFC 3339, a date, or an age such as 24h or 7d")
+	logsEventsExportCmd.Flags().Int("class-uid", 0, "Only events of this OCSF class, such as 3002 (Authentication)")
+	logsEventsExportCmd.Flags().Int("severity-id", 0, "Only events of this OCSF severity, from 0 (Unknown) to 6 (Fatal)")
+	logsEventsExportCmd.Flags().Int("limit", 1000, "Maximum number of events per request (1-1000)")
+	logsEventsExportCmd.Flags().Int("max-items", 0, "Stop after exporting this many events")
+	logsEventsExportCmd.MarkFlagRequired("format")
+}
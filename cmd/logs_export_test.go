@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+)
+
+// testSecurityEvent is a security event as the logs API returns it
+var testSecurityEvent = map[string]any{
+	"class_uid":     3002,
+	"class_name":    "Authentication",
+	"activity_id":   1,
+	"activity_name": "Logon",
+	"category_uid":  3,
+	"category_name": "Identity & Access Management",
+	"severity_id":   4,
+	"severity":      "High",
+	"type_uid":      300201,
+	"time":          1767225600000,
+	"metadata": map[string]any{
+		"product": map[string]any{"name": "Proof", "vendor_name": "Proof.com"},
+		"version": "1.1.0",
+	},
+}
+
+// formatTestEvent renders testSecurityEvent in format
+func formatTestEvent(t *testing.T, format string) string {
+	formatEvent, err := newEventFormatter(format, "host1")
+	require.NoError(t, err)
+	line, err := formatRecord(formatEvent, testSecurityEvent)
+	require.NoError(t, err)
+	return line
+}
+
+func TestExportFormats(t *testing.T) {
+	assert.Equal(t,
+		"CEF:0|Proof.com|Proof|1.1.0|300201|Authentication: Logon|7|rt=1767225600000 cat=Identity & Access Management "+
+			"cn1=3002 cn1Label=ocsfClassUid cs1=Authentication cs1Label=ocsfClassName cn2=1 cn2Label=ocsfActivityId "+
+			"cs2=Logon cs2Label=ocsfActivityName cn3=4 cn3Label=ocsfSeverityId cs3=High cs3Label=ocsfSeverity",
+		formatTestEvent(t, exportCEF))
+
+	assert.Equal(t,
+		"LEEF:1.0|Proof.com|Proof|1.1.0|300201|devTime=Jan 01 2026 00:00:00\tdevTimeFormat=MMM dd yyyy HH:mm:ss\tsev=7\t"+
+			"cat=Identity & Access Management\tclassUid=3002\tclassName=Authentication\tactivityId=1\tactivityName=Logon\t"+
+			"severityId=4\tseverity=High",
+		formatTestEvent(t, exportLEEF))
+
+	syslog := formatTestEvent(t, exportSyslog)
+	assert.True(t, strings.HasPrefix(syslog,
+		`<107>1 2026-01-01T00:00:00.000Z host1 proof - 300201 [ocsf@32473 class_uid="3002" activity_id="1" category_uid="3" severity_id="4" type_uid="300201"] {`),
+		syslog)
+	assert.Contains(t, syslog, `"activity_name":"Logon"`)
+
+	ocsf := formatTestEvent(t, exportOCSF)
+	assert.True(t, strings.HasPrefix(ocsf, "{") && !strings.Contains(ocsf, "\n"), ocsf)
+	assert.Contains(t, ocsf, `"type_uid":300201`)
+
+	_, err := newEventFormatter("json", "host1")
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
+}
+
+func TestFormatCEF_Escaping(t *testing.T) {
+	formatEvent, err := newEventFormatter(exportCEF, "host1")
+	require.NoError(t, err)
+	line, err := formatRecord(formatEvent, map[string]any{"class_name": "A|B", "category_name": "x=y\nz"})
+	require.NoError(t, err)
+	assert.Equal(t, `CEF:0|Proof|Proof|||A\|B|0|cat=x\=y\nz cs1=A|B cs1Label=ocsfClassName`, line)
+}
+
+func TestOpenEventSink_Syslog(t *testing.T) {
+	t.Run("udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		sink, _, err := openEventSink("", "udp://"+conn.LocalAddr().String(), exportSyslog)
+		require.NoError(t, err)
+		require.NoError(t, sink.send("<110>1 - - proof - - - hello"))
+		require.NoError(t, sink.Close())
+
+		buf := make([]byte, 1024)
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "<110>1 - - proof - - - hello", string(buf[:n]), "one datagram per message")
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		received := make(chan string)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				received <- err.Error()
+				return
+			}
+			defer conn.Close()
+			var lines []string
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			received <- strings.Join(lines, "|")
+		}()
+
+		sink, _, err := openEventSink("", "tcp://"+listener.Addr().String(), exportSyslog)
+		require.NoError(t, err)
+		require.NoError(t, sink.send("first"))
+		require.NoError(t, sink.send("second"))
+		require.NoError(t, sink.Close())
+		assert.Equal(t, "5 first6 second", <-received, "syslog over TCP is framed with octet counts")
+	})
+
+	for _, address := range []string{"localhost:514", "http://localhost", "udp://"} {
+		_, _, err := openEventSink("", address, exportCEF)
+		var cliErr *clierr.Error
+		require.ErrorAs(t, err, &cliErr, address)
+		assert.Equal(t, clierr.KindInput, cliErr.Kind)
+	}
+}
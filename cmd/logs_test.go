@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/logs"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
+)
+
+// newEventFilterCmd returns a command with the event filter flags set from args
+func newEventFilterCmd(t *testing.T, args ...string) *cobra.Command {
+	cmd := &cobra.Command{Use: "list"}
+	cmd.Flags().String("since", "", "")
+	cmd.Flags().Int("class-uid", 0, "")
+	cmd.Flags().Int("severity-id", 0, "")
+	require.NoError(t, cmd.Flags().Parse(args))
+	return cmd
+}
+
+func TestSecurityEventFilters(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	params, err := securityEventFilters(newEventFilterCmd(t, "--since", "24h", "--severity-id", "0"), now)
+	require.NoError(t, err)
+	require.NotNil(t, params.Since)
+	assert.Equal(t, "2026-03-09T12:00:00Z", *params.Since)
+	assert.Nil(t, params.ClassUid, "unset filters are not sent")
+	require.NotNil(t, params.SeverityId, "an explicit zero severity is sent")
+	assert.Equal(t, 0, *params.SeverityId)
+
+	for _, since := range []string{"91d", "yesterday"} {
+		_, err = securityEventFilters(newEventFilterCmd(t, "--since", since), now)
+		var cliErr *clierr.Error
+		require.ErrorAs(t, err, &cliErr, since)
+		assert.Equal(t, clierr.KindInput, cliErr.Kind)
+	}
+}
+
+func TestReadableEventTimes(t *testing.T) {
+	body := []byte(`{"data":[{"class_name":"Authentication","time":1767225600},{"class_name":"Account Change","time":1767225600000}],"meta":{"has_more":false}}`)
+
+	rewritten := string(readableEventTimes(body))
+	assert.Contains(t, rewritten, `{"class_name":"Authentication","time":"2026-01-01T00:00:00Z"}`)
+	assert.Contains(t, rewritten, `{"class_name":"Account Change","time":"2026-01-01T00:00:00Z"}`)
+
+	assert.Equal(t, "not json", string(readableEventTimes([]byte("not json"))))
+}
+
+func TestFetchSecurityEvents_FollowsCursor(t *testing.T) {
+	saveOutputGlobals(t)
+	outputFormat = utils.FormatNDJSON
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("cursor") == "" {
+			w.Write([]byte(`{"data":[{"type_uid":300201}],"meta":{"next_cursor":"c2","has_more":true,"count":1}}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"type_uid":300202}],"meta":{"next_cursor":"c3","has_more":false,"count":1}}`))
+	}))
+	defer server.Close()
+
+	client, err := logs.NewClientWithResponses(server.URL)
+	require.NoError(t, err)
+	params := logs.ListSecurityEventsParams{Since: ptr("2026-03-09T12:00:00Z")}
+
+	output := captureOutput(func() {
+		runList(newListCmd(t, "--all", "--page-size", "50"), listPages{
+			style:       utils.PageCursor,
+			first:       utils.PageRequest{Limit: 100},
+			maxPageSize: 1000,
+			fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				return fetchSecurityEvents(ctx, client, params, page)
+			},
+		})
+	})
+
+	assert.Equal(t, "{\"type_uid\":300201}\n{\"type_uid\":300202}\n", output)
+	require.Len(t, queries, 2, "has_more false ends the listing")
+	assert.Equal(t, "limit=50&since=2026-03-09T12%3A00%3A00Z", queries[0])
+	assert.Contains(t, queries[1], "cursor=c2")
+}
+
+// fakeEventFeed serves security event pages keyed by cursor, recording the cursors asked for
+type fakeEventFeed struct {
+	pages   map[string]string
+	cursors []string
+	since   []string
+}
+
+func (f *fakeEventFeed) fetch(_ context.Context, since string, page utils.PageRequest) ([]byte, error) {
+	f.cursors = append(f.cursors, page.Cursor)
+	f.since = append(f.since, since)
+	return []byte(f.pages[page.Cursor]), nil
+}
+
+// newTestTail returns a tail reading feed that saves to checkpoint and collects printed events
+func newTestTail(t *testing.T, feed *fakeEventFeed, checkpoint string, printed *[]any) *eventTail {
+	params := &logs.ListSecurityEventsParams{Since: ptr("2026-03-09T12:00:00Z")}
+	state, err := tailStartingPoint(checkpoint, params, time.Now())
+	require.NoError(t, err)
+	return &eventTail{
+		state:      state,
+		checkpoint: checkpoint,
+		limit:      2,
+		interval:   time.Second,
+		fetch:      feed.fetch,
+		write: func(records []any) error {
+			*printed = append(*printed, records...)
+			return nil
+		},
+	}
+}
+
+func TestEventTail_ResumesFromCheckpoint(t *testing.T) {
+	checkpoint := filepath.Join(t.TempDir(), "events.checkpoint")
+	feed := &fakeEventFeed{pages: map[string]string{
+		"":   `{"data":[{"n":1},{"n":2}],"meta":{"next_cursor":"c1","has_more":true}}`,
+		"c1": `{"data":[{"n":3}],"meta":{"next_cursor":"c1","has_more":false}}`,
+	}}
+
+	var printed []any
+	tail := newTestTail(t, feed, checkpoint, &printed)
+	caughtUp, err := tail.poll(context.Background())
+	require.NoError(t, err)
+	assert.False(t, caughtUp, "has_more fetches the next page straight away")
+	caughtUp, err = tail.poll(context.Background())
+	require.NoError(t, err)
+	assert.True(t, caughtUp)
+	assert.Len(t, printed, 3)
+
+	saved, err := utils.LoadCheckpoint(checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "c1", saved.Cursor)
+	assert.Len(t, saved.Seen, 1)
+	assert.Equal(t, "2026-03-09T12:00:00Z", saved.Since)
+
+	// A restarted tail picks up the page that was still filling without repeating its first event
+	feed.pages["c1"] = `{"data":[{"n":3},{"n":4}],"meta":{"next_cursor":"c2","has_more":false}}`
+	feed.pages["c2"] = `{"data":[],"meta":{"next_cursor":"c2","has_more":false}}`
+	printed = nil
+	tail = newTestTail(t, feed, checkpoint, &printed)
+	for range 2 {
+		_, err = tail.poll(context.Background())
+		require.NoError(t, err)
+	}
+	require.Len(t, printed, 1)
+	assert.Equal(t, map[string]any{"n": json.Number("4")}, printed[0])
+	assert.Equal(t, []string{"", "c1", "c1", "c2"}, feed.cursors)
+
+	saved, err = utils.LoadCheckpoint(checkpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "c2", saved.Cursor)
+	assert.Empty(t, saved.Seen)
+}
+
+func TestEventTail_AdvancesSinceWithoutCursor(t *testing.T) {
+	checkpoint := filepath.Join(t.TempDir(), "events.checkpoint")
+	// 2026-03-10T08:00:00Z and one and a half seconds later, in milliseconds
+	feed := &fakeEventFeed{pages: map[string]string{
+		"": `{"data":[{"time":1773129600000,"n":1},{"time":1773129601500,"n":2}],"meta":{"has_more":false}}`,
+	}}
+
+	var printed []any
+	tail := newTestTail(t, feed, checkpoint, &printed)
+	_, err := tail.poll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, printed, 2)
+
+	saved, err := utils.LoadCheckpoint(checkpoint)
+	require.NoError(t, err)
+	assert.Empty(t, saved.Cursor)
+	assert.Equal(t, "2026-03-10T08:00:01Z", saved.Since, "since moves up to the newest event printed")
+	assert.Len(t, saved.Seen, 2, "every event on the page is remembered")
+
+	// The first event has aged out of the window and a new one arrived in the
+	// same second as the second: only the new one is printed
+	feed.pages[""] = `{"data":[{"time":1773129601500,"n":2},{"time":1773129601900,"n":3}],"meta":{"has_more":false}}`
+	printed = nil
+	tail = newTestTail(t, feed, checkpoint, &printed)
+	_, err = tail.poll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, printed, 1)
+	assert.Equal(t, json.Number("3"), printed[0].(map[string]any)["n"])
+	assert.Equal(t, []string{"2026-03-09T12:00:00Z", "2026-03-10T08:00:01Z"}, feed.since)
+}
+
+func TestEventTail_SkipsLateIngestedEvents(t *testing.T) {
+	// since filters on ingestion time, so an event whose own time is older
+	// than the advanced start time is still returned
+	feed := &fakeEventFeed{pages: map[string]string{
+		"": `{"data":[{"time":1773129600000,"n":1},{"time":1773129660000,"n":2}],"meta":{"next_cursor":null}}`,
+	}}
+
+	var printed []any
+	tail := newTestTail(t, feed, "", &printed)
+	_, err := tail.poll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, printed, 2)
+	assert.Equal(t, "2026-03-10T08:01:00Z", tail.state.Since)
+
+	feed.pages[""] = `{"data":[{"time":1773129600000,"n":1},{"time":1773129660000,"n":2},{"time":1773129630000,"n":3}],"meta":{"next_cursor":null}}`
+	printed = nil
+	_, err = tail.poll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, printed, 1, "events older than since are not printed twice")
+	assert.Equal(t, json.Number("3"), printed[0].(map[string]any)["n"])
+}
+
+func TestEventKey(t *testing.T) {
+	withUID := map[string]any{"metadata": map[string]any{"uid": "evt_1"}, "time": json.Number("1")}
+	assert.Equal(t, "evt_1", eventKey(withUID))
+
+	a := map[string]any{"time": json.Number("1773129600000"), "n": json.Number("1")}
+	b := map[string]any{"n": json.Number("1"), "time": json.Number("1773129600000")}
+	c := map[string]any{"time": json.Number("1773129600000"), "n": json.Number("2")}
+	assert.Equal(t, eventKey(a), eventKey(b))
+	assert.NotEqual(t, eventKey(a), eventKey(c))
+}
+
+func TestTailStartingPoint(t *testing.T) {
+	checkpoint := filepath.Join(t.TempDir(), "events.checkpoint")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	params := &logs.ListSecurityEventsParams{}
+	state, err := tailStartingPoint(checkpoint, params, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-10T12:00:00Z", state.Since, "without --since the tail starts now")
+	assert.Equal(t, "2026-03-10T12:00:00Z", *params.Since)
+
+	state.ClassUID = ptr(3002)
+	require.NoError(t, utils.SaveCheckpoint(checkpoint, state))
+
+	_, err = tailStartingPoint(checkpoint, &logs.ListSecurityEventsParams{}, now)
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr, "a checkpoint is tied to its filters")
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
+
+	params = &logs.ListSecurityEventsParams{ClassUid: ptr(3002), Since: ptr("2026-03-01T00:00:00Z")}
+	_, err = tailStartingPoint(checkpoint, params, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-10T12:00:00Z", *params.Since, "the saved start time wins over --since")
+}
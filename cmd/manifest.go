@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tsarlewey/proof-cli/openapi"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"gopkg.in/yaml.v3"
+)
+
+// manifestKind describes how a transaction manifest maps onto an API
+type manifestKind struct {
+	// spec is the OpenAPI specification the request is validated against
+	spec string
+	// documentSchema validates document objects
+	documentSchema string
+	// contentField is the document object field holding the file contents
+	contentField string
+	// wrapStrings turns plain document strings into objects, for APIs that only accept objects
+	wrapStrings bool
+}
+
+var (
+	businessManifest = manifestKind{
+		spec:           openapi.Business,
+		documentSchema: "document_creation_params",
+		contentField:   "resource",
+	}
+	realEstateManifest = manifestKind{
+		spec:           openapi.RealEstate,
+		documentSchema: "document_params",
+		contentField:   "document",
+		wrapStrings:    true,
+	}
+)
+
+// manifestSchema is the component schema both create endpoints accept
+const manifestSchema = "transaction_create_params"
+
+// loadManifest reads a YAML or JSON transaction manifest ("-" for stdin),
+// resolves its documents and validates it against the API specification.
+// It returns the request body ready to send.
+func loadManifest(path string, kind manifestKind) ([]byte, error) {
+	var (
+		data    []byte
+		err     error
+		baseDir = "."
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+		baseDir = filepath.Dir(path)
+	}
+	if err != nil {
+		return nil, clierr.Wrap(clierr.KindInput, err, "failed to read manifest")
+	}
+
+	// JSON is valid YAML, so one decoder handles both
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, clierr.Wrap(clierr.KindInput, err, "failed to parse manifest")
+	}
+
+	// Round-trip through JSON so values have the types the validator expects
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, clierr.Wrap(clierr.KindInput, err, "failed to parse manifest")
+	}
+	var manifest map[string]any
+	if err := json.Unmarshal(encoded, &manifest); err != nil {
+		return nil, clierr.Input("manifest must be an object of transaction fields")
+	}
+
+	if err := resolveManifestDocuments(manifest, kind, baseDir); err != nil {
+		return nil, err
+	}
+	if err := validateManifest(manifest, kind); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, clierr.Wrap(clierr.KindGeneral, err, "failed to encode request")
+	}
+	return body, nil
+}
+
+// resolveManifestDocuments replaces local file references in "document" and
+// "documents" with their base64 contents
+func resolveManifestDocuments(manifest map[string]any, kind manifestKind, baseDir string) error {
+	resolve := func(entry any) (any, error) {
+		switch v := entry.(type) {
+		case string:
+			content, err := resolveDocumentSource(v, baseDir)
+			if err != nil {
+				return nil, err
+			}
+			if kind.wrapStrings {
+				return map[string]any{kind.contentField: content}, nil
+			}
+			return content, nil
+		case map[string]any:
+			for _, field := range []string{"resource", "document"} {
+				if source, ok := v[field].(string); ok {
+					content, err := resolveDocumentSource(source, baseDir)
+					if err != nil {
+						return nil, err
+					}
+					v[field] = content
+				}
+			}
+			return v, nil
+		default:
+			return nil, clierr.Input("manifest documents must be strings or objects")
+		}
+	}
+
+	if single, ok := manifest["document"]; ok {
+		resolved, err := resolve(single)
+		if err != nil {
+			return err
+		}
+		manifest["document"] = resolved
+	}
+
+	if list, ok := manifest["documents"]; ok {
+		items, ok := list.([]any)
+		if !ok {
+			return clierr.Input("manifest documents must be a list")
+		}
+		for i, item := range items {
+			resolved, err := resolve(item)
+			if err != nil {
+				return err
+			}
+			items[i] = resolved
+		}
+	}
+	return nil
+}
+
+// resolveDocumentSource turns a document reference into what the API accepts.
+// URLs and base64 content are passed through, local files are read and encoded.
+// Anything else that looks like a path is an error, so a typo in a file name is
+// not silently sent as a template permalink.
+func resolveDocumentSource(source, baseDir string) (string, error) {
+	if isURL(source) {
+		return source, nil
+	}
+
+	path := source
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", clierr.Wrap(clierr.KindInput, err, "failed to read document file")
+		}
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(source); err == nil {
+		return source, nil
+	}
+	if filepath.Ext(source) != "" || strings.ContainsAny(source, `/\`) {
+		return "", clierr.Input("document %q not found", source)
+	}
+	return source, nil
+}
+
+// validateManifest checks a resolved manifest against the API specification.
+// Documents are checked against the document schema separately, since the
+// business API accepts objects where its specification lists strings.
+func validateManifest(manifest map[string]any, kind manifestKind) error {
+	body := make(map[string]any, len(manifest))
+	for k, v := range manifest {
+		if k != "documents" && k != "document" {
+			body[k] = v
+		}
+	}
+
+	messages, err := openapi.Validate(kind.spec, manifestSchema, body)
+	if err != nil {
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to validate manifest")
+	}
+
+	documents := map[string]any{}
+	if single, ok := manifest["document"]; ok {
+		documents["document"] = single
+	}
+	if list, ok := manifest["documents"].([]any); ok {
+		for i, item := range list {
+			documents["documents."+strconv.Itoa(i)] = item
+		}
+	}
+	for prefix, document := range documents {
+		if _, ok := document.(map[string]any); !ok {
+			continue
+		}
+		docMessages, err := openapi.Validate(kind.spec, kind.documentSchema, document)
+		if err != nil {
+			return clierr.Wrap(clierr.KindGeneral, err, "failed to validate manifest")
+		}
+		for _, message := range docMessages {
+			messages = append(messages, prefix+"."+message)
+		}
+	}
+	sort.Strings(messages)
+
+	if len(messages) > 0 {
+		return &clierr.Error{Kind: clierr.KindInput, Message: "manifest does not match the API schema", Details: messages}
+	}
+	return nil
+}
+
+// manifestFlagConflict reports whether flags that describe the request body were given alongside -f
+func manifestFlagConflict(cmd *cobra.Command) error {
+	var conflicts []string
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed && f.Name != "file" {
+			conflicts = append(conflicts, "--"+f.Name)
+		}
+	})
+	if len(conflicts) > 0 {
+		return clierr.Input("--file cannot be combined with %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+)
+
+// writeManifest writes a manifest and a document next to it in a temp directory
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deed.pdf"), []byte("%PDF-1.4"), 0600))
+	path := filepath.Join(dir, "txn.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadManifest_Business(t *testing.T) {
+	path := writeManifest(t, `
+signer:
+  email: jane@example.com
+  first_name: Jane
+signers:
+  - email: john@example.com
+payer: sender
+draft: true
+documents:
+  - deed.pdf
+  - https://example.com/disclosure.pdf
+  - aGVsbG8=
+  - resource: deed.pdf
+    requirement: esign
+`)
+
+	body, err := loadManifest(path, businessManifest)
+	require.NoError(t, err)
+
+	var request map[string]any
+	require.NoError(t, json.Unmarshal(body, &request))
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("%PDF-1.4"))
+	assert.Equal(t, []any{
+		encoded,
+		"https://example.com/disclosure.pdf",
+		"aGVsbG8=",
+		map[string]any{"resource": encoded, "requirement": "esign"},
+	}, request["documents"])
+	assert.Equal(t, "sender", request["payer"])
+	assert.Equal(t, true, request["draft"])
+}
+
+func TestLoadManifest_RealEstateWrapsDocuments(t *testing.T) {
+	path := writeManifest(t, `{
+  "transaction_type": "refinance",
+  "street_address": {"line1": "123 Main St", "city": "Arlington", "state": "VA", "postal": "22201"},
+  "documents": ["deed.pdf"]
+}`)
+
+	body, err := loadManifest(path, realEstateManifest)
+	require.NoError(t, err)
+
+	var request map[string]any
+	require.NoError(t, json.Unmarshal(body, &request))
+	assert.Equal(t, []any{map[string]any{"document": base64.StdEncoding.EncodeToString([]byte("%PDF-1.4"))}}, request["documents"])
+}
+
+func TestLoadManifest_SchemaErrors(t *testing.T) {
+	path := writeManifest(t, `
+signer:
+  first_name: Jane
+payer: notary
+draft: "yes"
+documents:
+  - resource: deed.pdf
+    requirment: esign
+`)
+
+	_, err := loadManifest(path, businessManifest)
+	require.Error(t, err)
+
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
+	assert.Equal(t, []string{
+		"documents.0.requirment: unknown property",
+		"draft: value must be a boolean",
+		`payer: value is not one of the allowed values ["signer","sender"]`,
+		`signer.email: property "email" is missing`,
+	}, cliErr.Details)
+}
+
+func TestLoadManifest_MissingDocument(t *testing.T) {
+	path := writeManifest(t, `
+signer:
+  email: jane@example.com
+documents:
+  - missing.pdf
+`)
+
+	_, err := loadManifest(path, businessManifest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `document "missing.pdf" not found`)
+}
+
+func TestLoadManifest_NotAnObject(t *testing.T) {
+	path := writeManifest(t, "- one\n- two\n")
+
+	_, err := loadManifest(path, businessManifest)
+	assert.Error(t, err)
+
+	_, err = loadManifest(filepath.Join(t.TempDir(), "missing.yaml"), businessManifest)
+	assert.Error(t, err)
+}
+
+func TestManifestFlagConflict(t *testing.T) {
+	cmd := &cobra.Command{Use: "create"}
+	cmd.Flags().String("file", "", "")
+	cmd.Flags().String("email", "", "")
+
+	require.NoError(t, cmd.Flags().Set("file", "txn.yaml"))
+	assert.NoError(t, manifestFlagConflict(cmd))
+
+	require.NoError(t, cmd.Flags().Set("email", "jane@example.com"))
+	err := manifestFlagConflict(cmd)
+	require.Error(t, err)
+	assert.Equal(t, "--file cannot be combined with --email", err.Error())
+}
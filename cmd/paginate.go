@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
+)
+
+// listPages describes how a list command fetches its results
+type listPages struct {
+	style utils.PageStyle
+	// first is the page selected by the command's own limit and offset flags;
+	// a zero Limit leaves the page size to the API
+	first utils.PageRequest
+	// maxPageSize is the largest page the endpoint accepts, used by default with --all
+	maxPageSize int
+	fetch       utils.FetchPage
+}
+
+// addPaginationFlags registers --all, --max-items and --page-size on a list command
+func addPaginationFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("all", false, "Fetch every page of results, streaming them as they arrive")
+	cmd.Flags().Int("max-items", 0, "Stop after this many results, fetching further pages as needed")
+	cmd.Flags().Int("page-size", 0, "Results to request per page with --all or --max-items (default: the API maximum)")
+}
+
+// runList prints a single page of results, or with --all or --max-items walks
+// every page and streams the records. Streamed records are printed one by one,
+// so --query, --fields and --template apply to each record rather than to a page.
+func runList(cmd *cobra.Command, pages listPages) {
+	all, _ := cmd.Flags().GetBool("all")
+	maxItems, _ := cmd.Flags().GetInt("max-items")
+	pageSize, _ := cmd.Flags().GetInt("page-size")
+
+	if maxItems < 0 {
+		clierr.Exit(clierr.Input("--max-items must not be negative"))
+	}
+	if pageSize < 0 || pageSize > pages.maxPageSize {
+		clierr.Exit(clierr.Input("--page-size must be between 1 and %d", pages.maxPageSize))
+	}
+
+	ctx := context.Background()
+	if !all && maxItems == 0 {
+		first := pages.first
+		if pageSize > 0 {
+			first.Limit = pageSize
+		}
+		body, err := pages.fetch(ctx, first)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		PrintResponse(body)
+		return
+	}
+
+	first := pages.first
+	first.Limit = pages.maxPageSize
+	if pageSize > 0 {
+		first.Limit = pageSize
+	}
+
+	if dryRun || asCurl {
+		// Later pages depend on the responses, so a dry run shows the first request
+		if _, err := pages.fetch(ctx, first); err != nil {
+			clierr.Exit(err)
+		}
+		return
+	}
+
+	out, err := newRecordOutput()
+	if err != nil {
+		clierr.Exit(err)
+	}
+	paginator := utils.Paginator{
+		Style:    pages.style,
+		First:    first,
+		MaxItems: maxItems,
+		Fetch: func(ctx context.Context, req utils.PageRequest) ([]byte, error) {
+			if verbose {
+				// stderr keeps progress out of the streamed records
+				fmt.Fprintf(os.Stderr, "Fetching page (offset %d, limit %d%s)\n", req.Offset, req.Limit, cursorSuffix(req.Cursor))
+			}
+			return pages.fetch(ctx, req)
+		},
+	}
+	err = paginator.Each(ctx, out.write)
+	// Close the output even on failure so what was printed stays well-formed
+	if closeErr := out.writer.Close(); err == nil && closeErr != nil {
+		err = clierr.Wrap(clierr.KindGeneral, closeErr, "failed to write output")
+	}
+	if err != nil {
+		clierr.Exit(err)
+	}
+}
+
+// cursorSuffix describes a page cursor for verbose output
+func cursorSuffix(cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	return ", cursor " + cursor
+}
+
+// recordOutput prints streamed records honoring --output, --query, --fields and --template
+type recordOutput struct {
+	writer *utils.RecordWriter
+	query  *utils.Query
+}
+
+// newRecordOutput prepares a record stream for the selected output options.
+// Without --output the stream is pretty printed JSON, or NDJSON with --pretty=false.
+func newRecordOutput() (*recordOutput, error) {
+	format := outputFormat
+	if format == "" {
+		format = utils.FormatJSON
+		if !prettyPrint {
+			format = utils.FormatNDJSON
+		}
+	}
+	if outputTmpl != nil {
+		// Templates are rendered per record; the writer only frames the output
+		format = utils.FormatNDJSON
+	}
+
+	writer, err := utils.NewRecordWriter(os.Stdout, format, tableColumns...)
+	if err != nil {
+		return nil, clierr.Wrap(clierr.KindInput, err, "")
+	}
+	if outputFormat == "" && prettyPrint {
+		writer.Colorize = colorizeJSON
+	}
+
+	out := &recordOutput{writer: writer}
+	if queryExpr != "" {
+		out.query, err = utils.CompileQuery(queryExpr)
+		if err != nil {
+			return nil, clierr.Wrap(clierr.KindInput, err, "")
+		}
+	}
+	return out, nil
+}
+
+// write projects and prints one page of records
+func (o *recordOutput) write(records []any) error {
+	if o.query != nil {
+		var results []any
+		for _, record := range records {
+			values, err := o.query.Eval(record)
+			if err != nil {
+				return clierr.Wrap(clierr.KindInput, err, "failed to apply query")
+			}
+			results = append(results, values...)
+		}
+		records = results
+	}
+
+	if len(selectFields) > 0 {
+		encoded, err := json.Marshal(records)
+		if err != nil {
+			return clierr.Wrap(clierr.KindGeneral, err, "failed to select fields")
+		}
+		projected, err := utils.SelectFields(encoded, selectFields)
+		if err != nil {
+			return clierr.Wrap(clierr.KindInput, err, "failed to select fields")
+		}
+		records = nil
+		if err := json.Unmarshal(projected, &records); err != nil {
+			return clierr.Wrap(clierr.KindGeneral, err, "failed to select fields")
+		}
+	}
+
+	if outputTmpl != nil {
+		for _, record := range records {
+			encoded, err := json.Marshal(record)
+			if err != nil {
+				return clierr.Wrap(clierr.KindGeneral, err, "failed to render template")
+			}
+			rendered, err := utils.ExecuteTemplate(outputTmpl, encoded)
+			if err != nil {
+				return clierr.Wrap(clierr.KindInput, err, "failed to render template")
+			}
+			if !strings.HasSuffix(rendered, "\n") {
+				rendered += "\n"
+			}
+			fmt.Print(rendered)
+		}
+		return nil
+	}
+
+	if err := o.writer.Write(records); err != nil {
+		return clierr.Wrap(clierr.KindGeneral, err, "failed to write output")
+	}
+	return nil
+}
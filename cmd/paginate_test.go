@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
+)
+
+// newListCmd returns a command with the pagination flags set from args
+func newListCmd(t *testing.T, args ...string) *cobra.Command {
+	cmd := &cobra.Command{Use: "list"}
+	addPaginationFlags(cmd)
+	require.NoError(t, cmd.Flags().Parse(args))
+	return cmd
+}
+
+// fakeTransactionPages serves total transactions through an offset-paged endpoint
+func fakeTransactionPages(total int, requests *[]utils.PageRequest) listPages {
+	return listPages{
+		style:       utils.PageOffset,
+		first:       utils.PageRequest{Limit: 10},
+		maxPageSize: 3,
+		fetch: func(_ context.Context, req utils.PageRequest) ([]byte, error) {
+			*requests = append(*requests, req)
+			data := []any{}
+			for i := req.Offset; i < total && i < req.Offset+req.Limit; i++ {
+				data = append(data, map[string]any{"id": i, "status": "completed"})
+			}
+			return json.Marshal(map[string]any{"data": data, "total_count": total})
+		},
+	}
+}
+
+// saveOutputGlobals restores the output settings changed by a test
+func saveOutputGlobals(t *testing.T) {
+	oldFormat, oldPretty, oldQuery, oldFields, oldColumns, oldTmpl := outputFormat, prettyPrint, queryExpr, selectFields, tableColumns, outputTmpl
+	t.Cleanup(func() {
+		outputFormat, prettyPrint, queryExpr, selectFields, tableColumns, outputTmpl = oldFormat, oldPretty, oldQuery, oldFields, oldColumns, oldTmpl
+	})
+	outputFormat, prettyPrint, queryExpr, selectFields, tableColumns, outputTmpl = "", false, "", nil, nil, nil
+}
+
+func TestRunList_SinglePage(t *testing.T) {
+	saveOutputGlobals(t)
+	var requests []utils.PageRequest
+
+	output := captureOutput(func() {
+		runList(newListCmd(t), fakeTransactionPages(25, &requests))
+	})
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, 10, requests[0].Limit, "without --all the command's own limit is used")
+	assert.Contains(t, output, `"total_count":25`)
+}
+
+func TestRunList_AllStreamsNDJSON(t *testing.T) {
+	saveOutputGlobals(t)
+	outputFormat = utils.FormatNDJSON
+	var requests []utils.PageRequest
+
+	output := captureOutput(func() {
+		runList(newListCmd(t, "--all"), fakeTransactionPages(7, &requests))
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Len(t, lines, 7)
+	assert.Equal(t, `{"id":6,"status":"completed"}`, lines[6])
+	assert.Len(t, requests, 3)
+	assert.Equal(t, 3, requests[0].Limit, "--all defaults to the largest page size")
+}
+
+func TestRunList_MaxItemsAndPageSize(t *testing.T) {
+	saveOutputGlobals(t)
+	outputFormat = utils.FormatJSON
+	var requests []utils.PageRequest
+
+	output := captureOutput(func() {
+		runList(newListCmd(t, "--max-items", "3", "--page-size", "2"), fakeTransactionPages(100, &requests))
+	})
+
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(output), &decoded))
+	assert.Len(t, decoded, 3)
+	assert.Len(t, requests, 2)
+}
+
+func TestRunList_QueryAppliesPerRecord(t *testing.T) {
+	saveOutputGlobals(t)
+	outputFormat = utils.FormatNDJSON
+	queryExpr = ".id"
+	var requests []utils.PageRequest
+
+	output := captureOutput(func() {
+		runList(newListCmd(t, "--all"), fakeTransactionPages(4, &requests))
+	})
+
+	assert.Equal(t, "0\n1\n2\n3\n", output)
+}
+
+func TestRunList_TableHeaderOnce(t *testing.T) {
+	saveOutputGlobals(t)
+	outputFormat = utils.FormatTable
+	tableColumns = []string{"id"}
+	var requests []utils.PageRequest
+
+	output := captureOutput(func() {
+		runList(newListCmd(t, "--all"), fakeTransactionPages(5, &requests))
+	})
+
+	assert.Equal(t, "ID\n0\n1\n2\n3\n4\n", output)
+}
@@ -1,14 +1,16 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
-	"fmt"
 	"os"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 	"github.com/tsarlewey/proof-cli/pkg/sdk/realestate"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
 )
 
 // realEstateCmd represents the real-estate command
@@ -27,16 +29,17 @@ var reTransactionsCmd = &cobra.Command{
 }
 
 var reListTransactionsCmd = &cobra.Command{
-	Use:    "list",
-	Short:  "List real estate transactions",
-	Long:   `List real estate transactions with optional filtering`,
-	PreRun: initializeForAPICall,
+	Use:         "list",
+	Short:       "List real estate transactions",
+	Long:        `List real estate transactions with optional filtering`,
+	Annotations: map[string]string{annotationColumns: "id,transaction_type,detailed_status,loan_number,file_number,date_created"},
+	PreRun:      initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get command line flags
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
 		status, _ := cmd.Flags().GetString("status")
-		organizationID, _ := cmd.Flags().GetString("organization-id")
+		organizationID := organizationFlag(cmd, "organization-id")
 		loanNumber, _ := cmd.Flags().GetString("loan-number")
 		createdDateStart, _ := cmd.Flags().GetString("created-date-start")
 		createdDateEnd, _ := cmd.Flags().GetString("created-date-end")
@@ -47,12 +50,6 @@ var reListTransactionsCmd = &cobra.Command{
 			DocumentUrlVersion: ptr(realestate.GetAllMortgageTransactionsParamsDocumentUrlVersionV2),
 		}
 
-		if limit > 0 {
-			params.Limit = ptr(limit)
-		}
-		if offset > 0 {
-			params.Offset = ptr(offset)
-		}
 		if status != "" {
 			params.TransactionStatus = ptr(realestate.GetAllMortgageTransactionsParamsTransactionStatus(status))
 		}
@@ -67,8 +64,7 @@ var reListTransactionsCmd = &cobra.Command{
 		if createdDateStart != "" {
 			t, err := time.Parse(time.RFC3339, createdDateStart)
 			if err != nil {
-				fmt.Printf("Error parsing created-date-start: %v\n", err)
-				os.Exit(1)
+				clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to parse created-date-start"))
 			}
 			params.CreatedDateStart = &t
 		}
@@ -76,8 +72,7 @@ var reListTransactionsCmd = &cobra.Command{
 		if createdDateEnd != "" {
 			t, err := time.Parse(time.RFC3339, createdDateEnd)
 			if err != nil {
-				fmt.Printf("Error parsing created-date-end: %v\n", err)
-				os.Exit(1)
+				clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to parse created-date-end"))
 			}
 			params.CreatedDateEnd = &t
 		}
@@ -85,8 +80,7 @@ var reListTransactionsCmd = &cobra.Command{
 		if lastUpdatedDateStart != "" {
 			t, err := time.Parse(time.RFC3339, lastUpdatedDateStart)
 			if err != nil {
-				fmt.Printf("Error parsing last-updated-date-start: %v\n", err)
-				os.Exit(1)
+				clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to parse last-updated-date-start"))
 			}
 			params.LastUpdatedDateStart = &t
 		}
@@ -94,21 +88,31 @@ var reListTransactionsCmd = &cobra.Command{
 		if lastUpdatedDateEnd != "" {
 			t, err := time.Parse(time.RFC3339, lastUpdatedDateEnd)
 			if err != nil {
-				fmt.Printf("Error parsing last-updated-date-end: %v\n", err)
-				os.Exit(1)
+				clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to parse last-updated-date-end"))
 			}
 			params.LastUpdatedDateEnd = &t
 		}
 
-		// Make API call using SDK
 		client := getRealEstateClient()
-		resp, err := client.GetAllMortgageTransactionsWithResponse(context.Background(), params)
-		if err != nil {
-			fmt.Println("Error listing transactions:", err)
-			os.Exit(1)
-		}
-
-		PrintResponse(resp.Body)
+		runList(cmd, listPages{
+			style:       utils.PageOffset,
+			first:       utils.PageRequest{Offset: offset, Limit: limit},
+			maxPageSize: 1000,
+			fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				pageParams := *params
+				if page.Limit > 0 {
+					pageParams.Limit = ptr(page.Limit)
+				}
+				if page.Offset > 0 {
+					pageParams.Offset = ptr(page.Offset)
+				}
+				resp, err := client.GetAllMortgageTransactionsWithResponse(ctx, &pageParams)
+				if err := responseError(resp, err, "failed to list transactions"); err != nil {
+					return nil, err
+				}
+				return resp.Body, nil
+			},
+		})
 	},
 }
 
@@ -128,50 +132,218 @@ var reGetTransactionCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getRealEstateClient()
 		resp, err := client.GetMortgageTransactionWithResponse(context.Background(), transactionID, params)
-		if err != nil {
-			fmt.Println("Error getting transaction:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get transaction")
 
 		PrintResponse(resp.Body)
 	},
 }
 
 var reCreateTransactionCmd = &cobra.Command{
-	Use:    "create",
-	Short:  "Create a real estate transaction",
-	Long:   `Create a new real estate transaction`,
+	Use:   "create",
+	Short: "Create a real estate transaction",
+	Long: `Create a new real estate transaction.
+
+Signers, documents and contacts may be repeated:
+
+  proof real-estate transactions create \
+    --type refinance --loan-number LN-2024-001 \
+    --address-line1 "123 Main St" --city Arlington --state VA --postal 22201 \
+    --title-agency-id org_123 --title-underwriter-id und_456 \
+    --signer email=jane@example.com,first=Jane,last=Doe \
+    --contact role=loan_officer,email=lo@example.com,first=Pat,last=Kim \
+    --document closing-disclosure.pdf
+
+The full request can be given as a YAML or JSON manifest with -f. Documents may
+be local paths (relative to the manifest), URLs or base64:
+
+  transaction_type: refinance
+  loan_number: "123456"
+  street_address:
+    line1: 123 Main St
+    city: Arlington
+    state: VA
+    postal: "22201"
+  signers:
+    - email: jane@example.com
+  documents:
+    - closing-disclosure.pdf
+    - document: https://example.com/note.pdf
+      requirement: esign`,
 	PreRun: initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Get command line flags for basic transaction creation
+		queryParams := &realestate.CreateMortgageTransactionParams{
+			DocumentUrlVersion: ptr(realestate.CreateMortgageTransactionParamsDocumentUrlVersionV2),
+		}
+
+		if manifestPath, _ := cmd.Flags().GetString("file"); manifestPath != "" {
+			if err := manifestFlagConflict(cmd); err != nil {
+				clierr.Exit(err)
+			}
+			payload, err := loadManifest(manifestPath, realEstateManifest)
+			if err != nil {
+				clierr.Exit(err)
+			}
+
+			client := getRealEstateClient()
+			resp, err := client.CreateMortgageTransactionWithBodyWithResponse(context.Background(), queryParams, "application/json", bytes.NewReader(payload))
+			checkResponse(resp, err, "failed to create transaction")
+
+			PrintResponse(resp.Body)
+			return
+		}
+
+		// Get command line flags
 		transactionType, _ := cmd.Flags().GetString("type")
 		draft, _ := cmd.Flags().GetBool("draft")
 		fileNumber, _ := cmd.Flags().GetString("file-number")
 		loanNumber, _ := cmd.Flags().GetString("loan-number")
+		transactionName, _ := cmd.Flags().GetString("name")
+		externalID, _ := cmd.Flags().GetString("external-id")
+		signerSpecs, _ := cmd.Flags().GetStringArray("signer")
+		documentSpecs, _ := cmd.Flags().GetStringArray("document")
+		contactSpecs, _ := cmd.Flags().GetStringArray("contact")
+		ccEmails, _ := cmd.Flags().GetStringSlice("cc")
+		line1, _ := cmd.Flags().GetString("address-line1")
+		line2, _ := cmd.Flags().GetString("address-line2")
+		city, _ := cmd.Flags().GetString("city")
+		state, _ := cmd.Flags().GetString("state")
+		postal, _ := cmd.Flags().GetString("postal")
+		country, _ := cmd.Flags().GetString("country")
+		titleAgencyID, _ := cmd.Flags().GetString("title-agency-id")
+		titleUnderwriterID, _ := cmd.Flags().GetString("title-underwriter-id")
+		recordingJurisdictionID, _ := cmd.Flags().GetString("recording-jurisdiction-id")
+		eligibilityConsent, _ := cmd.Flags().GetString("jurisdiction-eligibility-consent")
+		paperNoteConsent, _ := cmd.Flags().GetBool("paper-note-consent")
+		skipClosingOps, _ := cmd.Flags().GetBool("skip-closing-ops")
+		activationTime, _ := cmd.Flags().GetString("activation-time")
+		expirationTime, _ := cmd.Flags().GetString("expiration-time")
+		messageToSigner, _ := cmd.Flags().GetString("message-to-signer")
+		messageSubject, _ := cmd.Flags().GetString("message-subject")
+
+		// Validate everything before touching the network
+		txnType, err := parseEnum("type", transactionType,
+			realestate.TransactionCreateParamsTransactionTypeRefinance,
+			realestate.TransactionCreateParamsTransactionTypeTrailingDocs,
+			realestate.TransactionCreateParamsTransactionTypePurchaseSeller,
+			realestate.TransactionCreateParamsTransactionTypePurchaseBuyerCash,
+			realestate.TransactionCreateParamsTransactionTypePurchaseBuyerLoan,
+			realestate.TransactionCreateParamsTransactionTypeHeloc,
+			realestate.TransactionCreateParamsTransactionTypeLoanModBorrower,
+			realestate.TransactionCreateParamsTransactionTypeOther,
+			realestate.TransactionCreateParamsTransactionTypeHybridRefinance,
+			realestate.TransactionCreateParamsTransactionTypeHybridTrailingDocs,
+			realestate.TransactionCreateParamsTransactionTypeHybridPurchaseSeller,
+			realestate.TransactionCreateParamsTransactionTypeHybridPurchaseBuyerCash,
+			realestate.TransactionCreateParamsTransactionTypeHybridPurchaseBuyerLoan,
+			realestate.TransactionCreateParamsTransactionTypeHybridOther,
+			realestate.TransactionCreateParamsTransactionTypeRealEstateEsign,
+			realestate.TransactionCreateParamsTransactionTypeRealEstateProof,
+			realestate.TransactionCreateParamsTransactionTypeWetSign)
+		if err != nil {
+			clierr.Exit(err)
+		}
 
-		queryParams := &realestate.CreateMortgageTransactionParams{
-			DocumentUrlVersion: ptr(realestate.CreateMortgageTransactionParamsDocumentUrlVersionV2),
+		var signers []realestate.Signers
+		for _, spec := range signerSpecs {
+			signer, err := parseSignerSpec(spec)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			signers = append(signers, signer.realEstateSigners())
 		}
 
-		body := realestate.CreateMortgageTransactionJSONRequestBody{
-			Draft:      ptr(draft),
-			FileNumber: ptrIfNotEmpty(fileNumber),
-			LoanNumber: ptrIfNotEmpty(loanNumber),
+		var contacts []realestate.Contact
+		for _, spec := range contactSpecs {
+			contact, err := parseContactSpec(spec)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			contacts = append(contacts, contact)
 		}
 
-		// Set transaction type if provided
-		if transactionType != "" {
-			txnType := realestate.TransactionCreateParamsTransactionType(transactionType)
-			body.TransactionType = &txnType
+		var documents []documentSpec
+		for _, spec := range documentSpecs {
+			document, err := parseDocumentSpec(spec)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			documents = append(documents, document)
+		}
+
+		ccParam, err := parseCCEmails(ccEmails)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		activationParam, err := parseISOTime("activation-time", activationTime)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		expirationParam, err := parseISOTime("expiration-time", expirationTime)
+		if err != nil {
+			clierr.Exit(err)
+		}
+		if err := checkTimeOrder("activation-time", activationParam, "expiration-time", expirationParam); err != nil {
+			clierr.Exit(err)
+		}
+
+		// Read local documents and encode them to base64
+		var resolved []realestate.DocumentParams
+		for _, document := range documents {
+			params, err := resolveRealEstateDocument(document)
+			if err != nil {
+				clierr.Exit(err)
+			}
+			resolved = append(resolved, params)
+		}
+
+		body := realestate.CreateMortgageTransactionJSONRequestBody{
+			Draft:                          ptr(draft),
+			TransactionType:                txnType,
+			FileNumber:                     ptrIfNotEmpty(fileNumber),
+			LoanNumber:                     ptrIfNotEmpty(loanNumber),
+			TransactionName:                ptrIfNotEmpty(transactionName),
+			ExternalId:                     ptrIfNotEmpty(externalID),
+			OrganizationId:                 ptrIfNotEmpty(organizationFlag(cmd, "organization-id")),
+			CcRecipientEmails:              ccParam,
+			TitleAgencyId:                  ptrIfNotEmpty(titleAgencyID),
+			TitleUnderwriterId:             ptrIfNotEmpty(titleUnderwriterID),
+			RecordingJurisdictionId:        ptrIfNotEmpty(recordingJurisdictionID),
+			JurisdictionEligibilityConsent: ptrIfNotEmpty(eligibilityConsent),
+			ActivationTime:                 activationParam,
+			ExpirationTime:                 expirationParam,
+			MessageToSigner:                ptrIfNotEmpty(messageToSigner),
+			MessageSubject:                 ptrIfNotEmpty(messageSubject),
+		}
+		if line1 != "" || line2 != "" || city != "" || state != "" || postal != "" || country != "" {
+			body.StreetAddress = &realestate.Address{
+				Line1:   ptrIfNotEmpty(line1),
+				Line2:   ptrIfNotEmpty(line2),
+				City:    ptrIfNotEmpty(city),
+				State:   ptrIfNotEmpty(state),
+				Postal:  ptrIfNotEmpty(postal),
+				Country: ptrIfNotEmpty(country),
+			}
+		}
+		if len(signers) > 0 {
+			body.Signers = &signers
+		}
+		if len(contacts) > 0 {
+			body.Contacts = &contacts
+		}
+		if len(resolved) > 0 {
+			body.Documents = &resolved
+		}
+		if cmd.Flags().Changed("paper-note-consent") {
+			body.PaperNoteConsent = ptr(paperNoteConsent)
+		}
+		if cmd.Flags().Changed("skip-closing-ops") {
+			body.SkipClosingOps = ptr(skipClosingOps)
 		}
 
 		// Make API call using SDK
 		client := getRealEstateClient()
 		resp, err := client.CreateMortgageTransactionWithResponse(context.Background(), queryParams, body)
-		if err != nil {
-			fmt.Println("Error creating transaction:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to create transaction")
 
 		PrintResponse(resp.Body)
 	},
@@ -193,10 +365,7 @@ var rePlaceOrderCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getRealEstateClient()
 		resp, err := client.PlaceOrderWithResponse(context.Background(), transactionID, params)
-		if err != nil {
-			fmt.Println("Error placing order:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to place order")
 
 		PrintResponse(resp.Body)
 	},
@@ -225,10 +394,7 @@ var reListDocumentsCmd = &cobra.Command{
 		// Get transaction which includes documents
 		client := getRealEstateClient()
 		resp, err := client.GetMortgageTransactionWithResponse(context.Background(), transactionID, params)
-		if err != nil {
-			fmt.Println("Error listing documents:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to list documents")
 
 		PrintResponse(resp.Body)
 	},
@@ -256,10 +422,7 @@ var reGetDocumentCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getRealEstateClient()
 		resp, err := client.GetMortgageDocumentWithResponse(context.Background(), transactionID, documentID, params)
-		if err != nil {
-			fmt.Println("Error getting document:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get document")
 
 		PrintResponse(resp.Body)
 	},
@@ -281,8 +444,7 @@ var reUploadDocumentCmd = &cobra.Command{
 		// Read the file
 		fileContent, err := os.ReadFile(filePath)
 		if err != nil {
-			fmt.Println("Error reading file:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to read file"))
 		}
 
 		// Encode document to base64
@@ -300,10 +462,7 @@ var reUploadDocumentCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getRealEstateClient()
 		resp, err := client.AddMortgageDocumentWithResponse(context.Background(), transactionID, queryParams, body)
-		if err != nil {
-			fmt.Println("Error uploading document:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to upload document")
 
 		PrintResponse(resp.Body)
 	},
@@ -325,10 +484,7 @@ var reListWebhooksCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getRealEstateClient()
 		resp, err := client.GetAllMortgageWebhooksV2WithResponse(context.Background())
-		if err != nil {
-			fmt.Println("Error listing webhooks:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to list webhooks")
 
 		PrintResponse(resp.Body)
 	},
@@ -357,10 +513,7 @@ var reCreateWebhookCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getRealEstateClient()
 		resp, err := client.CreateMortgageWebhookV2WithResponse(context.Background(), body)
-		if err != nil {
-			fmt.Println("Error creating webhook:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to create webhook")
 
 		PrintResponse(resp.Body)
 	},
@@ -381,8 +534,7 @@ var reVerifyAddressCmd = &cobra.Command{
 		transactionType, _ := cmd.Flags().GetString("transaction-type")
 
 		if line1 == "" || city == "" || state == "" {
-			fmt.Println("Error: line1, city, and state are required")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("line1, city, and state are required"))
 		}
 
 		// Transaction type is required for the API
@@ -404,10 +556,7 @@ var reVerifyAddressCmd = &cobra.Command{
 		// Make API call using SDK
 		client := getRealEstateClient()
 		resp, err := client.GetRecordingLocationsWithResponse(context.Background(), params)
-		if err != nil {
-			fmt.Println("Error verifying address:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to verify address")
 
 		PrintResponse(resp.Body)
 	},
@@ -441,18 +590,43 @@ func init() {
 	reListTransactionsCmd.Flags().Int("limit", 0, "Limit number of results")
 	reListTransactionsCmd.Flags().Int("offset", 0, "Offset for pagination")
 	reListTransactionsCmd.Flags().String("status", "", "Filter by transaction status")
-	reListTransactionsCmd.Flags().String("organization-id", "", "Organization ID of child account")
+	reListTransactionsCmd.Flags().String("organization-id", "", "Organization ID of child account (default: the profile's organization)")
 	reListTransactionsCmd.Flags().String("loan-number", "", "Find transactions associated with loan number")
 	reListTransactionsCmd.Flags().String("created-date-start", "", "ISO-8601 DateTime - transactions created after this time")
 	reListTransactionsCmd.Flags().String("created-date-end", "", "ISO-8601 DateTime - transactions created before this time")
 	reListTransactionsCmd.Flags().String("last-updated-date-start", "", "ISO-8601 DateTime - transactions updated after this time")
 	reListTransactionsCmd.Flags().String("last-updated-date-end", "", "ISO-8601 DateTime - transactions updated before this time")
 	reListTransactionsCmd.Flags().String("document-url-version", "v2", "Document URL version (v1 or v2)")
+	addPaginationFlags(reListTransactionsCmd)
 
-	reCreateTransactionCmd.Flags().String("type", "purchase", "Transaction type")
+	reCreateTransactionCmd.Flags().StringP("file", "f", "", "YAML or JSON manifest describing the transaction (- for stdin)")
+	reCreateTransactionCmd.Flags().String("type", "", "Transaction type (refinance, purchase_buyer_loan, purchase_seller, heloc, ...)")
 	reCreateTransactionCmd.Flags().Bool("draft", true, "Create as draft")
 	reCreateTransactionCmd.Flags().String("file-number", "", "File number")
 	reCreateTransactionCmd.Flags().String("loan-number", "", "Loan number")
+	reCreateTransactionCmd.Flags().String("name", "", "Transaction name")
+	reCreateTransactionCmd.Flags().String("external-id", "", "External system ID")
+	reCreateTransactionCmd.Flags().String("organization-id", "", "Child organization to create the transaction in (default: the profile's organization)")
+	reCreateTransactionCmd.Flags().StringArray("signer", nil, "Signer as email=...,first=...,last=...,phone=...[,middle=,order=,requirement=,external-id=] (repeatable)")
+	reCreateTransactionCmd.Flags().StringArray("document", nil, "Document file path or URL, optionally with ,requirement=esign and ,name=File.pdf (repeatable)")
+	reCreateTransactionCmd.Flags().StringArray("contact", nil, "Contact as role=title_agent|loan_officer|...,email=...,first=...,last=...[,phone=,organization=,title=,access=,shown=] (repeatable)")
+	reCreateTransactionCmd.Flags().StringSlice("cc", nil, "Email address to CC on the transaction (repeatable or comma-separated)")
+	reCreateTransactionCmd.Flags().String("address-line1", "", "Property street address")
+	reCreateTransactionCmd.Flags().String("address-line2", "", "Property address line 2")
+	reCreateTransactionCmd.Flags().String("city", "", "Property city")
+	reCreateTransactionCmd.Flags().String("state", "", "Property state")
+	reCreateTransactionCmd.Flags().String("postal", "", "Property postal code")
+	reCreateTransactionCmd.Flags().String("country", "", "Property country")
+	reCreateTransactionCmd.Flags().String("title-agency-id", "", "ID of the title agency (from address verification)")
+	reCreateTransactionCmd.Flags().String("title-underwriter-id", "", "ID of the title underwriter (from address verification)")
+	reCreateTransactionCmd.Flags().String("recording-jurisdiction-id", "", "ID of the recording jurisdiction for the property")
+	reCreateTransactionCmd.Flags().String("jurisdiction-eligibility-consent", "", "Consent value acknowledging jurisdiction eligibility")
+	reCreateTransactionCmd.Flags().Bool("paper-note-consent", false, "Create a hybrid transaction that bypasses the eNote requirement")
+	reCreateTransactionCmd.Flags().Bool("skip-closing-ops", false, "Don't send the transaction to closing ops")
+	reCreateTransactionCmd.Flags().String("activation-time", "", "ISO-8601 datetime when signers can start")
+	reCreateTransactionCmd.Flags().String("expiration-time", "", "ISO-8601 datetime after which the transaction expires")
+	reCreateTransactionCmd.Flags().String("message-to-signer", "", "Message to signer (GitHub Flavored Markdown)")
+	reCreateTransactionCmd.Flags().String("message-subject", "", "Email subject line")
 
 	// Add flags for documents
 	reGetDocumentCmd.Flags().String("encoding", "", "Encoding format (base64 or uri)")
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/common"
+)
+
+// checkResponse aborts the command when an SDK call failed, either because the
+// request could not be sent or because the API answered with a non-2xx status.
+// The error is written to stderr and the process exits with a code describing
+// the class of failure.
+func checkResponse(resp any, err error, message string) {
+	if err := responseError(resp, err, message); err != nil {
+		clierr.Exit(err)
+	}
+}
+
+// responseError converts the result of an SDK call into a classified error, or nil on success
+func responseError(resp any, err error, message string) *clierr.Error {
+	if err != nil {
+		// Only transport failures are network errors; anything else the SDK
+		// returns (request building, auth, decoding) keeps or gets a general kind
+		return clierr.From(err, message)
+	}
+
+	err = common.CheckResponse(resp)
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) {
+		return clierr.Wrap(clierr.KindGeneral, err, message)
+	}
+
+	details := apiErr.Messages
+	if len(details) == 0 && len(apiErr.Body) > 0 {
+		details = []string{string(apiErr.Body)}
+	}
+	return clierr.FromStatus(
+		apiErr.StatusCode,
+		fmt.Sprintf("%s: API error (status %s)", message, apiErr.Status),
+		details,
+	)
+}
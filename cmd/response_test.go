@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/business"
+)
+
+func TestResponseError_Success(t *testing.T) {
+	resp := &business.GetTransactionResponse{
+		Body:         []byte(`{"id":"ot_123"}`),
+		HTTPResponse: &http.Response{StatusCode: 200, Status: "200 OK"},
+	}
+
+	assert.Nil(t, responseError(resp, nil, "failed to fetch transaction"))
+}
+
+func TestResponseError_TransportFailure(t *testing.T) {
+	transportErr := &url.Error{Op: "Get", URL: "https://api.proof.com/v1/transactions", Err: errors.New("connection reset")}
+	err := responseError(nil, transportErr, "failed to fetch transaction")
+
+	require.NotNil(t, err)
+	assert.Equal(t, clierr.KindNetwork, err.Kind)
+	assert.Equal(t, clierr.ExitNetwork, err.ExitCode())
+}
+
+func TestResponseError_NonTransportFailure(t *testing.T) {
+	err := responseError(nil, errors.New("failed to decode response"), "failed to fetch transaction")
+	require.NotNil(t, err)
+	assert.Equal(t, clierr.KindGeneral, err.Kind)
+
+	err = responseError(nil, clierr.Input("missing credentials"), "failed to fetch transaction")
+	require.NotNil(t, err)
+	assert.Equal(t, clierr.KindInput, err.Kind)
+}
+
+func TestResponseError_APIStatus(t *testing.T) {
+	testCases := []struct {
+		status   int
+		expected clierr.Kind
+	}{
+		{401, clierr.KindAuth},
+		{404, clierr.KindNotFound},
+		{422, clierr.KindValidation},
+		{429, clierr.KindRateLimited},
+		{502, clierr.KindServer},
+	}
+
+	for _, tc := range testCases {
+		resp := &business.GetTransactionResponse{
+			Body:         []byte(`{"errors":["something went wrong"]}`),
+			HTTPResponse: &http.Response{StatusCode: tc.status, Status: http.StatusText(tc.status)},
+		}
+
+		err := responseError(resp, nil, "failed to fetch transaction")
+
+		require.NotNil(t, err, "status %d", tc.status)
+		assert.Equal(t, tc.expected, err.Kind, "status %d", tc.status)
+		assert.Equal(t, tc.status, err.StatusCode)
+		assert.Equal(t, []string{"something went wrong"}, err.Details)
+	}
+}
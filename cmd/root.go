@@ -5,27 +5,59 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/fatih/color"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 	"github.com/tsarlewey/proof-cli/pkg/sdk/business"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/certificates"
 	"github.com/tsarlewey/proof-cli/pkg/sdk/common"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/logs"
 	"github.com/tsarlewey/proof-cli/pkg/sdk/realestate"
 	"github.com/tsarlewey/proof-cli/pkg/sdk/scim"
 	"github.com/tsarlewey/proof-cli/pkg/utils"
 )
 
 var (
-	prettyPrint bool
-	verbose     bool
-	proofClient *utils.ProofClient
+	prettyPrint  bool
+	verbose      bool
+	debug        bool
+	dryRun       bool
+	asCurl       bool
+	profileName  string
+	configPath   string
+	endpoint     string
+	timeout      time.Duration
+	outputFormat string
+	tableColumns []string
+	queryExpr    string
+	selectFields []string
+	templateText string
+	templateFile string
+	outputTmpl   *template.Template
+	maxRetries   int
+	retryWait    time.Duration
+	rps          float64
+	concurrency  int
+	proofClient  *utils.ProofClient
 
 	// SDK clients - lazily initialized
 	businessClient   *business.ClientWithResponses
 	realestateClient *realestate.ClientWithResponses
 	scimClient       *scim.ClientWithResponses
+	logsClient       *logs.ClientWithResponses
+	certsClient      *certificates.ClientWithResponses
 )
 
+// annotationColumns is the command annotation holding the default table columns
+// for a list command, as a comma-separated list of dotted field paths
+const annotationColumns = "proof.columns"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "proof",
@@ -34,20 +66,41 @@ var rootCmd = &cobra.Command{
 This CLI allows you to manage transactions, documents, notaries, and webhooks.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// Initialize global settings that apply to all commands
+		clierr.SetJSONOutput(outputFormat == utils.FormatJSON)
+		utils.SetConfigFile(configPath)
+		utils.SelectProfile(profileName)
+		utils.SetOverrides(flagOverrides(cmd))
+		if outputFormat != "" && !slices.Contains(utils.OutputFormats, outputFormat) {
+			clierr.Exit(clierr.Input("unsupported output format %q (supported: %s)", outputFormat, strings.Join(utils.OutputFormats, ", ")))
+		}
+		if columns := cmd.Annotations[annotationColumns]; columns != "" {
+			tableColumns = strings.Split(columns, ",")
+		}
+		if len(selectFields) > 0 {
+			tableColumns = selectFields
+		}
+		if queryExpr != "" {
+			// Reject malformed expressions before any request is made
+			if _, err := utils.CompileQuery(queryExpr); err != nil {
+				clierr.Exit(clierr.Wrap(clierr.KindInput, err, ""))
+			}
+		}
+		outputTmpl = loadOutputTemplate()
 		if verbose {
 			fmt.Fprintf(os.Stderr, "Running command: %s\n", cmd.CommandPath())
 		}
 	},
-	SilenceUsage:  true,  // Don't show usage on errors
-	SilenceErrors: false, // Show errors but don't duplicate with usage
+	SilenceUsage:  true, // Don't show usage on errors
+	SilenceErrors: true, // Errors are printed by Execute so they honor --output
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		// Errors returned by cobra are flag and argument problems
+		clierr.SetJSONOutput(outputFormat == utils.FormatJSON)
+		clierr.Exit(clierr.Wrap(clierr.KindInput, err, ""))
 	}
 }
 
@@ -55,18 +108,79 @@ func Execute() {
 func initializeForAPICall(cmd *cobra.Command, args []string) {
 	toggleDebug(cmd, args)
 	if proofClient == nil {
-		client, err := utils.NewProofClient()
+		var client *utils.ProofClient
+		var err error
+		if dryRun || asCurl {
+			// A dry run sends nothing, so it needs no credential
+			client, err = utils.NewDryRunClient(os.Stdout, asCurl)
+		} else {
+			client, err = utils.NewProofClient()
+		}
 		utils.HandleError(err, "Failed to create client")
 		proofClient = client
+		if debug {
+			proofClient.EnableTracing(log.StandardLogger())
+		}
 	}
 }
 
+// flagOverrides collects the settings given as global flags, which take
+// precedence over every other source of configuration
+func flagOverrides(cmd *cobra.Command) utils.Overrides {
+	var overrides utils.Overrides
+	// Checked by value rather than Changed, since 'config profiles add' has
+	// its own --endpoint and --timeout flags that shadow the global ones
+	overrides.APIEndpoint = endpoint
+	if timeout < 0 {
+		clierr.Exit(clierr.Input("--timeout must not be negative"))
+	}
+	overrides.Timeout = timeout
+
+	if cmd.Flags().Changed("max-retries") {
+		if maxRetries < 0 {
+			clierr.Exit(clierr.Input("--max-retries must not be negative"))
+		}
+		overrides.MaxRetries = &maxRetries
+	}
+	if cmd.Flags().Changed("retry-wait") {
+		if retryWait <= 0 {
+			clierr.Exit(clierr.Input("--retry-wait must be positive"))
+		}
+		overrides.RetryWait = retryWait
+	}
+	if cmd.Flags().Changed("rps") {
+		if rps < 0 {
+			clierr.Exit(clierr.Input("--rps must not be negative"))
+		}
+		overrides.RPS = &rps
+	}
+	if cmd.Flags().Changed("concurrency") {
+		if concurrency < 0 {
+			clierr.Exit(clierr.Input("--concurrency must not be negative"))
+		}
+		overrides.Concurrency = &concurrency
+	}
+	return overrides
+}
+
+// organizationFlag returns the value of an organization ID flag, or the
+// default organization of the active profile when the flag is not set
+func organizationFlag(cmd *cobra.Command, name string) string {
+	if id, _ := cmd.Flags().GetString(name); id != "" {
+		return id
+	}
+	if proofClient == nil {
+		return ""
+	}
+	return proofClient.GetConfig().OrganizationID
+}
+
 // getBusinessClient returns a lazily-initialized Business SDK client
 func getBusinessClient() *business.ClientWithResponses {
 	if businessClient == nil {
 		authDoer := common.NewAuthenticatedDoer(proofClient)
 		client, err := business.NewClientWithResponses(
-			proofClient.GetConfig().APIEndpoint,
+			proofClient.GetConfig().Endpoint(utils.APIBusiness),
 			business.WithHTTPClient(authDoer),
 		)
 		utils.HandleError(err, "Failed to create Business SDK client")
@@ -80,7 +194,7 @@ func getRealEstateClient() *realestate.ClientWithResponses {
 	if realestateClient == nil {
 		authDoer := common.NewAuthenticatedDoer(proofClient)
 		client, err := realestate.NewClientWithResponses(
-			proofClient.GetConfig().APIEndpoint,
+			proofClient.GetConfig().Endpoint(utils.APIRealEstate),
 			realestate.WithHTTPClient(authDoer),
 		)
 		utils.HandleError(err, "Failed to create Real Estate SDK client")
@@ -94,7 +208,7 @@ func getSCIMClient() *scim.ClientWithResponses {
 	if scimClient == nil {
 		authDoer := common.NewAuthenticatedDoer(proofClient)
 		client, err := scim.NewClientWithResponses(
-			proofClient.GetConfig().APIEndpoint,
+			proofClient.GetConfig().Endpoint(utils.APISCIM),
 			scim.WithHTTPClient(authDoer),
 		)
 		utils.HandleError(err, "Failed to create SCIM SDK client")
@@ -103,14 +217,74 @@ func getSCIMClient() *scim.ClientWithResponses {
 	return scimClient
 }
 
-// PrintResponse handles response output with optional pretty printing
+// getLogsClient returns a lazily-initialized Logs SDK client
+func getLogsClient() *logs.ClientWithResponses {
+	if logsClient == nil {
+		authDoer := common.NewAuthenticatedDoer(proofClient)
+		client, err := logs.NewClientWithResponses(
+			proofClient.GetConfig().Endpoint(utils.APILogs),
+			logs.WithHTTPClient(authDoer),
+		)
+		utils.HandleError(err, "Failed to create Logs SDK client")
+		logsClient = client
+	}
+	return logsClient
+}
+
+// getCertificatesClient returns a lazily-initialized Certificates SDK client
+func getCertificatesClient() *certificates.ClientWithResponses {
+	if certsClient == nil {
+		authDoer := common.NewAuthenticatedDoer(proofClient)
+		client, err := certificates.NewClientWithResponses(
+			proofClient.GetConfig().Endpoint(utils.APICertificates),
+			certificates.WithHTTPClient(authDoer),
+		)
+		utils.HandleError(err, "Failed to create Certificates SDK client")
+		certsClient = client
+	}
+	return certsClient
+}
+
+// PrintResponse handles response output in the format selected by --output.
+// Without --output, JSON is pretty printed unless --pretty=false is given.
 func PrintResponse(resp []byte, prefix ...string) {
 	// Print prefix if provided
 	if len(prefix) > 0 {
 		fmt.Println(prefix[0])
 	}
 
-	if prettyPrint {
+	if len(resp) == 0 && (dryRun || asCurl) {
+		// The request was printed instead of sent, so there is no response
+		return
+	}
+
+	resp = applyProjection(resp)
+
+	if outputTmpl != nil {
+		if !json.Valid(resp) {
+			clierr.Exit(clierr.Input("--template requires a JSON response"))
+		}
+		rendered, err := utils.ExecuteTemplate(outputTmpl, resp)
+		if err != nil {
+			clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to render template"))
+		}
+		fmt.Print(rendered)
+		if !strings.HasSuffix(rendered, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+
+	format := outputFormat
+	if format == "" {
+		if !prettyPrint {
+			fmt.Println(string(resp))
+			return
+		}
+		format = utils.FormatJSON
+	}
+
+	if format == utils.FormatJSON {
 		var result any
 		if err := json.Unmarshal(resp, &result); err != nil {
 			// If JSON parsing fails, just print raw response
@@ -128,9 +302,78 @@ func PrintResponse(resp []byte, prefix ...string) {
 
 		// Apply colors to the properly formatted JSON
 		fmt.Println(colorizeJSON(string(prettyJSON)))
-	} else {
+		return
+	}
+
+	if !json.Valid(resp) {
+		// Non-JSON bodies cannot be reformatted
 		fmt.Println(string(resp))
+		return
+	}
+
+	formatted, err := utils.FormatOutput(resp, format, tableColumns...)
+	if err != nil {
+		clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to format output"))
+	}
+	if formatted != "" {
+		fmt.Println(formatted)
+	}
+}
+
+// loadOutputTemplate parses --template or --template-file, if either was given.
+// Inline templates may use \t and \n escapes, since shells make literal tabs awkward.
+func loadOutputTemplate() *template.Template {
+	if templateText == "" && templateFile == "" {
+		return nil
+	}
+	if templateText != "" && templateFile != "" {
+		clierr.Exit(clierr.Input("--template and --template-file cannot be used together"))
+	}
+	if outputFormat != "" {
+		clierr.Exit(clierr.Input("--template cannot be combined with --output"))
+	}
+
+	name, text := "template", strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(templateText)
+	if templateFile != "" {
+		content, err := os.ReadFile(templateFile)
+		if err != nil {
+			clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to read template file"))
+		}
+		name, text = templateFile, string(content)
+	}
+
+	tmpl, err := utils.ParseTemplate(name, text)
+	if err != nil {
+		clierr.Exit(clierr.Wrap(clierr.KindInput, err, ""))
+	}
+	return tmpl
+}
+
+// applyProjection applies --query and then --fields to a response body
+func applyProjection(resp []byte) []byte {
+	if queryExpr == "" && len(selectFields) == 0 {
+		return resp
+	}
+	if !json.Valid(resp) {
+		clierr.Exit(clierr.Input("--query and --fields require a JSON response"))
+	}
+
+	if queryExpr != "" {
+		result, err := utils.ApplyQuery(resp, queryExpr)
+		if err != nil {
+			clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to apply query"))
+		}
+		resp = result
+	}
+
+	if len(selectFields) > 0 {
+		result, err := utils.SelectFields(resp, selectFields)
+		if err != nil {
+			clierr.Exit(clierr.Wrap(clierr.KindInput, err, "failed to select fields"))
+		}
+		resp = result
 	}
+	return resp
 }
 
 // colorizeJSON adds color to JSON output using proper patterns
@@ -216,6 +459,15 @@ func colorizeJSON(jsonStr string) string {
 }
 
 // PrintVerbose prints additional information when verbose flag is set
+// printSuccess reports that an API call took effect. A dry run sends nothing,
+// so it prints no success message after the request.
+func printSuccess(message string) {
+	if dryRun || asCurl {
+		return
+	}
+	fmt.Println(message)
+}
+
 func PrintVerbose(message string) {
 	if verbose {
 		fmt.Println(message)
@@ -228,9 +480,25 @@ func init() {
 	rootCmd.CompletionOptions.DisableDescriptions = false
 
 	// Global flags
-	rootCmd.PersistentFlags().BoolVarP(&prettyPrint, "pretty", "p", true, "pretty print JSON output")
+	rootCmd.PersistentFlags().BoolVarP(&prettyPrint, "pretty", "p", true, "pretty print JSON output (same as --output json)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "show additional output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "output format: json, yaml, table, csv or ndjson (json also writes errors as JSON)")
+	rootCmd.PersistentFlags().StringVarP(&queryExpr, "query", "q", "", "jq-style expression applied to the response, e.g. '.data[] | .id'")
+	rootCmd.PersistentFlags().StringVar(&templateText, "template", "", "Go template used to render the response, e.g. '{{range .data}}{{.id}}\\t{{.detailed_status}}\\n{{end}}'")
+	rootCmd.PersistentFlags().StringVar(&templateFile, "template-file", "", "file containing a Go template used to render the response")
+	rootCmd.PersistentFlags().StringSliceVar(&selectFields, "fields", nil, "comma-separated dotted fields to keep, e.g. id,detailed_status,signer_info.email")
+
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "config file, JSON or YAML (default: PROOF_CONFIG or ~/.proof-cli/config.json)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "configuration profile to use (default: PROOF_PROFILE or the current profile)")
+	rootCmd.PersistentFlags().StringVar(&endpoint, "endpoint", "", "API endpoint for this command, overriding PROOF_ENDPOINT and the config")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "request timeout for this command, e.g. 90s, overriding PROOF_TIMEOUT and the config")
+
+	rootCmd.PersistentFlags().IntVar(&maxRetries, "max-retries", utils.DefaultMaxRetries, "retries for transient failures (connection errors, 429, 502-504); 0 disables")
+	rootCmd.PersistentFlags().DurationVar(&retryWait, "retry-wait", utils.DefaultRetryWait, "base delay between retries, doubled on each attempt with jitter")
+	rootCmd.PersistentFlags().Float64Var(&rps, "rps", 0, "maximum requests per second across all API calls (0 for no limit)")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 0, "maximum requests in flight at once (0 for no limit)")
 
-	// Make --debug a global flag since it's already handled globally
-	rootCmd.PersistentFlags().Bool("debug", false, "Enable debug output")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "print the API request instead of sending it, with the credential as a placeholder")
+	rootCmd.PersistentFlags().BoolVar(&asCurl, "as-curl", false, "print the API request as a curl command instead of sending it (implies --dry-run)")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "log every HTTP request and response to stderr, with credentials redacted")
 }
@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
 )
 
 // captureOutput captures stdout and stderr during function execution
@@ -337,3 +339,99 @@ func TestPrintVerbose_WhenNotVerbose(t *testing.T) {
 
 	assert.Empty(t, strings.TrimSpace(output))
 }
+
+func TestPrintSuccess_SkippedInDryRun(t *testing.T) {
+	oldDryRun, oldCurl := dryRun, asCurl
+	defer func() { dryRun, asCurl = oldDryRun, oldCurl }()
+
+	dryRun, asCurl = false, false
+	output := captureOutput(func() {
+		printSuccess("Transaction deleted successfully")
+	})
+	assert.Equal(t, "Transaction deleted successfully\n", output)
+
+	for _, flags := range [][2]bool{{true, false}, {false, true}} {
+		dryRun, asCurl = flags[0], flags[1]
+		output = captureOutput(func() {
+			printSuccess("Transaction deleted successfully")
+		})
+		assert.Empty(t, output, "nothing was sent, so nothing was deleted")
+	}
+}
+
+func TestPrintResponse_OutputFormats(t *testing.T) {
+	// Save and restore output settings
+	oldFormat, oldColumns := outputFormat, tableColumns
+	defer func() { outputFormat, tableColumns = oldFormat, oldColumns }()
+
+	input := []byte(`{"data":[{"id":"ot_1","detailed_status":"completed","signer_info":{"email":"a@example.com"}}]}`)
+
+	outputFormat = "table"
+	tableColumns = []string{"id", "signer_info.email"}
+	output := captureOutput(func() {
+		PrintResponse(input)
+	})
+	assert.Contains(t, output, "SIGNER_INFO.EMAIL")
+	assert.Contains(t, output, "a@example.com")
+	assert.NotContains(t, output, "completed")
+
+	outputFormat = "csv"
+	output = captureOutput(func() {
+		PrintResponse(input)
+	})
+	assert.Contains(t, output, "detailed_status,id,signer_info.email")
+
+	outputFormat = "yaml"
+	output = captureOutput(func() {
+		PrintResponse(input)
+	})
+	assert.Contains(t, output, "id: ot_1")
+}
+
+func TestPrintResponse_NonJSONWithOutputFormat(t *testing.T) {
+	oldFormat := outputFormat
+	defer func() { outputFormat = oldFormat }()
+
+	outputFormat = "table"
+	output := captureOutput(func() {
+		PrintResponse([]byte("plain text body"))
+	})
+
+	assert.Contains(t, output, "plain text body")
+}
+
+func TestPrintResponse_QueryAndFields(t *testing.T) {
+	oldFormat, oldPretty, oldQuery, oldFields := outputFormat, prettyPrint, queryExpr, selectFields
+	defer func() { outputFormat, prettyPrint, queryExpr, selectFields = oldFormat, oldPretty, oldQuery, oldFields }()
+
+	input := []byte(`{"data":[{"id":"ot_1","detailed_status":"completed","signer_info":{"email":"a@example.com"}},{"id":"ot_2","detailed_status":"sent","signer_info":{"email":"b@example.com"}}]}`)
+
+	outputFormat, prettyPrint = "", false
+	queryExpr = `.data[] | select(.detailed_status == "sent") | .id`
+	output := captureOutput(func() {
+		PrintResponse(input)
+	})
+	assert.Equal(t, `["ot_2"]`, strings.TrimSpace(output))
+
+	queryExpr = ""
+	selectFields = []string{"id", "signer_info.email"}
+	output = captureOutput(func() {
+		PrintResponse(input)
+	})
+	assert.Equal(t, `[{"id":"ot_1","signer_info":{"email":"a@example.com"}},{"id":"ot_2","signer_info":{"email":"b@example.com"}}]`, strings.TrimSpace(output))
+}
+
+func TestPrintResponse_Template(t *testing.T) {
+	oldTmpl := outputTmpl
+	defer func() { outputTmpl = oldTmpl }()
+
+	tmpl, err := utils.ParseTemplate("test", "{{range .data}}{{.id}}={{.detailed_status}};{{end}}")
+	require.NoError(t, err)
+	outputTmpl = tmpl
+
+	output := captureOutput(func() {
+		PrintResponse([]byte(`{"data":[{"id":"ot_1","detailed_status":"completed"}]}`))
+	})
+
+	assert.Equal(t, "ot_1=completed;\n", output)
+}
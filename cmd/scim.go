@@ -4,12 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
-	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 	"github.com/tsarlewey/proof-cli/pkg/sdk/scim"
+	"github.com/tsarlewey/proof-cli/pkg/utils"
 )
 
 // scimCmd represents the scim command
@@ -28,11 +28,12 @@ var scimUsersCmd = &cobra.Command{
 }
 
 var scimListUsersCmd = &cobra.Command{
-	Use:    "list <organization-id>",
-	Short:  "List SCIM users",
-	Long:   `List SCIM users in an organization with optional pagination`,
-	Args:   cobra.ExactArgs(1),
-	PreRun: initializeForAPICall,
+	Use:         "list <organization-id>",
+	Short:       "List SCIM users",
+	Long:        `List SCIM users in an organization with optional pagination`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationColumns: "id,userName,name.givenName,name.familyName,active"},
+	PreRun:      initializeForAPICall,
 	Run: func(cmd *cobra.Command, args []string) {
 		organizationID := args[0]
 
@@ -40,26 +41,28 @@ var scimListUsersCmd = &cobra.Command{
 		startIndex, _ := cmd.Flags().GetInt("start-index")
 		count, _ := cmd.Flags().GetInt("count")
 
-		params := &scim.RetrieveResourceTypesCopyParams{}
-		if startIndex > 0 {
-			si := int32(startIndex)
-			params.StartIndex = &si
-		}
-		if count > 0 {
-			c := int32(count)
-			params.Count = &c
-		}
-
-		// Make API call using SDK client
 		client := getSCIMClient()
-		resp, err := client.RetrieveResourceTypesCopyWithResponse(context.Background(), organizationID, params)
-		if err != nil {
-			fmt.Println("Error listing users:", err)
-			os.Exit(1)
-		}
-
-		// Use global helper to print response
-		PrintResponse(resp.Body)
+		runList(cmd, listPages{
+			style:       utils.PageSCIM,
+			first:       utils.PageRequest{Offset: startIndex, Limit: count},
+			maxPageSize: 100,
+			fetch: func(ctx context.Context, page utils.PageRequest) ([]byte, error) {
+				params := &scim.RetrieveResourceTypesCopyParams{}
+				if page.Offset > 0 {
+					si := int32(page.Offset)
+					params.StartIndex = &si
+				}
+				if page.Limit > 0 {
+					c := int32(page.Limit)
+					params.Count = &c
+				}
+				resp, err := client.RetrieveResourceTypesCopyWithResponse(ctx, organizationID, params)
+				if err := responseError(resp, err, "failed to list users"); err != nil {
+					return nil, err
+				}
+				return resp.Body, nil
+			},
+		})
 	},
 }
 
@@ -76,10 +79,7 @@ var scimGetUserCmd = &cobra.Command{
 		// Make API call using SDK client
 		client := getSCIMClient()
 		resp, err := client.CreateUserCopyWithResponse(context.Background(), organizationID, userID, nil)
-		if err != nil {
-			fmt.Println("Error getting user:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get user")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -105,8 +105,7 @@ var scimCreateUserCmd = &cobra.Command{
 		active, _ := cmd.Flags().GetBool("active")
 
 		if userName == "" {
-			fmt.Println("Error: username is required")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("username is required"))
 		}
 
 		body := scim.CreateUserJSONRequestBody{
@@ -153,10 +152,7 @@ var scimCreateUserCmd = &cobra.Command{
 		// Make API call using SDK client
 		client := getSCIMClient()
 		resp, err := client.CreateUserWithResponse(context.Background(), organizationID, nil, body)
-		if err != nil {
-			fmt.Println("Error creating user:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to create user")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -183,8 +179,7 @@ var scimUpdateUserCmd = &cobra.Command{
 		active, _ := cmd.Flags().GetBool("active")
 
 		if userName == "" {
-			fmt.Println("Error: username is required")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("username is required"))
 		}
 
 		body := scim.CreateUserCopy1JSONRequestBody{
@@ -231,10 +226,7 @@ var scimUpdateUserCmd = &cobra.Command{
 		// Make API call using SDK client
 		client := getSCIMClient()
 		resp, err := client.CreateUserCopy1WithResponse(context.Background(), organizationID, userID, nil, body)
-		if err != nil {
-			fmt.Println("Error updating user:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to update user")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -255,8 +247,7 @@ var scimPatchUserCmd = &cobra.Command{
 		operations, _ := cmd.Flags().GetStringSlice("operation")
 
 		if len(operations) == 0 {
-			fmt.Println("Error: at least one operation is required. Use --operation flag")
-			os.Exit(1)
+			clierr.Exit(clierr.Input("at least one operation is required. Use --operation flag"))
 		}
 
 		// Build SCIM patch request body
@@ -274,8 +265,7 @@ var scimPatchUserCmd = &cobra.Command{
 			// Parse operation string in format "op:path:value"
 			parts := strings.SplitN(op, ":", 3)
 			if len(parts) < 2 {
-				fmt.Printf("Error: invalid operation format: %s. Expected format: op:path[:value]\n", op)
-				os.Exit(1)
+				clierr.Exit(clierr.Input("invalid operation format: %s. Expected format: op:path[:value]", op))
 			}
 
 			patchOp := PatchOperation{
@@ -305,8 +295,7 @@ var scimPatchUserCmd = &cobra.Command{
 		// Marshal to JSON for raw body request
 		bodyBytes, err := json.Marshal(patchReq)
 		if err != nil {
-			fmt.Println("Error marshaling patch request:", err)
-			os.Exit(1)
+			clierr.Exit(clierr.Wrap(clierr.KindGeneral, err, "failed to marshal patch request"))
 		}
 
 		// Make API call using SDK client with raw body
@@ -319,10 +308,7 @@ var scimPatchUserCmd = &cobra.Command{
 			"application/json",
 			bytes.NewReader(bodyBytes),
 		)
-		if err != nil {
-			fmt.Println("Error patching user:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to patch user")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -342,15 +328,12 @@ var scimDeleteUserCmd = &cobra.Command{
 		// Make API call using SDK client
 		client := getSCIMClient()
 		resp, err := client.ReplaceUserCopy1WithResponse(context.Background(), organizationID, userID, nil)
-		if err != nil {
-			fmt.Println("Error deleting user:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to delete user")
 
 		if len(resp.Body) > 0 {
 			PrintResponse(resp.Body)
 		} else {
-			fmt.Println("SCIM user deleted successfully")
+			printSuccess("SCIM user deleted successfully")
 		}
 	},
 }
@@ -374,10 +357,7 @@ var scimGetUserSchemaCmd = &cobra.Command{
 		// Make API call using SDK client
 		client := getSCIMClient()
 		resp, err := client.RetrieveUsersSchemaWithResponse(context.Background(), organizationID)
-		if err != nil {
-			fmt.Println("Error getting user schema:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get user schema")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -396,10 +376,7 @@ var scimGetServiceProviderConfigCmd = &cobra.Command{
 		// Make API call using SDK client
 		client := getSCIMClient()
 		resp, err := client.RetrieveServiceProviderConfigCopyWithResponse(context.Background(), organizationID)
-		if err != nil {
-			fmt.Println("Error getting service provider config:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get service provider config")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -418,10 +395,7 @@ var scimGetResourceTypesCmd = &cobra.Command{
 		// Make API call using SDK client
 		client := getSCIMClient()
 		resp, err := client.RetrieveUsersSchemaCopyWithResponse(context.Background(), organizationID)
-		if err != nil {
-			fmt.Println("Error getting resource types:", err)
-			os.Exit(1)
-		}
+		checkResponse(resp, err, "failed to get resource types")
 
 		// Use global helper to print response
 		PrintResponse(resp.Body)
@@ -451,6 +425,7 @@ func init() {
 	// Add flags for user list
 	scimListUsersCmd.Flags().Int("start-index", 1, "1-based index of first result")
 	scimListUsersCmd.Flags().Int("count", 50, "Maximum number of results per page")
+	addPaginationFlags(scimListUsersCmd)
 
 	// Add flags for user create
 	scimCreateUserCmd.Flags().String("username", "", "Username (email address) - required")
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/business"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/realestate"
+)
+
+// documentRequirements are the completion requirements accepted for a document
+var documentRequirements = []string{"notarization", "esign", "identity_confirmation", "readonly", "non_essential"}
+
+// documentSpec is a parsed --document value
+type documentSpec struct {
+	Source      string
+	Requirement string
+	Filename    string
+}
+
+// transactionCreateBody is the create-transaction request body. The generated
+// TransactionCreateParams types documents as plain strings, but the API also
+// accepts document objects, which is the only way to set a per-document requirement.
+type transactionCreateBody struct {
+	business.TransactionCreateParams
+	Documents []business.DocumentCreationParams `json:"documents,omitempty"`
+}
+
+// parseKeyValueSpec splits a "key=value,key=value" flag value, rejecting keys
+// that are not in allowed
+func parseKeyValueSpec(flag, spec string, allowed ...string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, clierr.Input("invalid --%s %q: expected key=value pairs", flag, spec)
+		}
+		if !slices.Contains(allowed, key) {
+			return nil, clierr.Input("invalid --%s key %q (allowed: %s)", flag, key, strings.Join(allowed, ", "))
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+// signerSpec is a parsed --signer value. It is API-neutral so the same flag
+// syntax serves both business and real estate transactions.
+type signerSpec struct {
+	Email       string
+	First       string
+	Middle      string
+	Last        string
+	Phone       string
+	ExternalID  string
+	Requirement string
+	Order       *int
+}
+
+// primarySignerFlags describe the signer given by --email, paired with the
+// --signer key that sets the same detail
+var primarySignerFlags = [][2]string{
+	{"first-name", "first"},
+	{"middle-name", "middle"},
+	{"last-name", "last"},
+	{"phone-number", "phone"},
+}
+
+// checkPrimarySignerFlags rejects the --email signer's detail flags when
+// --email is not given, since the primary signer then comes from the first
+// --signer and the flags would be silently dropped
+func checkPrimarySignerFlags(flags *pflag.FlagSet) error {
+	if flags.Changed("email") {
+		return nil
+	}
+	for _, flag := range primarySignerFlags {
+		if flags.Changed(flag[0]) {
+			return clierr.Input("--%s requires --email; set %s= in --signer instead", flag[0], flag[1])
+		}
+	}
+	return nil
+}
+
+// parseSignerSpec parses a --signer value such as
+// "email=jane@example.com,first=Jane,last=Doe,phone=5555550123"
+func parseSignerSpec(spec string) (signerSpec, error) {
+	values, err := parseKeyValueSpec("signer", spec, "email", "first", "middle", "last", "phone", "order", "requirement", "external-id")
+	if err != nil {
+		return signerSpec{}, err
+	}
+	if values["email"] == "" {
+		return signerSpec{}, clierr.Input("invalid --signer %q: email is required", spec)
+	}
+
+	signer := signerSpec{
+		Email:      values["email"],
+		First:      values["first"],
+		Middle:     values["middle"],
+		Last:       values["last"],
+		Phone:      values["phone"],
+		ExternalID: values["external-id"],
+	}
+
+	if order := values["order"]; order != "" {
+		n, err := strconv.Atoi(order)
+		if err != nil || n < 1 {
+			return signerSpec{}, clierr.Input("invalid --signer order %q: must be a positive number", order)
+		}
+		signer.Order = ptr(n)
+	}
+
+	if _, err := parseEnum("signer requirement", values["requirement"],
+		business.SignersSigningRequirementEsign,
+		business.SignersSigningRequirementIdentify,
+		business.SignersSigningRequirementVerify); err != nil {
+		return signerSpec{}, err
+	}
+	signer.Requirement = values["requirement"]
+	return signer, nil
+}
+
+// businessSigner converts the spec into the transaction's required primary signer
+func (s signerSpec) businessSigner() business.Signer {
+	signer := business.Signer{
+		Email:       s.Email,
+		FirstName:   ptrIfNotEmpty(s.First),
+		MiddleName:  ptrIfNotEmpty(s.Middle),
+		LastName:    ptrIfNotEmpty(s.Last),
+		PhoneNumber: ptrIfNotEmpty(s.Phone),
+		ExternalId:  ptrIfNotEmpty(s.ExternalID),
+		Order:       s.Order,
+	}
+	if s.Requirement != "" {
+		signer.SigningRequirement = ptr(business.SignerSigningRequirement(s.Requirement))
+	}
+	return signer
+}
+
+// businessSigners converts the spec into an additional business signer
+func (s signerSpec) businessSigners() business.Signers {
+	signer := business.Signers{
+		Email:       ptr(s.Email),
+		FirstName:   ptrIfNotEmpty(s.First),
+		MiddleName:  ptrIfNotEmpty(s.Middle),
+		LastName:    ptrIfNotEmpty(s.Last),
+		PhoneNumber: ptrIfNotEmpty(s.Phone),
+		ExternalId:  ptrIfNotEmpty(s.ExternalID),
+		Order:       s.Order,
+	}
+	if s.Requirement != "" {
+		signer.SigningRequirement = ptr(business.SignersSigningRequirement(s.Requirement))
+	}
+	return signer
+}
+
+// realEstateSigners converts the spec into a real estate signer
+func (s signerSpec) realEstateSigners() realestate.Signers {
+	signer := realestate.Signers{
+		Email:       ptr(s.Email),
+		FirstName:   ptrIfNotEmpty(s.First),
+		MiddleName:  ptrIfNotEmpty(s.Middle),
+		LastName:    ptrIfNotEmpty(s.Last),
+		PhoneNumber: ptrIfNotEmpty(s.Phone),
+		ExternalId:  ptrIfNotEmpty(s.ExternalID),
+		Order:       s.Order,
+	}
+	if s.Requirement != "" {
+		signer.SigningRequirement = ptr(realestate.SignersSigningRequirement(s.Requirement))
+	}
+	return signer
+}
+
+// parseCosignerSpec parses a --cosigner value such as "first=John,last=Doe,requirement=esign"
+func parseCosignerSpec(spec string) (*business.Cosigner, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	values, err := parseKeyValueSpec("cosigner", spec, "first", "last", "requirement")
+	if err != nil {
+		return nil, err
+	}
+
+	cosigner := &business.Cosigner{
+		FirstName: ptrIfNotEmpty(values["first"]),
+		LastName:  ptrIfNotEmpty(values["last"]),
+	}
+	cosigner.SigningRequirement, err = parseEnum("cosigner requirement", values["requirement"],
+		business.CosignerSigningRequirementEsign,
+		business.CosignerSigningRequirementIdentify,
+		business.CosignerSigningRequirementVerify)
+	if err != nil {
+		return nil, err
+	}
+	return cosigner, nil
+}
+
+// parseDocumentSpec parses a --document value of the form
+// "path-or-url[,requirement=esign][,name=Filename.pdf]"
+func parseDocumentSpec(spec string) (documentSpec, error) {
+	source, options, _ := strings.Cut(spec, ",")
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return documentSpec{}, clierr.Input("invalid --document %q: a file path or URL is required", spec)
+	}
+
+	values, err := parseKeyValueSpec("document", options, "requirement", "name")
+	if err != nil {
+		return documentSpec{}, err
+	}
+	if r := values["requirement"]; r != "" && !slices.Contains(documentRequirements, r) {
+		return documentSpec{}, clierr.Input("invalid --document requirement %q (allowed: %s)", r, strings.Join(documentRequirements, ", "))
+	}
+
+	return documentSpec{Source: source, Requirement: values["requirement"], Filename: values["name"]}, nil
+}
+
+// isURL reports whether a document source is a remote URL rather than a local file
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// content returns what the API should receive for the document: local files
+// are read and encoded as base64, URLs are passed through for the API to fetch
+func (d documentSpec) content() (string, error) {
+	if isURL(d.Source) {
+		return d.Source, nil
+	}
+	data, err := os.ReadFile(d.Source)
+	if err != nil {
+		return "", clierr.Wrap(clierr.KindInput, err, "failed to read document file")
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// resolveDocument builds the business request object for a document
+func resolveDocument(spec documentSpec) (business.DocumentCreationParams, error) {
+	resource, err := spec.content()
+	if err != nil {
+		return business.DocumentCreationParams{}, err
+	}
+
+	return business.DocumentCreationParams{
+		Resource:    ptr(resource),
+		Requirement: ptrIfNotEmpty(spec.Requirement),
+		Filename:    ptrIfNotEmpty(spec.Filename),
+	}, nil
+}
+
+// resolveRealEstateDocument builds the real estate request object for a document
+func resolveRealEstateDocument(spec documentSpec) (realestate.DocumentParams, error) {
+	document, err := spec.content()
+	if err != nil {
+		return realestate.DocumentParams{}, err
+	}
+
+	return realestate.DocumentParams{
+		Document:    ptr(document),
+		Requirement: ptrIfNotEmpty(spec.Requirement),
+		Name:        ptrIfNotEmpty(spec.Filename),
+	}, nil
+}
+
+// parseCCEmails validates --cc addresses
+func parseCCEmails(emails []string) (*[]business.CcRecipientEmail, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	for _, email := range emails {
+		if !strings.Contains(email, "@") {
+			return nil, clierr.Input("invalid --cc %q: not an email address", email)
+		}
+	}
+	return ptr(emails), nil
+}
+
+// parseNotaryInstructions converts --notary-instruction values, enforcing the API's 500 character limit
+func parseNotaryInstructions(notes []string) (*[]business.NotaryInstructions, error) {
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	instructions := make([]business.NotaryInstructions, 0, len(notes))
+	for _, note := range notes {
+		if len([]rune(note)) > 500 {
+			return nil, clierr.Input("--notary-instruction is limited to 500 characters (got %d)", len([]rune(note)))
+		}
+		instructions = append(instructions, business.NotaryInstructions{NotaryNote: ptr(note)})
+	}
+	return &instructions, nil
+}
+
+// parseContactSpec parses a --contact value such as
+// "role=title_agent,email=agent@example.com,first=Sam,last=Lee,organization=Acme Title"
+func parseContactSpec(spec string) (realestate.Contact, error) {
+	values, err := parseKeyValueSpec("contact", spec, "role", "email", "first", "last", "phone", "organization", "title", "access", "shown")
+	if err != nil {
+		return realestate.Contact{}, err
+	}
+	if values["role"] == "" {
+		return realestate.Contact{}, clierr.Input("invalid --contact %q: role is required", spec)
+	}
+
+	contact := realestate.Contact{
+		Email:            ptrIfNotEmpty(values["email"]),
+		FirstName:        ptrIfNotEmpty(values["first"]),
+		LastName:         ptrIfNotEmpty(values["last"]),
+		PhoneNumber:      ptrIfNotEmpty(values["phone"]),
+		OrganizationName: ptrIfNotEmpty(values["organization"]),
+		Title:            ptrIfNotEmpty(values["title"]),
+	}
+
+	contact.Role, err = parseEnum("contact role", values["role"],
+		realestate.ContactRoleTitleAgent,
+		realestate.ContactRoleLoanOfficer,
+		realestate.ContactRoleLoanProcessor,
+		realestate.ContactRoleEscrowOfficer,
+		realestate.ContactRoleCloser,
+		realestate.ContactRoleAttorney,
+		realestate.ContactRoleMortgageBroker,
+		realestate.ContactRoleRealEstateAgent,
+		realestate.ContactRoleOther)
+	if err != nil {
+		return realestate.Contact{}, err
+	}
+
+	for key, field := range map[string]**bool{"access": &contact.AccessToTransaction, "shown": &contact.ShownToSigner} {
+		if value := values[key]; value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return realestate.Contact{}, clierr.Input("invalid --contact %s %q: must be true or false", key, value)
+			}
+			*field = ptr(b)
+		}
+	}
+	return contact, nil
+}
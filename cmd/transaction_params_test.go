@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/business"
+	"github.com/tsarlewey/proof-cli/pkg/sdk/realestate"
+)
+
+func TestParseSignerSpec(t *testing.T) {
+	signer, err := parseSignerSpec("email=jane@example.com, first=Jane,last=Doe,phone=5555550123,order=2,requirement=esign")
+	require.NoError(t, err)
+
+	additional := signer.businessSigners()
+	assert.Equal(t, "jane@example.com", *additional.Email)
+	assert.Equal(t, "Jane", *additional.FirstName)
+	assert.Equal(t, "Doe", *additional.LastName)
+	assert.Equal(t, "5555550123", *additional.PhoneNumber)
+	assert.Equal(t, 2, *additional.Order)
+	assert.Equal(t, business.SignersSigningRequirementEsign, *additional.SigningRequirement)
+	assert.Nil(t, additional.MiddleName)
+
+	realEstate := signer.realEstateSigners()
+	assert.Equal(t, "jane@example.com", *realEstate.Email)
+	assert.Equal(t, realestate.SignersSigningRequirementEsign, *realEstate.SigningRequirement)
+}
+
+func TestParseSignerSpec_Invalid(t *testing.T) {
+	testCases := []string{
+		"first=Jane",
+		"email=jane@example.com,nickname=JJ",
+		"email=jane@example.com,order=first",
+		"email=jane@example.com,requirement=notarize",
+		"jane@example.com",
+	}
+
+	for _, spec := range testCases {
+		_, err := parseSignerSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestSignerSpec_BusinessSigner(t *testing.T) {
+	spec, err := parseSignerSpec("email=jane@example.com,first=Jane,requirement=verify")
+	require.NoError(t, err)
+
+	signer := spec.businessSigner()
+
+	assert.Equal(t, "jane@example.com", signer.Email)
+	assert.Equal(t, "Jane", *signer.FirstName)
+	assert.Equal(t, business.SignerSigningRequirementVerify, *signer.SigningRequirement)
+}
+
+func TestCheckPrimarySignerFlags(t *testing.T) {
+	newFlags := func(args ...string) *pflag.FlagSet {
+		flags := pflag.NewFlagSet("create", pflag.ContinueOnError)
+		for _, name := range []string{"email", "first-name", "middle-name", "last-name", "phone-number"} {
+			flags.String(name, "", "")
+		}
+		flags.StringSlice("signer", nil, "")
+		require.NoError(t, flags.Parse(args))
+		return flags
+	}
+
+	assert.NoError(t, checkPrimarySignerFlags(newFlags("--email", "jane@example.com", "--first-name", "Jane")))
+	assert.NoError(t, checkPrimarySignerFlags(newFlags("--signer", "email=jane@example.com,first=Jane")))
+
+	err := checkPrimarySignerFlags(newFlags("--signer", "email=jane@example.com", "--phone-number", "5555550123"))
+	requireInputError(t, err)
+	assert.Contains(t, err.Error(), "--phone-number requires --email")
+}
+
+func TestParseCosignerSpec(t *testing.T) {
+	cosigner, err := parseCosignerSpec("")
+	assert.NoError(t, err)
+	assert.Nil(t, cosigner)
+
+	cosigner, err = parseCosignerSpec("first=John,last=Doe,requirement=identify")
+	require.NoError(t, err)
+	assert.Equal(t, "John", *cosigner.FirstName)
+	assert.Equal(t, business.CosignerSigningRequirementIdentify, *cosigner.SigningRequirement)
+
+	_, err = parseCosignerSpec("email=john@example.com")
+	assert.Error(t, err)
+}
+
+func TestParseDocumentSpec(t *testing.T) {
+	doc, err := parseDocumentSpec("deed.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, documentSpec{Source: "deed.pdf"}, doc)
+
+	doc, err = parseDocumentSpec("https://example.com/disclosure.pdf,requirement=esign,name=Disclosure.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, documentSpec{Source: "https://example.com/disclosure.pdf", Requirement: "esign", Filename: "Disclosure.pdf"}, doc)
+
+	_, err = parseDocumentSpec("deed.pdf,requirement=sign")
+	assert.Error(t, err)
+
+	_, err = parseDocumentSpec(",requirement=esign")
+	assert.Error(t, err)
+}
+
+func TestResolveDocument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deed.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("%PDF-1.4"), 0600))
+
+	params, err := resolveDocument(documentSpec{Source: path, Requirement: "notarization"})
+	require.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("%PDF-1.4")), *params.Resource)
+	assert.Equal(t, "notarization", *params.Requirement)
+
+	params, err = resolveDocument(documentSpec{Source: "https://example.com/deed.pdf"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/deed.pdf", *params.Resource)
+	assert.Nil(t, params.Requirement)
+
+	_, err = resolveDocument(documentSpec{Source: filepath.Join(t.TempDir(), "missing.pdf")})
+	assert.Error(t, err)
+}
+
+func TestParseCCEmailsAndNotaryInstructions(t *testing.T) {
+	cc, err := parseCCEmails([]string{"agent@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent@example.com"}, *cc)
+
+	_, err = parseCCEmails([]string{"agent"})
+	assert.Error(t, err)
+
+	instructions, err := parseNotaryInstructions([]string{"Verify both IDs"})
+	require.NoError(t, err)
+	assert.Equal(t, "Verify both IDs", *(*instructions)[0].NotaryNote)
+
+	_, err = parseNotaryInstructions([]string{strings.Repeat("x", 501)})
+	assert.Error(t, err)
+}
+
+func TestTransactionCreateBody_DocumentObjects(t *testing.T) {
+	body := transactionCreateBody{
+		TransactionCreateParams: business.TransactionCreateParams{
+			Signer:  business.Signer{Email: "jane@example.com"},
+			Signers: &[]business.Signers{{Email: ptr("john@example.com")}},
+		},
+		Documents: []business.DocumentCreationParams{{Resource: ptr("aGVsbG8="), Requirement: ptr("esign")}},
+	}
+
+	encoded, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"signer": {"email": "jane@example.com"},
+		"signers": [{"email": "john@example.com"}],
+		"documents": [{"resource": "aGVsbG8=", "requirement": "esign"}]
+	}`, string(encoded))
+}
+
+func TestParseContactSpec(t *testing.T) {
+	contact, err := parseContactSpec("role=title_agent,email=agent@example.com,first=Sam,last=Lee,organization=Acme Title,access=true")
+	require.NoError(t, err)
+
+	assert.Equal(t, realestate.ContactRoleTitleAgent, *contact.Role)
+	assert.Equal(t, "agent@example.com", *contact.Email)
+	assert.Equal(t, "Acme Title", *contact.OrganizationName)
+	assert.True(t, *contact.AccessToTransaction)
+	assert.Nil(t, contact.ShownToSigner)
+}
+
+func TestParseContactSpec_Invalid(t *testing.T) {
+	testCases := []string{
+		"email=agent@example.com",
+		"role=notary,email=agent@example.com",
+		"role=closer,access=maybe",
+		"role=closer,fax=5555550123",
+	}
+
+	for _, spec := range testCases {
+		_, err := parseContactSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestResolveRealEstateDocument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("%PDF-1.4"), 0600))
+
+	params, err := resolveRealEstateDocument(documentSpec{Source: path, Requirement: "esign", Filename: "Note.pdf"})
+	require.NoError(t, err)
+
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("%PDF-1.4")), *params.Document)
+	assert.Equal(t, "esign", *params.Requirement)
+	assert.Equal(t, "Note.pdf", *params.Name)
+}
@@ -0,0 +1,140 @@
+// Package openapi embeds the Proof API specifications the SDK clients are
+// generated from, so requests can be validated locally before they are sent.
+package openapi
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Spec names, matching the JSON files in this directory
+const (
+	Business     = "business"
+	RealEstate   = "realestate"
+	SCIM         = "scim"
+	Logs         = "logs"
+	Certificates = "certificates"
+)
+
+//go:embed *.json
+var files embed.FS
+
+var (
+	mu     sync.Mutex
+	loaded = map[string]*openapi3.T{}
+)
+
+// Raw returns the JSON document of a specification
+func Raw(name string) ([]byte, error) {
+	data, err := files.ReadFile(name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown API specification %q", name)
+	}
+	return data, nil
+}
+
+// Load parses a specification, caching the result for later calls
+func Load(name string) (*openapi3.T, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if doc, ok := loaded[name]; ok {
+		return doc, nil
+	}
+
+	data, err := Raw(name)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s specification: %w", name, err)
+	}
+	loaded[name] = doc
+	return doc, nil
+}
+
+// Validate checks a decoded JSON value against a component schema of a
+// specification. It returns one message per violation, each prefixed with the
+// path of the offending field; an empty result means the value is valid.
+// Properties the schema does not declare are reported too, so typos in
+// hand-written request files are caught.
+func Validate(spec, schema string, value any) ([]string, error) {
+	doc, err := Load(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, ok := doc.Components.Schemas[schema]
+	if !ok || ref.Value == nil {
+		return nil, fmt.Errorf("schema %q not found in %s specification", schema, spec)
+	}
+
+	var messages []string
+	unknownProperties(ref.Value, value, "", &messages)
+	if err := ref.Value.VisitJSON(value, openapi3.MultiErrors()); err != nil {
+		collectErrors(err, &messages)
+	}
+	sort.Strings(messages)
+	return messages, nil
+}
+
+// unknownProperties reports object keys that a schema does not declare
+func unknownProperties(schema *openapi3.Schema, value any, path string, messages *[]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		additional := schema.AdditionalProperties
+		if len(schema.Properties) == 0 || additional.Schema != nil || (additional.Has != nil && *additional.Has) {
+			return
+		}
+		for key, item := range v {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			prop, ok := schema.Properties[key]
+			if !ok || prop.Value == nil {
+				*messages = append(*messages, field+": unknown property")
+				continue
+			}
+			unknownProperties(prop.Value, item, field, messages)
+		}
+	case []any:
+		if schema.Items == nil || schema.Items.Value == nil {
+			return
+		}
+		for i, item := range v {
+			unknownProperties(schema.Items.Value, item, fmt.Sprintf("%s.%d", path, i), messages)
+		}
+	}
+}
+
+// collectErrors flattens kin-openapi's nested validation errors into messages
+func collectErrors(err error, messages *[]string) {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		for _, e := range multi {
+			collectErrors(e, messages)
+		}
+		return
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		path := strings.Join(schemaErr.JSONPointer(), ".")
+		if path == "" {
+			*messages = append(*messages, schemaErr.Reason)
+			return
+		}
+		*messages = append(*messages, path+": "+schemaErr.Reason)
+		return
+	}
+
+	*messages = append(*messages, err.Error())
+}
@@ -0,0 +1,58 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_AllSpecs(t *testing.T) {
+	for _, name := range []string{Business, RealEstate, SCIM, Logs, Certificates} {
+		doc, err := Load(name)
+		require.NoError(t, err, name)
+		assert.NotEmpty(t, doc.Paths.Len(), name)
+	}
+
+	_, err := Load("nope")
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	messages, err := Validate(Business, "transaction_create_params", map[string]any{
+		"signer": map[string]any{"email": "jane@example.com"},
+		"payer":  "sender",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	messages, err = Validate(Business, "transaction_create_params", map[string]any{
+		"signer": map[string]any{"first_name": "Jane"},
+		"payer":  "notary",
+		"draft":  "yes",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"draft: value must be a boolean",
+		`payer: value is not one of the allowed values ["signer","sender"]`,
+		`signer.email: property "email" is missing`,
+	}, messages)
+
+	_, err = Validate(Business, "no_such_schema", map[string]any{})
+	assert.Error(t, err)
+}
+
+func TestValidate_UnknownProperties(t *testing.T) {
+	messages, err := Validate(Business, "transaction_create_params", map[string]any{
+		"signer":  map[string]any{"email": "jane@example.com", "fist_name": "Jane"},
+		"signers": []any{map[string]any{"email": "john@example.com", "phone_numbr": "5555550123"}},
+		"payr":    "sender",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"payr: unknown property",
+		"signer.fist_name: unknown property",
+		"signers.0.phone_numbr: unknown property",
+	}, messages)
+}
@@ -0,0 +1,199 @@
+// Package clierr defines the typed errors surfaced by the CLI and the stable
+// process exit code associated with each kind of failure.
+package clierr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+)
+
+// Kind classifies a failure so wrapper scripts can branch on it
+type Kind string
+
+const (
+	KindGeneral     Kind = "general"
+	KindInput       Kind = "input"
+	KindAuth        Kind = "auth"
+	KindNotFound    Kind = "not_found"
+	KindValidation  Kind = "validation"
+	KindRateLimited Kind = "rate_limited"
+	KindServer      Kind = "server"
+	KindNetwork     Kind = "network"
+)
+
+// Exit codes returned by the CLI. These values are part of the public
+// interface and must not change once released.
+const (
+	ExitOK          = 0
+	ExitGeneral     = 1
+	ExitInput       = 2
+	ExitAuth        = 3
+	ExitNotFound    = 4
+	ExitValidation  = 5
+	ExitRateLimited = 6
+	ExitServer      = 7
+	ExitNetwork     = 8
+)
+
+var exitCodes = map[Kind]int{
+	KindGeneral:     ExitGeneral,
+	KindInput:       ExitInput,
+	KindAuth:        ExitAuth,
+	KindNotFound:    ExitNotFound,
+	KindValidation:  ExitValidation,
+	KindRateLimited: ExitRateLimited,
+	KindServer:      ExitServer,
+	KindNetwork:     ExitNetwork,
+}
+
+// Error is a classified CLI failure
+type Error struct {
+	Kind       Kind
+	Message    string
+	StatusCode int
+	Details    []string
+	Err        error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	switch {
+	case e.Message == "" && e.Err != nil:
+		return e.Err.Error()
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	default:
+		return e.Message
+	}
+}
+
+// Unwrap returns the underlying error, if any
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for the error's kind
+func (e *Error) ExitCode() int {
+	if code, ok := exitCodes[e.Kind]; ok {
+		return code
+	}
+	return ExitGeneral
+}
+
+// New creates an error of the given kind with a formatted message
+func New(kind Kind, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Input creates a local input error, used for invalid flags, arguments and files
+func Input(format string, args ...any) *Error {
+	return New(KindInput, format, args...)
+}
+
+// Wrap attaches a kind and context message to an existing error
+func Wrap(kind Kind, err error, message string) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// FromStatus creates an error for an API response with the given HTTP status code
+func FromStatus(statusCode int, message string, details []string) *Error {
+	return &Error{
+		Kind:       KindForStatus(statusCode),
+		Message:    message,
+		StatusCode: statusCode,
+		Details:    details,
+	}
+}
+
+// KindForStatus maps an HTTP status code onto an error kind
+func KindForStatus(statusCode int) Kind {
+	switch {
+	case statusCode == 401 || statusCode == 403:
+		return KindAuth
+	case statusCode == 404:
+		return KindNotFound
+	case statusCode == 400 || statusCode == 409 || statusCode == 422:
+		return KindValidation
+	case statusCode == 429:
+		return KindRateLimited
+	case statusCode >= 500:
+		return KindServer
+	default:
+		return KindGeneral
+	}
+}
+
+// From converts any error into an *Error. Errors that already carry a kind are
+// returned unchanged; network failures (net.Error, which includes *url.Error),
+// timeouts and cancellations are classified as network errors.
+func From(err error, message string) *Error {
+	var cliErr *Error
+	if errors.As(err, &cliErr) {
+		if message == "" {
+			return cliErr
+		}
+		wrapped := *cliErr
+		wrapped.Message = message + ": " + cliErr.Error()
+		wrapped.Err = nil
+		return &wrapped
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return Wrap(KindNetwork, err, message)
+	}
+	return Wrap(KindGeneral, err, message)
+}
+
+// jsonOutput controls whether errors are rendered as JSON objects
+var jsonOutput bool
+
+// SetJSONOutput switches error rendering between text and JSON
+func SetJSONOutput(enabled bool) {
+	jsonOutput = enabled
+}
+
+// Print writes the error to w as text, or as a JSON object when JSON output is enabled
+func Print(w io.Writer, err *Error) {
+	if jsonOutput {
+		payload := struct {
+			Error jsonError `json:"error"`
+		}{
+			Error: jsonError{
+				Kind:       err.Kind,
+				Message:    err.Error(),
+				StatusCode: err.StatusCode,
+				Details:    err.Details,
+				ExitCode:   err.ExitCode(),
+			},
+		}
+		data, _ := json.Marshal(payload)
+		fmt.Fprintln(w, string(data))
+		return
+	}
+
+	fmt.Fprintf(w, "Error: %s\n", err.Error())
+	for _, detail := range err.Details {
+		fmt.Fprintf(w, "  - %s\n", detail)
+	}
+}
+
+// Exit prints the error to stderr and terminates the process with its exit code
+func Exit(err error) {
+	cliErr := From(err, "")
+	Print(os.Stderr, cliErr)
+	os.Exit(cliErr.ExitCode())
+}
+
+type jsonError struct {
+	Kind       Kind     `json:"kind"`
+	Message    string   `json:"message"`
+	StatusCode int      `json:"status_code,omitempty"`
+	Details    []string `json:"details,omitempty"`
+	ExitCode   int      `json:"exit_code"`
+}
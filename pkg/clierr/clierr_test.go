@@ -0,0 +1,100 @@
+package clierr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCodes_AreStable(t *testing.T) {
+	expected := map[Kind]int{
+		KindGeneral:     1,
+		KindInput:       2,
+		KindAuth:        3,
+		KindNotFound:    4,
+		KindValidation:  5,
+		KindRateLimited: 6,
+		KindServer:      7,
+		KindNetwork:     8,
+	}
+
+	for kind, code := range expected {
+		assert.Equal(t, code, (&Error{Kind: kind}).ExitCode(), "kind %s", kind)
+	}
+	assert.Equal(t, ExitGeneral, (&Error{Kind: "unknown"}).ExitCode())
+}
+
+func TestKindForStatus(t *testing.T) {
+	testCases := []struct {
+		status   int
+		expected Kind
+	}{
+		{400, KindValidation},
+		{401, KindAuth},
+		{403, KindAuth},
+		{404, KindNotFound},
+		{409, KindValidation},
+		{422, KindValidation},
+		{429, KindRateLimited},
+		{500, KindServer},
+		{503, KindServer},
+		{418, KindGeneral},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, KindForStatus(tc.status), "status %d", tc.status)
+	}
+}
+
+func TestError_Message(t *testing.T) {
+	assert.Equal(t, "email is required", Input("email is required").Error())
+	assert.Equal(t, "failed to read file: boom", Wrap(KindInput, errors.New("boom"), "failed to read file").Error())
+	assert.Equal(t, "boom", Wrap(KindInput, errors.New("boom"), "").Error())
+}
+
+func TestFrom(t *testing.T) {
+	original := Input("bad flag")
+	assert.Same(t, original, From(original, ""))
+	assert.Equal(t, KindInput, From(fmt.Errorf("wrapped: %w", original), "").Kind)
+
+	wrapped := From(original, "failed to create transaction")
+	assert.Equal(t, KindInput, wrapped.Kind)
+	assert.Equal(t, "failed to create transaction: bad flag", wrapped.Error())
+
+	assert.Equal(t, KindNetwork, From(context.DeadlineExceeded, "request").Kind)
+	assert.Equal(t, KindNetwork, From(context.Canceled, "request").Kind)
+	assert.Equal(t, KindNetwork, From(&url.Error{Op: "Get", URL: "https://api.proof.com", Err: errors.New("connection refused")}, "request").Kind)
+	assert.Equal(t, KindGeneral, From(errors.New("other"), "").Kind)
+}
+
+func TestPrint_Text(t *testing.T) {
+	SetJSONOutput(false)
+
+	var buf bytes.Buffer
+	Print(&buf, FromStatus(422, "failed to create transaction", []string{"signer.email: is invalid"}))
+
+	assert.Equal(t, "Error: failed to create transaction\n  - signer.email: is invalid\n", buf.String())
+}
+
+func TestPrint_JSON(t *testing.T) {
+	SetJSONOutput(true)
+	defer SetJSONOutput(false)
+
+	var buf bytes.Buffer
+	Print(&buf, FromStatus(404, "failed to fetch transaction", []string{"not found"}))
+
+	var payload map[string]map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &payload))
+	assert.Equal(t, "not_found", payload["error"]["kind"])
+	assert.Equal(t, "failed to fetch transaction", payload["error"]["message"])
+	assert.Equal(t, float64(404), payload["error"]["status_code"])
+	assert.Equal(t, float64(ExitNotFound), payload["error"]["exit_code"])
+	assert.Equal(t, []any{"not found"}, payload["error"]["details"])
+}
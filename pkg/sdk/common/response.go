@@ -0,0 +1,137 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// StatusResponse is implemented by every *Response type produced by oapi-codegen.
+type StatusResponse interface {
+	StatusCode() int
+	Status() string
+}
+
+// APIError describes a non-2xx response returned through one of the generated SDK clients.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Messages   []string
+	Body       []byte
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error (status %d)", e.StatusCode)
+	switch {
+	case len(e.Messages) > 0:
+		msg += ": " + strings.Join(e.Messages, "; ")
+	case len(e.Body) > 0:
+		msg += ": " + strings.TrimSpace(string(e.Body))
+	}
+	return msg
+}
+
+// CheckResponse inspects a generated *Response value and returns an *APIError when
+// its status code is outside the 2xx range. Error messages are taken from the typed
+// JSON4xx/JSON5xx field matching the status code when the SDK decoded one, and from
+// the raw body otherwise.
+func CheckResponse(resp any) error {
+	sr, ok := resp.(StatusResponse)
+	if rv := reflect.ValueOf(resp); !ok || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return fmt.Errorf("no response received")
+	}
+
+	code := sr.StatusCode()
+	if code >= 200 && code < 300 {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: code,
+		Status:     sr.Status(),
+	}
+	if apiErr.Status == "" {
+		apiErr.Status = http.StatusText(code)
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(resp))
+	if body := v.FieldByName("Body"); body.IsValid() && body.Kind() == reflect.Slice {
+		apiErr.Body = body.Bytes()
+	}
+
+	// Prefer the typed error payload when the SDK decoded one for this status
+	if typed := v.FieldByName(fmt.Sprintf("JSON%d", code)); typed.IsValid() && !typed.IsNil() {
+		if data, err := json.Marshal(typed.Interface()); err == nil {
+			apiErr.Messages = ExtractErrorMessages(data)
+		}
+	}
+	if len(apiErr.Messages) == 0 {
+		apiErr.Messages = ExtractErrorMessages(apiErr.Body)
+	}
+
+	return apiErr
+}
+
+// ExtractErrorMessages pulls human readable messages out of a Proof error payload.
+// The APIs use several shapes: errors as a string, a list of strings, a map of
+// field names to messages, a deprecated top-level message, and SCIM's detail.
+func ExtractErrorMessages(data []byte) []string {
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil
+	}
+
+	var messages []string
+	if errs, ok := payload["errors"]; ok {
+		messages = append(messages, flattenErrorValue("", errs)...)
+	}
+	for _, key := range []string{"message", "detail", "error", "error_description"} {
+		if s, ok := payload[key].(string); ok && s != "" {
+			messages = append(messages, s)
+		}
+	}
+	return messages
+}
+
+// flattenErrorValue converts an arbitrary errors value into a list of strings
+func flattenErrorValue(field string, value any) []string {
+	prefix := ""
+	if field != "" {
+		prefix = field + ": "
+	}
+
+	switch val := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{prefix + val}
+	case []any:
+		var out []string
+		for _, item := range val {
+			out = append(out, flattenErrorValue(field, item)...)
+		}
+		return out
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var out []string
+		for _, k := range keys {
+			name := k
+			if field != "" {
+				name = field + "." + k
+			}
+			out = append(out, flattenErrorValue(name, val[k])...)
+		}
+		return out
+	default:
+		return []string{prefix + fmt.Sprint(val)}
+	}
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeErrors mirrors the shape of the generated errors_object types
+type fakeErrors struct {
+	Errors  *[]string `json:"errors,omitempty"`
+	Message *string   `json:"message,omitempty"`
+}
+
+// fakeResponse mirrors the shape of a generated *Response type
+type fakeResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *map[string]any
+	JSON422      *fakeErrors
+}
+
+func (r fakeResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+func (r fakeResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+func newFakeResponse(statusCode int, body string) *fakeResponse {
+	return &fakeResponse{
+		Body: []byte(body),
+		HTTPResponse: &http.Response{
+			StatusCode: statusCode,
+			Status:     http.StatusText(statusCode),
+		},
+	}
+}
+
+func TestCheckResponse_Success(t *testing.T) {
+	for _, code := range []int{200, 201, 204} {
+		assert.NoError(t, CheckResponse(newFakeResponse(code, `{}`)))
+	}
+}
+
+func TestCheckResponse_NilResponse(t *testing.T) {
+	var resp *fakeResponse
+	assert.Error(t, CheckResponse(resp))
+	assert.Error(t, CheckResponse(nil))
+}
+
+func TestCheckResponse_UsesTypedErrorField(t *testing.T) {
+	resp := newFakeResponse(422, `{"errors":["raw body message"]}`)
+	resp.JSON422 = &fakeErrors{Errors: &[]string{"signer email is invalid"}}
+
+	err := CheckResponse(resp)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 422, apiErr.StatusCode)
+	assert.Equal(t, []string{"signer email is invalid"}, apiErr.Messages)
+	assert.Contains(t, err.Error(), "status 422")
+}
+
+func TestCheckResponse_FallsBackToBody(t *testing.T) {
+	resp := newFakeResponse(401, `{"message":"Unauthorized"}`)
+
+	err := CheckResponse(resp)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, []string{"Unauthorized"}, apiErr.Messages)
+}
+
+func TestCheckResponse_NonJSONBody(t *testing.T) {
+	resp := newFakeResponse(502, `Bad Gateway`)
+
+	err := CheckResponse(resp)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Empty(t, apiErr.Messages)
+	assert.Contains(t, err.Error(), "Bad Gateway")
+}
+
+func TestExtractErrorMessages(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"string errors", `{"errors":"bad request"}`, []string{"bad request"}},
+		{"list errors", `{"errors":["a","b"]}`, []string{"a", "b"}},
+		{"map errors", `{"errors":{"signer":{"email":["is invalid"]}}}`, []string{"signer.email: is invalid"}},
+		{"message", `{"message":"deprecated"}`, []string{"deprecated"}},
+		{"scim detail", `{"detail":"User not found"}`, []string{"User not found"}},
+		{"not json", `oops`, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractErrorMessages([]byte(tc.input)))
+		})
+	}
+}
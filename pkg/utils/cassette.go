@@ -0,0 +1,326 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+)
+
+// ErrCassetteMiss is returned when a replayed request has no recorded response
+var ErrCassetteMiss = errors.New("no recorded response in cassette")
+
+// Cassette is a file of recorded HTTP exchanges, written with PROOF_RECORD and
+// served back with PROOF_REPLAY
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request as stored in a cassette, with secrets scrubbed
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	// Encoding is "base64" for bodies that are not UTF-8 text
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// RecordedResponse is a response as stored in a cassette, with secrets scrubbed
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Encoding   string      `json:"encoding,omitempty"`
+}
+
+// LoadCassette reads a cassette file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, clierr.Wrap(clierr.KindInput, err, "error reading cassette")
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, clierr.Wrap(clierr.KindInput, err, "error parsing cassette "+path)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to path. Cassettes hold no credentials but may hold
+// customer data, so the file is private to the user.
+func (c *Cassette) Save(path string) error {
+	// HTML escaping would make recorded URLs and bodies harder to read
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
+		return fmt.Errorf("error marshaling cassette: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("error writing cassette: %w", err)
+	}
+	return nil
+}
+
+// RecordTransport is an http.RoundTripper that sends requests through Base and
+// appends each exchange to a cassette file. Credential headers and fields are
+// scrubbed, as is any occurrence of the Secrets. The file is rewritten after
+// every exchange so a command that exits early still leaves a usable cassette.
+type RecordTransport struct {
+	Base    http.RoundTripper
+	Path    string
+	Secrets []string
+
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// NewRecordTransport wraps base, or http.DefaultTransport when base is nil.
+// Exchanges are appended to the cassette at path if it already exists, so one
+// cassette can cover every command run by a script.
+func NewRecordTransport(base http.RoundTripper, path string, secrets ...string) (*RecordTransport, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	cassette := &Cassette{}
+	if _, err := os.Stat(path); err == nil {
+		if cassette, err = LoadCassette(path); err != nil {
+			return nil, err
+		}
+	}
+	return &RecordTransport{Base: base, Path: path, Secrets: secrets, cassette: cassette}, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RecordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     scrubURL(req.URL, t.Secrets),
+			Headers: scrubHeaders(req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    scrubHeaders(resp.Header),
+		},
+	}
+	interaction.Request.Body, interaction.Request.Encoding = scrubBody(reqBody, req.Header.Get("Content-Type"), t.Secrets)
+	interaction.Response.Body, interaction.Response.Encoding = scrubBody(respBody, resp.Header.Get("Content-Type"), t.Secrets)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+	if err := t.cassette.Save(t.Path); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReplayTransport is an http.RoundTripper that serves responses from a
+// cassette instead of the network. A request is answered by the first unused
+// interaction with the same method and URL, preferring one whose body matches
+// too; once all of them are used the last is served again, so polling settles
+// on the final recorded state. Requests with no recording fail with
+// ErrCassetteMiss.
+type ReplayTransport struct {
+	Secrets []string
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayTransport loads the cassette at path
+func NewReplayTransport(path string, secrets ...string) (*ReplayTransport, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayTransport{
+		Secrets:  secrets,
+		cassette: cassette,
+		used:     make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	target := scrubURL(req.URL, t.Secrets)
+	body, _ := scrubBody(reqBody, req.Header.Get("Content-Type"), t.Secrets)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	match, unused, last := -1, -1, -1
+	for i, interaction := range t.cassette.Interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.URL != target {
+			continue
+		}
+		last = i
+		if t.used[i] {
+			continue
+		}
+		if unused < 0 {
+			unused = i
+		}
+		if match < 0 && interaction.Request.Body == body {
+			match = i
+		}
+	}
+	switch {
+	case match >= 0:
+	case unused >= 0:
+		match = unused
+	case last >= 0:
+		match = last
+	default:
+		return nil, fmt.Errorf("%w: %s %s", ErrCassetteMiss, req.Method, target)
+	}
+	t.used[match] = true
+
+	recorded := t.cassette.Interactions[match].Response
+	data, err := decodeBody(recorded.Body, recorded.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding recorded response for %s %s: %w", req.Method, target, err)
+	}
+	header := recorded.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode:    recorded.StatusCode,
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// readRequestBody returns the whole request body without consuming it
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// scrubHeaders copies header with credential headers redacted
+func scrubHeaders(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	scrubbed := header.Clone()
+	for name := range scrubbed {
+		if containsFold(redactedHeaders, name) {
+			scrubbed[name] = []string{"[REDACTED]"}
+		}
+	}
+	return scrubbed
+}
+
+// scrubURL renders u with credential query parameters and secrets redacted
+func scrubURL(u *url.URL, secrets []string) string {
+	scrubbed := *u
+	if query := scrubbed.Query(); len(query) > 0 {
+		for key := range query {
+			if containsFold(redactedFields, key) {
+				query.Set(key, "[REDACTED]")
+			}
+		}
+		scrubbed.RawQuery = query.Encode()
+	}
+	return scrubSecrets(scrubbed.String(), secrets)
+}
+
+// scrubBody renders a body for a cassette with credential fields and secrets
+// redacted. Bodies that are not UTF-8 are stored as base64 and returned with
+// the "base64" encoding.
+func scrubBody(body []byte, contentType string, secrets []string) (string, string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+	if !utf8.Valid(body) {
+		return base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	text := string(body)
+	if redacted, ok := redactFields(body, contentType, false); ok {
+		text = redacted
+	}
+	return scrubSecrets(text, secrets), ""
+}
+
+// scrubSecrets replaces every occurrence of the secrets in s
+func scrubSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "[REDACTED]")
+		}
+	}
+	return s
+}
+
+// decodeBody reverses the encoding applied by scrubBody
+func decodeBody(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpoint records how far a security event tail has read, so a restarted
+// tail resumes exactly where it stopped
+type Checkpoint struct {
+	// Cursor is the position to resume from; empty until the API returns one
+	Cursor string `json:"cursor,omitempty"`
+	// Since is the start time used until the API returns a cursor. It moves
+	// up to the newest event printed, so it never falls out of the API's
+	// retention window.
+	Since string `json:"since,omitempty"`
+	// Seen identifies the events on the last page fetched from Cursor, or from
+	// Since when there is no cursor yet. The API returns the same events again
+	// when that position is fetched again, and these are skipped.
+	Seen []string `json:"seen,omitempty"`
+	// ClassUID and SeverityID are the filters the cursor was issued for
+	ClassUID   *int `json:"class_uid,omitempty"`
+	SeverityID *int `json:"severity_id,omitempty"`
+	// UpdatedAt is when the checkpoint was last saved
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LoadCheckpoint reads the checkpoint at path, returning nil when it does not exist
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint %s: %w", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// SaveCheckpoint writes checkpoint to path. The file is replaced atomically,
+// so a tail stopped at any point leaves either the old or the new checkpoint.
+func SaveCheckpoint(path string, checkpoint *Checkpoint) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("error creating checkpoint directory: %w", err)
+		}
+	}
+	checkpoint.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling checkpoint: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	return nil
+}
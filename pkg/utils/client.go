@@ -3,36 +3,49 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 )
 
 type ProofClient struct {
 	config     *Config
 	httpClient *http.Client
+	retry      *RetryTransport
+	limiter    *RateLimitTransport
+	dryRun     bool
+	replaying  bool
 	apiKey     string
 	oauthToken *OAuthToken
 }
 
 func NewProofClient() (*ProofClient, error) {
-	config, err := LoadConfig()
+	client, err := newProofClient()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+		return nil, err
 	}
 
-	client := &ProofClient{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+	// PROOF_REPLAY serves responses from a cassette, so no credentials are needed
+	replay, record := os.Getenv("PROOF_REPLAY"), os.Getenv("PROOF_RECORD")
+	if replay != "" && record != "" {
+		return nil, fmt.Errorf("PROOF_RECORD and PROOF_REPLAY cannot both be set")
+	}
+	if replay != "" {
+		if err := client.EnableReplay(replay); err != nil {
+			return nil, err
+		}
+		return client, nil
 	}
 
 	// Check if OAuth is enabled
-	if config.OAuth != nil && config.OAuth.Enabled {
+	if client.config.OAuth != nil && client.config.OAuth.Enabled {
 		// Use OAuth authentication - get token on first use to avoid unnecessary calls
 		// Token will be retrieved when needed in getValidOAuthToken()
 	} else {
@@ -44,9 +57,50 @@ func NewProofClient() (*ProofClient, error) {
 		client.apiKey = apiKey
 	}
 
+	if record != "" {
+		if err := client.EnableRecording(record); err != nil {
+			return nil, err
+		}
+	}
+
 	return client, nil
 }
 
+// NewDryRunClient creates a client that prints requests to out instead of
+// sending them, as curl commands when curl is set. No credential is read, so
+// a dry run works before an API key or OAuth client is configured.
+func NewDryRunClient(out io.Writer, curl bool) (*ProofClient, error) {
+	client, err := newProofClient()
+	if err != nil {
+		return nil, err
+	}
+	client.EnableDryRun(out, curl)
+	return client, nil
+}
+
+// newProofClient loads the configuration and sets up the shared transports
+func newProofClient() (*ProofClient, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Every request, including those made by the SDK clients, goes through
+	// the shared transports. The limiter sits below the retries so each
+	// attempt is paced.
+	limiter := NewRateLimitTransport(nil, config.RequestLimits())
+	retry := NewRetryTransport(limiter, config.RetryPolicy())
+	return &ProofClient{
+		config: config,
+		httpClient: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: retry,
+		},
+		retry:   retry,
+		limiter: limiter,
+	}, nil
+}
+
 // AuthenticateOAuth performs OAuth authentication and returns a token
 func (c *ProofClient) AuthenticateOAuth() (*OAuthToken, error) {
 	req, err := PrepareOAuthTokenRequest(c.config)
@@ -93,28 +147,51 @@ func (c *ProofClient) AuthenticateOAuth() (*OAuthToken, error) {
 
 // getValidOAuthToken gets a valid OAuth token, refreshing if necessary
 func (c *ProofClient) getValidOAuthToken() (*OAuthToken, error) {
+	if c.oauthToken != nil && !c.oauthToken.IsExpired() {
+		return c.oauthToken, nil
+	}
+
 	needsRefresh, err := ShouldRefreshToken(c.config)
 	if err != nil {
 		return nil, err
 	}
 
-	if needsRefresh {
+	if !needsRefresh {
+		// Load existing valid token
+		token, err := LoadOAuthToken()
+		if err != nil {
+			return nil, err
+		}
+		c.oauthToken = token
+		return token, nil
+	}
+
+	// Refresh holding the refresh lock. A process that waited for the lock
+	// finds the token another process just saved and uses it instead of
+	// requesting its own.
+	err = withTokenRefreshLock(func() error {
+		if token, err := LoadOAuthToken(); err == nil && !token.IsExpired() {
+			c.oauthToken = token
+			return nil
+		}
+
 		// Get new token
 		token, err := c.AuthenticateOAuth()
 		if err != nil {
-			return nil, fmt.Errorf("error getting OAuth token: %w", err)
+			return fmt.Errorf("error getting OAuth token: %w", err)
 		}
 
 		// Save the new token
 		if err := SaveOAuthToken(token); err != nil {
-			return nil, fmt.Errorf("error saving OAuth token: %w", err)
+			return fmt.Errorf("error saving OAuth token: %w", err)
 		}
-
-		return token, nil
+		c.oauthToken = token
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	// Load existing valid token
-	return LoadOAuthToken()
+	return c.oauthToken, nil
 }
 
 // TestOAuthAuthentication tests OAuth authentication by getting a fresh token
@@ -129,6 +206,7 @@ func (c *ProofClient) TestOAuthAuthentication() (*OAuthToken, error) {
 	if err := SaveOAuthToken(token); err != nil {
 		return nil, fmt.Errorf("error saving OAuth token: %w", err)
 	}
+	c.oauthToken = token
 
 	return token, nil
 }
@@ -137,6 +215,8 @@ func (c *ProofClient) TestOAuthAuthentication() (*OAuthToken, error) {
 type RequestOptions struct {
 	ContentType string
 	Accept      string
+	// RetryUnsafe allows a POST or PATCH to be retried; only set it for requests that are safe to repeat
+	RetryUnsafe bool
 }
 
 func (c *ProofClient) Request(method, path string, body any, opts ...*RequestOptions) ([]byte, error) {
@@ -151,21 +231,19 @@ func (c *ProofClient) Request(method, path string, body any, opts ...*RequestOpt
 		bodyReader = bytes.NewBuffer(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, url, bodyReader)
+	ctx := context.Background()
+	if len(opts) > 0 && opts[0] != nil && opts[0].RetryUnsafe {
+		ctx = WithRetryUnsafe(ctx)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set authentication header
-	if c.config.OAuth != nil && c.config.OAuth.Enabled {
-		// Use OAuth authentication
-		token, err := c.getValidOAuthToken()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get OAuth token: %w", err)
-		}
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-	} else {
-		req.Header.Set("ApiKey", c.apiKey)
+	if err := c.AddAuthHeaders(req); err != nil {
+		return nil, err
 	}
 
 	// Set content type from options or use default
@@ -194,7 +272,11 @@ func (c *ProofClient) Request(method, path string, body any, opts ...*RequestOpt
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		var details []string
+		if len(respBody) > 0 {
+			details = []string{string(respBody)}
+		}
+		return nil, clierr.FromStatus(resp.StatusCode, fmt.Sprintf("API error (status %d)", resp.StatusCode), details)
 	}
 
 	return respBody, nil
@@ -223,7 +305,19 @@ func (c *ProofClient) Delete(path string, opts ...*RequestOptions) ([]byte, erro
 // AddAuthHeaders adds authentication headers (OAuth Bearer token or API key) to an HTTP request.
 // This is useful for integrating with generated SDK clients that require a custom HTTP client.
 func (c *ProofClient) AddAuthHeaders(req *http.Request) error {
-	if c.config.OAuth != nil && c.config.OAuth.Enabled {
+	oauth := c.config.OAuth != nil && c.config.OAuth.Enabled
+	if c.dryRun || c.replaying {
+		// Never fetch a token or reveal a credential for a request that is only
+		// printed or answered from a cassette
+		if oauth {
+			req.Header.Set("Authorization", "Bearer "+AccessTokenPlaceholder)
+		} else {
+			req.Header.Set("ApiKey", APIKeyPlaceholder)
+		}
+		return nil
+	}
+
+	if oauth {
 		// Use OAuth authentication
 		token, err := c.getValidOAuthToken()
 		if err != nil {
@@ -236,6 +330,128 @@ func (c *ProofClient) AddAuthHeaders(req *http.Request) error {
 	return nil
 }
 
+// RetryPolicy returns the retry policy of the shared transport
+func (c *ProofClient) RetryPolicy() RetryPolicy {
+	if c.retry == nil {
+		return c.config.RetryPolicy()
+	}
+	return c.retry.Policy
+}
+
+// SetRetryPolicy replaces the retry policy, e.g. with values from command-line flags.
+// It applies to every client sharing this ProofClient's transport.
+func (c *ProofClient) SetRetryPolicy(policy RetryPolicy) {
+	if c.retry == nil {
+		c.retry = NewRetryTransport(c.httpClient.Transport, policy)
+		c.httpClient.Transport = c.retry
+		return
+	}
+	c.retry.Policy = policy
+}
+
+// RateLimit returns the rate limits of the shared transport
+func (c *ProofClient) RateLimit() RateLimit {
+	if c.limiter == nil {
+		return c.config.RequestLimits()
+	}
+	return c.limiter.Limit()
+}
+
+// SetRateLimit replaces the rate limits, e.g. with values from command-line flags.
+// It applies to every client sharing this ProofClient's transport.
+func (c *ProofClient) SetRateLimit(limit RateLimit) {
+	if c.limiter == nil {
+		c.limiter = NewRateLimitTransport(c.httpClient.Transport, limit)
+		c.httpClient.Transport = c.limiter
+		return
+	}
+	c.limiter.SetLimit(limit)
+}
+
+// EnableDryRun prints requests to out instead of sending them, as curl
+// commands when curl is set. Requests are answered with an empty response,
+// and credentials appear as the APIKeyPlaceholder or AccessTokenPlaceholder.
+func (c *ProofClient) EnableDryRun(out io.Writer, curl bool) {
+	c.dryRun = true
+	c.replaceNetwork(&DryRunTransport{Out: out, Curl: curl})
+}
+
+// EnableTracing logs every request and response on the wire at debug level.
+// The trace transport sits closest to the network, so each retry attempt is
+// logged separately and timings exclude rate limiting.
+func (c *ProofClient) EnableTracing(logger log.FieldLogger) {
+	c.wrapNetwork(func(base http.RoundTripper) http.RoundTripper {
+		return NewTraceTransport(base, logger)
+	})
+}
+
+// EnableRecording appends every exchange to the cassette at path, with
+// credentials scrubbed. Each retry attempt is recorded separately.
+func (c *ProofClient) EnableRecording(path string) error {
+	var secrets []string
+	if c.apiKey != "" {
+		secrets = append(secrets, c.apiKey)
+	}
+	if c.config.OAuth != nil && c.config.OAuth.ClientSecret != "" {
+		secrets = append(secrets, c.config.OAuth.ClientSecret)
+	}
+
+	var err error
+	c.wrapNetwork(func(base http.RoundTripper) http.RoundTripper {
+		var record *RecordTransport
+		if record, err = NewRecordTransport(base, path, secrets...); err != nil {
+			return base
+		}
+		return record
+	})
+	return err
+}
+
+// EnableReplay answers requests from the cassette at path instead of the
+// network. Credentials appear as placeholders, as in a dry run, so none need
+// to be configured.
+func (c *ProofClient) EnableReplay(path string) error {
+	replay, err := NewReplayTransport(path)
+	if err != nil {
+		return err
+	}
+	c.replaying = true
+	c.replaceNetwork(replay)
+	return nil
+}
+
+// wrapNetwork replaces the transport closest to the network with the result
+// of wrap, keeping the retry and rate limiting transports above it
+func (c *ProofClient) wrapNetwork(wrap func(base http.RoundTripper) http.RoundTripper) {
+	if c.limiter != nil {
+		c.limiter.Base = wrap(c.limiter.Base)
+		return
+	}
+	c.httpClient.Transport = wrap(c.httpClient.Transport)
+}
+
+// replaceNetwork swaps the network for network, below any trace or record
+// transports already installed so they see the substituted exchanges
+func (c *ProofClient) replaceNetwork(network http.RoundTripper) {
+	c.wrapNetwork(func(base http.RoundTripper) http.RoundTripper {
+		return withNetwork(base, network)
+	})
+}
+
+// withNetwork replaces the innermost transport of a chain of trace and record
+// transports with network
+func withNetwork(chain, network http.RoundTripper) http.RoundTripper {
+	switch t := chain.(type) {
+	case *TraceTransport:
+		t.Base = withNetwork(t.Base, network)
+		return t
+	case *RecordTransport:
+		t.Base = withNetwork(t.Base, network)
+		return t
+	}
+	return network
+}
+
 // HTTPClient returns the underlying HTTP client for use with generated SDK clients.
 func (c *ProofClient) HTTPClient() *http.Client {
 	return c.httpClient
@@ -246,10 +462,11 @@ func (c *ProofClient) GetConfig() *Config {
 	return c.config
 }
 
+// HandleError prints err with the given context message and exits with the
+// exit code for its kind. It does nothing when err is nil.
 func HandleError(err error, message string) {
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s: %v\n", message, err)
-		os.Exit(1)
+		clierr.Exit(clierr.From(err, message))
 	}
 }
 
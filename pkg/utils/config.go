@@ -1,11 +1,12 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strconv"
 	"time"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 )
 
 // Config represents the configuration for the CLI
@@ -15,6 +16,17 @@ type Config struct {
 	OAuth       *OAuthConfig  `json:"oauth,omitempty"`
 	APIKey      string        `json:"api_key,omitempty"`
 	OAuthToken  *OAuthToken   `json:"oauth_token,omitempty"`
+	Retry       *RetryConfig  `json:"retry,omitempty"`
+	RateLimit   *RateLimit    `json:"rate_limit,omitempty"`
+	// OrganizationID is the default organization for commands that take one
+	OrganizationID string `json:"organization_id,omitempty"`
+	// Endpoints overrides the base URL of individual APIs
+	Endpoints *Endpoints `json:"endpoints,omitempty"`
+
+	// Profile is the name of the profile the settings belong to
+	Profile string `json:"-"`
+	// Credentials is the name of the credential store holding the secrets
+	Credentials string `json:"-"`
 }
 
 // OAuthConfig represents OAuth configuration
@@ -35,125 +47,303 @@ type OAuthToken struct {
 	Scope        string    `json:"scope,omitempty"`
 }
 
-// LoadConfig loads the configuration from the config file
+// Overrides are settings given as command-line flags, the highest-precedence
+// source. Empty fields are not set.
+type Overrides struct {
+	APIEndpoint string
+	Timeout     time.Duration
+	MaxRetries  *int
+	RetryWait   time.Duration
+	RPS         *float64
+	Concurrency *int
+}
+
+// flagOverrides holds the settings given on the command line
+var flagOverrides Overrides
+
+// SetOverrides records the settings given as command-line flags
+func SetOverrides(overrides Overrides) {
+	flagOverrides = overrides
+}
+
+// Sources records where each effective setting came from, such as "default",
+// "env PROOF_ENDPOINT" or "profile sandbox", keyed by setting name
+type Sources map[string]string
+
+// Setting names used as Sources keys, in display order
+var SettingNames = []string{
+	"profile", "api_endpoint", "timeout", "organization_id", "credential_store", "api_key", "oauth",
+	"endpoints.business", "endpoints.real_estate", "endpoints.scim", "endpoints.logs", "endpoints.certificates",
+	"retry.max_retries", "retry.wait", "rate_limit.rps", "rate_limit.burst", "rate_limit.concurrency",
+}
+
+// LoadConfig resolves the effective settings of the active profile. Each
+// setting comes from the first source that sets it: command-line flags,
+// environment variables, the profile, the top-level settings of the config
+// file, then the built-in defaults. The config file is created with default
+// settings if it does not exist.
 func LoadConfig() (*Config, error) {
-	// Default config
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		Timeout:     30 * time.Second,
+	config, _, err := ResolveConfig()
+	return config, err
+}
+
+// ResolveConfig resolves the settings like LoadConfig and also reports where
+// each one came from
+func ResolveConfig() (*Config, Sources, error) {
+	file, err := readConfigFile()
+	if err != nil {
+		return defaultConfig(), nil, err
+	}
+
+	name := file.activeProfile()
+	config, sources, err := file.resolve(name)
+	if err != nil {
+		return defaultConfig(), nil, err
 	}
+	sources["profile"] = file.profileSource()
 
-	// Get home directory
-	homeDir, err := os.UserHomeDir()
+	storeName, store, err := file.credentialStore()
 	if err != nil {
-		return config, fmt.Errorf("error getting home directory: %w", err)
+		return defaultConfig(), nil, err
 	}
+	config.Credentials = storeName
+	switch {
+	case os.Getenv("PROOF_CREDENTIAL_STORE") != "":
+		sources["credential_store"] = "env PROOF_CREDENTIAL_STORE"
+	case file.CredentialStore != "":
+		sources["credential_store"] = "config file " + file.path
+	}
+	// The environment is applied first so the store is only asked for
+	// secrets that are still missing, and a headless run with PROOF_API_KEY
+	// never needs to unlock it
+	if err := applyEnvironment(config, sources); err != nil {
+		return defaultConfig(), nil, err
+	}
+	if store != nil {
+		if err := loadCredentials(store, name, "credential store "+storeName, config, sources); err != nil {
+			return defaultConfig(), nil, err
+		}
+	}
+	applyOverrides(config, sources)
 
-	// Create config directory if it doesn't exist
-	configDir := filepath.Join(homeDir, ".proof-cli")
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(configDir, 0755); err != nil {
-			return config, fmt.Errorf("error creating config directory: %w", err)
+	// The token cache is keyed by the final endpoint, so it is read last
+	if usesTokenCache(config) {
+		token, err := loadCachedToken(config)
+		if err != nil {
+			return defaultConfig(), nil, err
+		}
+		if token != nil {
+			config.OAuthToken = token
 		}
 	}
+	return config, sources, nil
+}
 
-	// Check if config file exists
-	configFile := filepath.Join(configDir, "config.json")
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		// Create default config file
-		configJSON, err := json.MarshalIndent(config, "", "  ")
+// UpdateConfig changes the settings stored for the active profile. update
+// sees only what the profile itself stores, never values from the defaults,
+// the environment or flags, so those are not written to the file.
+func UpdateConfig(update func(config *Config) error) error {
+	return updateConfigFile(func(file *configFile) error {
+		name := file.activeProfile()
+		config, err := file.profile(name)
 		if err != nil {
-			return config, fmt.Errorf("error marshaling config: %w", err)
+			return err
 		}
+		if err := update(config); err != nil {
+			return err
+		}
+		return file.saveProfile(name, config)
+	})
+}
 
-		if err := os.WriteFile(configFile, configJSON, 0600); err != nil {
-			return config, fmt.Errorf("error writing config file: %w", err)
+// SaveConfig stores the settings as given for their profile in the config
+// file. A config without a profile name is saved to the active profile.
+func SaveConfig(config *Config) error {
+	return updateConfigFile(func(file *configFile) error {
+		name := config.Profile
+		if name == "" {
+			name = file.activeProfile()
+		}
+		if _, err := file.profile(name); err != nil {
+			return err
 		}
+		return file.saveProfile(name, config)
+	})
+}
 
-		return config, nil
+// defaultConfig returns the built-in settings
+func defaultConfig() *Config {
+	return &Config{
+		APIEndpoint: "https://api.proof.com",
+		Timeout:     30 * time.Second,
 	}
+}
 
-	// Read config file
-	configData, err := os.ReadFile(configFile)
-	if err != nil {
-		return config, fmt.Errorf("error reading config file: %w", err)
+// defaultSources marks every setting as coming from the built-in defaults
+func defaultSources() Sources {
+	sources := Sources{}
+	for _, name := range SettingNames {
+		sources[name] = "default"
 	}
+	return sources
+}
 
-	// Parse config file
-	if err := json.Unmarshal(configData, config); err != nil {
-		return config, fmt.Errorf("error parsing config file: %w", err)
+// mergeConfig copies the settings src sets onto dst, recording source for
+// each. Credentials and the OAuth token are copied only when credentials is
+// set, so a profile never picks up another profile's credentials.
+func mergeConfig(dst, src *Config, source string, sources Sources, credentials bool) {
+	if src.APIEndpoint != "" {
+		dst.APIEndpoint = src.APIEndpoint
+		sources["api_endpoint"] = source
+	}
+	if src.Timeout > 0 {
+		dst.Timeout = src.Timeout
+		sources["timeout"] = source
+	}
+	if src.OrganizationID != "" {
+		dst.OrganizationID = src.OrganizationID
+		sources["organization_id"] = source
+	}
+	mergeEndpoints(dst, src.Endpoints, source, sources)
+	if src.Retry != nil {
+		if src.Retry.MaxRetries != nil {
+			retries := *src.Retry.MaxRetries
+			dst.retryConfig().MaxRetries = &retries
+			sources["retry.max_retries"] = source
+		}
+		if src.Retry.Wait > 0 {
+			dst.retryConfig().Wait = src.Retry.Wait
+			sources["retry.wait"] = source
+		}
+	}
+	if src.RateLimit != nil {
+		if src.RateLimit.RPS > 0 {
+			dst.rateLimit().RPS = src.RateLimit.RPS
+			sources["rate_limit.rps"] = source
+		}
+		if src.RateLimit.Burst > 0 {
+			dst.rateLimit().Burst = src.RateLimit.Burst
+			sources["rate_limit.burst"] = source
+		}
+		if src.RateLimit.Concurrency > 0 {
+			dst.rateLimit().Concurrency = src.RateLimit.Concurrency
+			sources["rate_limit.concurrency"] = source
+		}
 	}
 
-	return config, nil
+	if !credentials {
+		return
+	}
+	if src.APIKey != "" {
+		dst.APIKey = src.APIKey
+		sources["api_key"] = source
+	}
+	if src.OAuth != nil {
+		oauth := *src.OAuth
+		dst.OAuth = &oauth
+		sources["oauth"] = source
+	}
+	dst.OAuthToken = src.OAuthToken
 }
 
-// SaveConfig saves the configuration to the config file
-func SaveConfig(config *Config) error {
-	// Get home directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("error getting home directory: %w", err)
+// applyEnvironment applies PROOF_ENDPOINT, PROOF_TIMEOUT and PROOF_API_KEY
+func applyEnvironment(config *Config, sources Sources) error {
+	if endpoint := os.Getenv("PROOF_ENDPOINT"); endpoint != "" {
+		config.APIEndpoint = endpoint
+		sources["api_endpoint"] = "env PROOF_ENDPOINT"
 	}
-
-	// Create config directory if it doesn't exist
-	configDir := filepath.Join(homeDir, ".proof-cli")
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(configDir, 0755); err != nil {
-			return fmt.Errorf("error creating config directory: %w", err)
+	if value := os.Getenv("PROOF_TIMEOUT"); value != "" {
+		timeout, err := ParseSeconds(value)
+		if err != nil || timeout <= 0 {
+			return clierr.Input("PROOF_TIMEOUT must be a number of seconds or a duration such as 90s, got %q", value)
 		}
+		config.Timeout = timeout
+		sources["timeout"] = "env PROOF_TIMEOUT"
 	}
+	if apiKey := os.Getenv("PROOF_API_KEY"); apiKey != "" {
+		config.APIKey = apiKey
+		sources["api_key"] = "env PROOF_API_KEY"
+	}
+	return nil
+}
 
-	// Marshal config
-	configJSON, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("error marshaling config: %w", err)
+// applyOverrides applies the settings given as command-line flags
+func applyOverrides(config *Config, sources Sources) {
+	o := flagOverrides
+	if o.APIEndpoint != "" {
+		config.APIEndpoint = o.APIEndpoint
+		sources["api_endpoint"] = "flag --endpoint"
 	}
+	if o.Timeout > 0 {
+		config.Timeout = o.Timeout
+		sources["timeout"] = "flag --timeout"
+	}
+	if o.MaxRetries != nil {
+		retries := *o.MaxRetries
+		config.retryConfig().MaxRetries = &retries
+		sources["retry.max_retries"] = "flag --max-retries"
+	}
+	if o.RetryWait > 0 {
+		config.retryConfig().Wait = o.RetryWait
+		sources["retry.wait"] = "flag --retry-wait"
+	}
+	if o.RPS != nil {
+		config.rateLimit().RPS = *o.RPS
+		sources["rate_limit.rps"] = "flag --rps"
+	}
+	if o.Concurrency != nil {
+		config.rateLimit().Concurrency = *o.Concurrency
+		sources["rate_limit.concurrency"] = "flag --concurrency"
+	}
+}
 
-	// Write config file with restricted permissions since it now contains sensitive data
-	configFile := filepath.Join(configDir, "config.json")
-	if err := os.WriteFile(configFile, configJSON, 0600); err != nil {
-		return fmt.Errorf("error writing config file: %w", err)
+// retryConfig returns the retry settings, allocating them if unset
+func (c *Config) retryConfig() *RetryConfig {
+	if c.Retry == nil {
+		c.Retry = &RetryConfig{}
 	}
+	return c.Retry
+}
 
-	return nil
+// rateLimit returns the rate limits, allocating them if unset
+func (c *Config) rateLimit() *RateLimit {
+	if c.RateLimit == nil {
+		c.RateLimit = &RateLimit{}
+	}
+	return c.RateLimit
 }
 
-// GetAPIKey gets the API key from the environment or config
-func GetAPIKey() (string, error) {
-	// Check environment variable first
-	apiKey := os.Getenv("PROOF_API_KEY")
-	if apiKey != "" {
-		return apiKey, nil
+// ParseSeconds parses a timeout given as a whole number of seconds or as a
+// duration such as 90s or 2m
+func ParseSeconds(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
 	}
+	return time.ParseDuration(value)
+}
 
-	// Load config and get API key
+// GetAPIKey gets the effective API key, from PROOF_API_KEY or the active profile
+func GetAPIKey() (string, error) {
 	config, err := LoadConfig()
 	if err != nil {
 		return "", fmt.Errorf("error loading config: %w", err)
 	}
 
 	if config.APIKey == "" {
-		return "", fmt.Errorf("API key not found. Set PROOF_API_KEY environment variable or run 'proof config set-api-key'")
+		return "", clierr.New(clierr.KindAuth, "API key not found. Set PROOF_API_KEY environment variable or run 'proof config set-api-key'")
 	}
 
 	return config.APIKey, nil
 }
 
-// SaveAPIKey saves the API key to the config
+// SaveAPIKey saves the API key to the active profile
 func SaveAPIKey(apiKey string) error {
-	// Load current config
-	config, err := LoadConfig()
+	err := UpdateConfig(func(config *Config) error {
+		config.APIKey = apiKey
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error loading config: %w", err)
-	}
-
-	// Update API key
-	config.APIKey = apiKey
-
-	// Save config
-	if err := SaveConfig(config); err != nil {
 		return fmt.Errorf("error saving config: %w", err)
 	}
-
 	return nil
 }
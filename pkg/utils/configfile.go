@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileOverride is the config file chosen with --config
+var configFileOverride string
+
+// SetConfigFile makes path the config file for this process. An empty path
+// leaves the choice to PROOF_CONFIG and the default location.
+func SetConfigFile(path string) {
+	configFileOverride = path
+}
+
+// ConfigFilePath returns the config file in use: --config, then PROOF_CONFIG,
+// then ~/.proof-cli/config.json. Only the default file is created on demand;
+// a file named explicitly must already exist.
+func ConfigFilePath() (path string, explicit bool, err error) {
+	if configFileOverride != "" {
+		return configFileOverride, true, nil
+	}
+	if path := os.Getenv("PROOF_CONFIG"); path != "" {
+		return path, true, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", false, fmt.Errorf("error getting home directory: %w", err)
+	}
+
+	configDir := filepath.Join(homeDir, ".proof-cli")
+	if _, err := os.Stat(configDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(configDir, 0755); err != nil {
+			return "", false, fmt.Errorf("error creating config directory: %w", err)
+		}
+	}
+	return filepath.Join(configDir, "config.json"), false, nil
+}
+
+// readConfigFile reads the config file, creating the default one with the
+// default settings if it does not exist
+func readConfigFile() (*configFile, error) {
+	path, explicit, err := ConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	configData, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		file := &configFile{Config: *defaultConfig(), path: path}
+		if err := writeConfigFile(file); err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	if os.IsNotExist(err) {
+		return nil, clierr.Input("config file %s not found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	if isYAML(path) {
+		if configData, err = yamlToJSON(configData); err != nil {
+			return nil, clierr.Wrap(clierr.KindInput, err, "error parsing config file "+path)
+		}
+	}
+	var file configFile
+	if err := json.Unmarshal(configData, &file); err != nil {
+		return nil, clierr.Wrap(clierr.KindInput, err, "error parsing config file "+path)
+	}
+	file.path = path
+	return &file, nil
+}
+
+// writeConfigFile atomically replaces the config file, with permissions
+// restricted to the user since it may hold credentials. YAML files are
+// rewritten without comments.
+func writeConfigFile(file *configFile) error {
+	path := file.path
+	if path == "" {
+		var err error
+		if path, _, err = ConfigFilePath(); err != nil {
+			return err
+		}
+	}
+
+	configData, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling config: %w", err)
+	}
+	if isYAML(path) {
+		if configData, err = jsonToYAML(configData); err != nil {
+			return fmt.Errorf("error marshaling config: %w", err)
+		}
+	}
+	if err := writeFileAtomic(path, configData, 0600); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	return nil
+}
+
+// updateConfigFile reads the config file, applies update and writes it back
+// while holding the config file lock, so concurrent processes do not lose
+// each other's changes
+func updateConfigFile(update func(file *configFile) error) error {
+	path, _, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+	return withFileLock(path, func() error {
+		file, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		if err := update(file); err != nil {
+			return err
+		}
+		return writeConfigFile(file)
+	})
+}
+
+// isYAML reports whether a config file is YAML, judging by its extension
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// durationSettings are stored as nanoseconds in JSON. In YAML they are written
+// as durations such as 30s, and a bare number means seconds.
+var durationSettings = []string{"timeout", "retry.wait"}
+
+// yamlToJSON converts a YAML config file to the JSON layout
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	err := eachSettings(doc, func(settings map[string]any) error {
+		for _, name := range durationSettings {
+			parent, key := settingParent(settings, name)
+			value, ok := parent[key]
+			if !ok {
+				continue
+			}
+			d, err := yamlDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			parent[key] = int64(d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// jsonToYAML converts the JSON layout to a YAML config file
+func jsonToYAML(data []byte) ([]byte, error) {
+	var doc map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	_ = eachSettings(doc, func(settings map[string]any) error {
+		for _, name := range durationSettings {
+			parent, key := settingParent(settings, name)
+			if n, ok := parent[key].(json.Number); ok {
+				if ns, err := n.Int64(); err == nil {
+					parent[key] = time.Duration(ns).String()
+				}
+			}
+		}
+		return nil
+	})
+	return yaml.Marshal(plainNumbers(doc))
+}
+
+// eachSettings calls fn for the top-level settings and for each profile
+func eachSettings(doc map[string]any, fn func(settings map[string]any) error) error {
+	if err := fn(doc); err != nil {
+		return err
+	}
+	profiles, _ := doc["profiles"].(map[string]any)
+	for name, profile := range profiles {
+		if settings, ok := profile.(map[string]any); ok {
+			if err := fn(settings); err != nil {
+				return fmt.Errorf("profile %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// settingParent returns the map holding a dotted setting and its last key
+func settingParent(settings map[string]any, name string) (map[string]any, string) {
+	parent := settings
+	parts := strings.Split(name, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := parent[part].(map[string]any)
+		if !ok {
+			return map[string]any{}, parts[len(parts)-1]
+		}
+		parent = child
+	}
+	return parent, parts[len(parts)-1]
+}
+
+// yamlDuration converts a YAML duration setting: a number of seconds or a duration string
+func yamlDuration(value any) (time.Duration, error) {
+	switch v := value.(type) {
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case string:
+		return ParseSeconds(v)
+	default:
+		return 0, fmt.Errorf("expected seconds or a duration such as 30s, got %v", value)
+	}
+}
+
+// plainNumbers replaces json.Number values with ints or floats so YAML writes them unquoted
+func plainNumbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = plainNumbers(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = plainNumbers(item)
+		}
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
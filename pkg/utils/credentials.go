@@ -0,0 +1,627 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+)
+
+// ErrCredentialNotFound is returned by a CredentialStore that holds no secret under a key
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// CredentialStore keeps the API key, OAuth client secret and OAuth token out
+// of the config file. Keys have the form "<profile>/<credential>", such as
+// "default/api_key".
+type CredentialStore interface {
+	// Get returns the secret stored under key, or ErrCredentialNotFound
+	Get(key string) (string, error)
+	// Set stores a secret under key, replacing any previous one
+	Set(key, value string) error
+	// Delete removes the secret stored under key. Deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+// Credential store names, set with credential_store or PROOF_CREDENTIAL_STORE
+const (
+	// StoreConfig keeps secrets in the config file in plain text
+	StoreConfig = "config"
+	// StoreFile keeps secrets in a file encrypted with a passphrase
+	StoreFile = "file"
+	// StoreHelper delegates to an external command, like git credential helpers
+	StoreHelper = "helper"
+	// StoreEnv reads secrets from environment variables and stores nothing
+	StoreEnv = "env"
+)
+
+// StoreNames lists the credential stores
+var StoreNames = []string{StoreConfig, StoreFile, StoreHelper, StoreEnv}
+
+// Credentials held by a store for each profile
+const (
+	credentialAPIKey       = "api_key"
+	credentialClientSecret = "oauth_client_secret"
+	credentialOAuthToken   = "oauth_token"
+)
+
+// credentialKey returns the store key of a profile's credential
+func credentialKey(profile, credential string) string {
+	return profile + "/" + credential
+}
+
+// credentialName returns the credential part of a store key
+func credentialName(key string) string {
+	return key[strings.LastIndex(key, "/")+1:]
+}
+
+// credentialStores caches the stores opened by this process, so the
+// passphrase of the encrypted file is stretched once and tokens kept in
+// memory by the env store survive reloading the config
+var (
+	credentialStoresMu sync.Mutex
+	credentialStores   = map[string]CredentialStore{}
+)
+
+// NewCredentialStore opens the named credential store. It returns nil for the
+// config store, whose secrets stay in the config file. helper is the command
+// run by the helper store.
+func NewCredentialStore(name, helper string) (CredentialStore, error) {
+	credentialStoresMu.Lock()
+	defer credentialStoresMu.Unlock()
+
+	var cacheKey string
+	switch name {
+	case "", StoreConfig:
+		return nil, nil
+	case StoreFile:
+		path, err := credentialFilePath()
+		if err != nil {
+			return nil, err
+		}
+		cacheKey = name + "\x00" + path
+		if store, ok := credentialStores[cacheKey]; ok {
+			return store, nil
+		}
+		credentialStores[cacheKey] = &FileStore{Path: path, Passphrase: os.Getenv("PROOF_CREDENTIAL_PASSPHRASE")}
+	case StoreHelper:
+		if strings.TrimSpace(helper) == "" {
+			return nil, clierr.Input("the helper credential store needs a command: set credential_helper or PROOF_CREDENTIAL_HELPER")
+		}
+		cacheKey = name + "\x00" + helper
+		if store, ok := credentialStores[cacheKey]; ok {
+			return store, nil
+		}
+		credentialStores[cacheKey] = &HelperStore{Command: helper}
+	case StoreEnv:
+		cacheKey = name
+		if store, ok := credentialStores[cacheKey]; ok {
+			return store, nil
+		}
+		credentialStores[cacheKey] = &EnvStore{}
+	default:
+		return nil, clierr.Input("unknown credential store %q: use one of %s", name, strings.Join(StoreNames, ", "))
+	}
+	return credentialStores[cacheKey], nil
+}
+
+// credentialStore returns the name of the store in use, from
+// PROOF_CREDENTIAL_STORE or the config file, and opens it
+func (f *configFile) credentialStore() (string, CredentialStore, error) {
+	name, helper := f.CredentialStore, f.CredentialHelper
+	if env := os.Getenv("PROOF_CREDENTIAL_STORE"); env != "" {
+		name = env
+	}
+	if env := os.Getenv("PROOF_CREDENTIAL_HELPER"); env != "" {
+		helper = env
+	}
+	if name == "" {
+		name = StoreConfig
+	}
+	store, err := NewCredentialStore(name, helper)
+	return name, store, err
+}
+
+// loadCredentials fills in the profile's credentials from the store. Values
+// already present, from the config file or the environment, are kept, and the
+// OAuth token is only looked up for a profile that uses OAuth.
+func loadCredentials(store CredentialStore, name, source string, config *Config, sources Sources) error {
+	get := func(credential string) (string, error) {
+		value, err := store.Get(credentialKey(name, credential))
+		if errors.Is(err, ErrCredentialNotFound) {
+			return "", nil
+		}
+		return value, err
+	}
+
+	if config.APIKey == "" {
+		apiKey, err := get(credentialAPIKey)
+		if err != nil {
+			return err
+		}
+		if apiKey != "" {
+			config.APIKey = apiKey
+			sources["api_key"] = source
+		}
+	}
+	if config.OAuth != nil && config.OAuth.ClientSecret == "" {
+		secret, err := get(credentialClientSecret)
+		if err != nil {
+			return err
+		}
+		config.OAuth.ClientSecret = secret
+	}
+	if config.OAuth != nil && config.OAuthToken == nil {
+		data, err := get(credentialOAuthToken)
+		if err != nil {
+			return err
+		}
+		if data != "" {
+			var token OAuthToken
+			if err := json.Unmarshal([]byte(data), &token); err != nil {
+				return fmt.Errorf("error parsing stored OAuth token: %w", err)
+			}
+			config.OAuthToken = &token
+		}
+	}
+	return nil
+}
+
+// storeCredentials moves the credentials set on a profile's stored settings
+// into the store, leaving only the non-secret settings for the config file
+func storeCredentials(store CredentialStore, name string, config *Config) error {
+	if config.APIKey != "" {
+		if err := store.Set(credentialKey(name, credentialAPIKey), config.APIKey); err != nil {
+			return err
+		}
+		config.APIKey = ""
+	}
+	if config.OAuth != nil && config.OAuth.ClientSecret != "" {
+		if err := store.Set(credentialKey(name, credentialClientSecret), config.OAuth.ClientSecret); err != nil {
+			return err
+		}
+		oauth := *config.OAuth
+		oauth.ClientSecret = ""
+		config.OAuth = &oauth
+	}
+	if config.OAuthToken != nil {
+		data, err := json.Marshal(config.OAuthToken)
+		if err != nil {
+			return fmt.Errorf("error marshaling OAuth token: %w", err)
+		}
+		if err := store.Set(credentialKey(name, credentialOAuthToken), string(data)); err != nil {
+			return err
+		}
+		config.OAuthToken = nil
+	}
+	return nil
+}
+
+// deleteCredentials removes a profile's credentials from the store
+func deleteCredentials(store CredentialStore, name string) error {
+	for _, credential := range []string{credentialAPIKey, credentialClientSecret, credentialOAuthToken} {
+		if err := store.Delete(credentialKey(name, credential)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateCredentials moves the credentials of every profile from the config
+// file, or the store currently in use, into the named store and makes it the
+// store recorded in the config file. It returns the number of secrets moved.
+func MigrateCredentials(name, helper string) (int, error) {
+	if name == StoreEnv {
+		return 0, clierr.Input("the env credential store cannot hold secrets: set PROOF_CREDENTIAL_STORE=env and provide PROOF_API_KEY or PROOF_OAUTH_CLIENT_SECRET instead")
+	}
+	target, err := NewCredentialStore(name, helper)
+	if err != nil {
+		return 0, err
+	}
+
+	var current CredentialStore
+	var names []string
+	moved := 0
+	err = updateConfigFile(func(file *configFile) error {
+		var err error
+		current, err = NewCredentialStore(file.CredentialStore, file.CredentialHelper)
+		if err != nil {
+			return err
+		}
+
+		names = []string{DefaultProfile}
+		for profile := range file.Profiles {
+			names = append(names, profile)
+		}
+		for _, profile := range names {
+			config, err := file.profile(profile)
+			if err != nil {
+				return err
+			}
+			if current != nil {
+				if err := loadCredentials(current, profile, "", config, Sources{}); err != nil {
+					return err
+				}
+			}
+			moved += countCredentials(config)
+			if target != nil && target != current {
+				if err := storeCredentials(target, profile, config); err != nil {
+					return err
+				}
+			}
+			file.setProfile(profile, config)
+		}
+
+		file.CredentialStore, file.CredentialHelper = name, ""
+		if name == StoreConfig {
+			file.CredentialStore = ""
+		}
+		if name == StoreHelper {
+			file.CredentialHelper = helper
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Only drop the old copies once the config file points at the new store
+	if current != nil && current != target {
+		for _, profile := range names {
+			if err := deleteCredentials(current, profile); err != nil {
+				return moved, err
+			}
+		}
+	}
+	return moved, nil
+}
+
+// countCredentials returns how many secrets a profile's settings hold
+func countCredentials(config *Config) int {
+	count := 0
+	if config.APIKey != "" {
+		count++
+	}
+	if config.OAuth != nil && config.OAuth.ClientSecret != "" {
+		count++
+	}
+	if config.OAuthToken != nil {
+		count++
+	}
+	return count
+}
+
+// credentialFilePath returns the location of the encrypted credential file
+func credentialFilePath() (string, error) {
+	if path := os.Getenv("PROOF_CREDENTIAL_FILE"); path != "" {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".proof-cli", "credentials.enc"), nil
+}
+
+// fileStoreIterations is the PBKDF2 iteration count for new credential files
+var fileStoreIterations = 600000
+
+// encryptedFile is the layout of the encrypted credential file. The secrets
+// are a JSON object of keys to values, sealed with AES-256-GCM under a key
+// derived from the passphrase with PBKDF2-SHA256.
+type encryptedFile struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// FileStore is a CredentialStore kept in a file encrypted with a passphrase,
+// so it works without a desktop keyring
+type FileStore struct {
+	Path       string
+	Passphrase string
+
+	mu sync.Mutex
+	// salt, key and rounds describe the last derived key, reused while the salt is unchanged
+	salt   []byte
+	key    []byte
+	rounds int
+}
+
+// Get implements CredentialStore
+func (s *FileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[key]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return value, nil
+}
+
+// Set implements CredentialStore
+func (s *FileStore) Set(key, value string) error {
+	return s.update(func(secrets map[string]string) bool {
+		secrets[key] = value
+		return true
+	})
+}
+
+// Delete implements CredentialStore
+func (s *FileStore) Delete(key string) error {
+	return s.update(func(secrets map[string]string) bool {
+		if _, ok := secrets[key]; !ok {
+			return false
+		}
+		delete(secrets, key)
+		return true
+	})
+}
+
+// update rewrites the credential file if change reports a change, holding
+// the file's lock so concurrent processes do not lose each other's secrets
+func (s *FileStore) update(change func(secrets map[string]string) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkPassphrase(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("error creating credential directory: %w", err)
+	}
+	return withFileLock(s.Path, func() error {
+		secrets, err := s.read()
+		if err != nil {
+			return err
+		}
+		if !change(secrets) {
+			return nil
+		}
+		return s.write(secrets)
+	})
+}
+
+// read decrypts the credential file. A missing file holds no secrets, so it
+// is read without a passphrase.
+func (s *FileStore) read() (map[string]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading credential file: %w", err)
+	}
+	if err := s.checkPassphrase(); err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, clierr.Wrap(clierr.KindInput, err, "error parsing credential file "+s.Path)
+	}
+	if file.KDF != "pbkdf2-sha256" {
+		return nil, clierr.Input("credential file %s uses unsupported key derivation %q", s.Path, file.KDF)
+	}
+	key, err := s.deriveKey(file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, clierr.New(clierr.KindAuth, "cannot decrypt credential file %s: wrong PROOF_CREDENTIAL_PASSPHRASE or corrupted file", s.Path)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("error parsing decrypted credentials: %w", err)
+	}
+	return secrets, nil
+}
+
+// write encrypts the secrets to the credential file with a fresh nonce
+func (s *FileStore) write(secrets map[string]string) error {
+	if s.key == nil {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("error generating salt: %w", err)
+		}
+		if _, err := s.deriveKey(salt, fileStoreIterations); err != nil {
+			return err
+		}
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("error generating nonce: %w", err)
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("error marshaling credentials: %w", err)
+	}
+
+	data, err := json.MarshalIndent(encryptedFile{
+		KDF:        "pbkdf2-sha256",
+		Iterations: s.rounds,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling credential file: %w", err)
+	}
+	if err := writeFileAtomic(s.Path, data, 0600); err != nil {
+		return fmt.Errorf("error writing credential file: %w", err)
+	}
+	return nil
+}
+
+// checkPassphrase reports a missing passphrase as an input error
+func (s *FileStore) checkPassphrase() error {
+	if s.Passphrase == "" {
+		return clierr.Input("the file credential store needs a passphrase: set PROOF_CREDENTIAL_PASSPHRASE")
+	}
+	return nil
+}
+
+// deriveKey stretches the passphrase with salt, reusing the last key when the
+// salt has not changed
+func (s *FileStore) deriveKey(salt []byte, iterations int) ([]byte, error) {
+	if s.key != nil && bytes.Equal(s.salt, salt) {
+		return s.key, nil
+	}
+	if iterations <= 0 {
+		return nil, clierr.Input("credential file %s has an invalid iteration count", s.Path)
+	}
+	key, err := pbkdf2.Key(sha256.New, s.Passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving credential key: %w", err)
+	}
+	s.salt, s.key, s.rounds = salt, key, iterations
+	return key, nil
+}
+
+// newGCM returns an AES-GCM cipher for key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// HelperStore is a CredentialStore backed by an external command, in the
+// spirit of git credential helpers. The command is run with an action and the
+// key as arguments:
+//
+//	<command> get <key>     prints the secret, or nothing if there is none
+//	<command> store <key>   reads the secret from standard input
+//	<command> erase <key>   removes the secret
+type HelperStore struct {
+	Command string
+}
+
+// Get implements CredentialStore
+func (s *HelperStore) Get(key string) (string, error) {
+	out, err := s.run("get", key, "")
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimRight(out, "\r\n")
+	if value == "" {
+		return "", ErrCredentialNotFound
+	}
+	return value, nil
+}
+
+// Set implements CredentialStore
+func (s *HelperStore) Set(key, value string) error {
+	_, err := s.run("store", key, value)
+	return err
+}
+
+// Delete implements CredentialStore
+func (s *HelperStore) Delete(key string) error {
+	_, err := s.run("erase", key, "")
+	return err
+}
+
+// run invokes the helper command. Secrets travel on standard input and output
+// only, never in arguments, so they do not show up in process listings.
+func (s *HelperStore) run(action, key, input string) (string, error) {
+	args := strings.Fields(s.Command)
+	cmd := exec.Command(args[0], append(args[1:], action, key)...)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("credential helper %q %s failed: %s", args[0], action, message)
+	}
+	return stdout.String(), nil
+}
+
+// EnvStore is a CredentialStore that reads the API key and OAuth client
+// secret from PROOF_API_KEY and PROOF_OAUTH_CLIENT_SECRET, for CI systems
+// that inject secrets into the environment. Nothing is written to disk; OAuth
+// tokens are kept in memory for the life of the process.
+type EnvStore struct {
+	mu     sync.Mutex
+	memory map[string]string
+}
+
+// envCredentials maps credentials to the variables the env store reads
+var envCredentials = map[string]string{
+	credentialAPIKey:       "PROOF_API_KEY",
+	credentialClientSecret: "PROOF_OAUTH_CLIENT_SECRET",
+}
+
+// Get implements CredentialStore
+func (s *EnvStore) Get(key string) (string, error) {
+	if variable, ok := envCredentials[credentialName(key)]; ok {
+		if value := os.Getenv(variable); value != "" {
+			return value, nil
+		}
+		return "", ErrCredentialNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.memory[key]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return value, nil
+}
+
+// Set implements CredentialStore
+func (s *EnvStore) Set(key, value string) error {
+	if variable, ok := envCredentials[credentialName(key)]; ok {
+		return clierr.Input("the env credential store is read-only: set %s instead", variable)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.memory == nil {
+		s.memory = map[string]string{}
+	}
+	s.memory[key] = value
+	return nil
+}
+
+// Delete implements CredentialStore
+func (s *EnvStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.memory, key)
+	return nil
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DryRunHeader marks the empty response returned for a request that was
+// printed instead of sent
+const DryRunHeader = "X-Proof-Dry-Run"
+
+// Credential placeholders shown instead of real credentials in dry runs
+const (
+	APIKeyPlaceholder      = "$PROOF_API_KEY"
+	AccessTokenPlaceholder = "$PROOF_ACCESS_TOKEN"
+)
+
+// DryRunTransport is an http.RoundTripper that prints each request instead of
+// sending it, either as a readable description or as a curl command. Each
+// request is answered with an empty 204 response marked with DryRunHeader, so
+// commands that send several requests print all of them.
+type DryRunTransport struct {
+	Out  io.Writer
+	Curl bool
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *DryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := peekRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	if t.Curl {
+		_, err = fmt.Fprintln(t.Out, CurlCommand(req, body))
+	} else {
+		_, err = fmt.Fprint(t.Out, describeRequest(req, body))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:     "204 No Content",
+		StatusCode: http.StatusNoContent,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{DryRunHeader: []string{"true"}},
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+// describeRequest renders a request as its method and URL, headers and body
+func describeRequest(req *http.Request, body []byte) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s %s\n", req.Method, req.URL)
+	for _, name := range sortedHeaderNames(req.Header) {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(&buf, "%s: %s\n", name, value)
+		}
+	}
+	if len(body) > 0 {
+		buf.WriteByte('\n')
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") == nil {
+			buf.Write(pretty.Bytes())
+		} else if utf8.Valid(body) {
+			buf.Write(body)
+		} else {
+			fmt.Fprintf(&buf, "[binary body, %d bytes]", len(body))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// CurlCommand renders a request as a curl command. Credential placeholders are
+// double-quoted so the shell expands them; everything else is single-quoted.
+func CurlCommand(req *http.Request, body []byte) string {
+	lines := []string{fmt.Sprintf("curl -X %s %s", req.Method, shellQuote(req.URL.String()))}
+	for _, name := range sortedHeaderNames(req.Header) {
+		for _, value := range req.Header[name] {
+			header := name + ": " + value
+			if strings.Contains(value, APIKeyPlaceholder) || strings.Contains(value, AccessTokenPlaceholder) {
+				lines = append(lines, `-H "`+header+`"`)
+			} else {
+				lines = append(lines, "-H "+shellQuote(header))
+			}
+		}
+	}
+	if len(body) > 0 {
+		if utf8.Valid(body) {
+			lines = append(lines, "--data-raw "+shellQuote(string(body)))
+		} else {
+			lines = append(lines, "--data-binary @request-body.bin")
+		}
+	}
+	return strings.Join(lines, " \\\n  ")
+}
+
+// shellQuote single-quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// sortedHeaderNames returns the header names in a stable order
+func sortedHeaderNames(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
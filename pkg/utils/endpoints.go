@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+)
+
+// API names, used as keys under endpoints in the config file
+const (
+	APIBusiness     = "business"
+	APIRealEstate   = "real_estate"
+	APISCIM         = "scim"
+	APILogs         = "logs"
+	APICertificates = "certificates"
+)
+
+// APINames lists the APIs with their own base URL setting, in display order
+var APINames = []string{APIBusiness, APIRealEstate, APISCIM, APILogs, APICertificates}
+
+// Every API is served from api_endpoint, whose default is the production host;
+// a profile reaches the sandbox or staging hosts listed in the specifications
+// by setting api_endpoint to them. Credentials are issued per host, so no API
+// falls back to a host other than api_endpoint.
+const (
+	// scimPath is appended to api_endpoint to form the SCIM base URL
+	scimPath = "/scim/v1/organizations"
+)
+
+// Endpoints overrides the base URL of individual APIs. Empty fields use the
+// default for the API.
+type Endpoints struct {
+	Business     string `json:"business,omitempty"`
+	RealEstate   string `json:"real_estate,omitempty"`
+	SCIM         string `json:"scim,omitempty"`
+	Logs         string `json:"logs,omitempty"`
+	Certificates string `json:"certificates,omitempty"`
+}
+
+// field returns a pointer to the setting for api, or nil for an unknown API
+func (e *Endpoints) field(api string) *string {
+	switch api {
+	case APIBusiness:
+		return &e.Business
+	case APIRealEstate:
+		return &e.RealEstate
+	case APISCIM:
+		return &e.SCIM
+	case APILogs:
+		return &e.Logs
+	case APICertificates:
+		return &e.Certificates
+	default:
+		return nil
+	}
+}
+
+// Endpoint returns the base URL for api: endpoints.<api> when set, otherwise
+// the default derived from api_endpoint
+func (c *Config) Endpoint(api string) string {
+	if c.Endpoints != nil {
+		if value := c.Endpoints.field(api); value != nil && *value != "" {
+			return *value
+		}
+	}
+
+	base := strings.TrimRight(c.APIEndpoint, "/")
+	switch api {
+	case APISCIM:
+		return base + scimPath
+	default:
+		return base
+	}
+}
+
+// SetEndpoint sets the base URL for api. An empty url restores the default.
+func (c *Config) SetEndpoint(api, url string) error {
+	if c.Endpoints == nil {
+		c.Endpoints = &Endpoints{}
+	}
+	value := c.Endpoints.field(api)
+	if value == nil {
+		return clierr.Input("unknown API %q: use one of %s", api, strings.Join(APINames, ", "))
+	}
+	*value = url
+	if *c.Endpoints == (Endpoints{}) {
+		c.Endpoints = nil
+	}
+	return nil
+}
+
+// EndpointSource reports where the base URL for api came from: its own
+// setting, or the setting it is derived from
+func (s Sources) EndpointSource(api string) string {
+	if source := s["endpoints."+api]; source != "" && source != "default" {
+		return source
+	}
+	return s["api_endpoint"]
+}
+
+// mergeEndpoints copies the base URLs src sets onto dst, recording source for each
+func mergeEndpoints(dst *Config, src *Endpoints, source string, sources Sources) {
+	if src == nil {
+		return
+	}
+	for _, api := range APINames {
+		value := *src.field(api)
+		if value == "" {
+			continue
+		}
+		if dst.Endpoints == nil {
+			dst.Endpoints = &Endpoints{}
+		}
+		*dst.Endpoints.field(api) = value
+		sources["endpoints."+api] = source
+	}
+}
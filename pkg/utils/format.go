@@ -0,0 +1,436 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by FormatOutput
+const (
+	FormatJSON   = "json"
+	FormatYAML   = "yaml"
+	FormatTable  = "table"
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// OutputFormats lists every format accepted by FormatOutput
+var OutputFormats = []string{FormatJSON, FormatYAML, FormatTable, FormatCSV, FormatNDJSON}
+
+// listKeys are the fields list endpoints use to wrap their results, in order
+// of preference. Only these are unwrapped: a single record such as a
+// transaction also holds arrays, like its documents and signers.
+var listKeys = []string{"data", "Resources", "items", "results", "records", "templates", "eligible_title_underwriters"}
+
+// FormatOutput formats data in the given format. data may be a raw JSON
+// document ([]byte or json.RawMessage) or any value that marshals to JSON.
+// columns selects and orders the table columns using dotted paths; when empty
+// every scalar field is shown. CSV output always flattens every field.
+func FormatOutput(data any, format string, columns ...string) (string, error) {
+	value, err := normalizeJSON(data)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case FormatJSON:
+		out, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error formatting JSON: %w", err)
+		}
+		return string(out), nil
+	case FormatYAML:
+		out, err := yaml.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("error formatting YAML: %w", err)
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	case FormatNDJSON:
+		var buf strings.Builder
+		for i, record := range Records(value) {
+			line, err := json.Marshal(record)
+			if err != nil {
+				return "", fmt.Errorf("error formatting NDJSON: %w", err)
+			}
+			if i > 0 {
+				buf.WriteByte('\n')
+			}
+			buf.Write(line)
+		}
+		return buf.String(), nil
+	case FormatTable:
+		records := Records(value)
+		if len(columns) == 0 {
+			columns = flattenedColumns(records)
+		}
+		return formatTable(records, columns), nil
+	case FormatCSV:
+		records := Records(value)
+		return formatCSV(records, flattenedColumns(records))
+	default:
+		return "", fmt.Errorf("unsupported output format %q (supported: %s)", format, strings.Join(OutputFormats, ", "))
+	}
+}
+
+// Records returns the list of items contained in a response body. Top-level
+// arrays are returned as-is, list envelopes such as {"data": [...]} or SCIM's
+// {"Resources": [...]} are unwrapped, and any other value is a single record.
+func Records(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	case map[string]any:
+		if items, ok := listField(v); ok {
+			return items
+		}
+	}
+	return []any{value}
+}
+
+// listField returns the array wrapped by a list envelope, if obj is one
+func listField(obj map[string]any) ([]any, bool) {
+	for _, key := range listKeys {
+		if items, ok := obj[key].([]any); ok {
+			return items, true
+		}
+	}
+	return nil, false
+}
+
+// LookupPath resolves a dotted path such as "signer_info.email" inside a decoded JSON value
+func LookupPath(value any, path string) (any, bool) {
+	current := value
+	for _, part := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []any:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			current = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// normalizeJSON converts data into the generic representation produced by encoding/json
+func normalizeJSON(data any) (any, error) {
+	var raw []byte
+	switch v := data.(type) {
+	case []byte:
+		raw = v
+	case json.RawMessage:
+		raw = v
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding output: %w", err)
+		}
+		raw = encoded
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("error parsing JSON output: %w", err)
+	}
+	return convertNumbers(value), nil
+}
+
+// convertNumbers replaces json.Number values with int64 or float64 so integers
+// such as epoch timestamps are not rendered in exponent notation
+func convertNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, item := range v {
+			v[k] = convertNumbers(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = convertNumbers(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// flatten collects the scalar leaves of a record keyed by their dotted path.
+// Arrays are kept whole and rendered as compact JSON.
+func flatten(prefix string, value any, out map[string]any) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		key := prefix
+		if key == "" {
+			key = "value"
+		}
+		out[key] = value
+		return
+	}
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+// flattenedColumns returns the sorted union of flattened field names across records
+func flattenedColumns(records []any) []string {
+	seen := map[string]bool{}
+	for _, record := range records {
+		fields := map[string]any{}
+		flatten("", record, fields)
+		for k := range fields {
+			seen[k] = true
+		}
+	}
+
+	columns := make([]string, 0, len(seen))
+	for k := range seen {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// cellValue renders the value at column for a single record
+func cellValue(record any, column string) string {
+	value, ok := LookupPath(record, column)
+	if !ok && column == "value" {
+		value, ok = record, true
+	}
+	if !ok || value == nil {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+// formatTable renders records as aligned columns with an upper-case header row
+func formatTable(records []any, columns []string) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	writeTableRows(w, records, columns, true)
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// writeTableRows writes records as tab-separated rows for a tabwriter, after
+// a header row of upper-cased column names when header is set
+func writeTableRows(w io.Writer, records []any, columns []string, header bool) {
+	if header {
+		headers := make([]string, len(columns))
+		for i, column := range columns {
+			headers[i] = strings.ToUpper(column)
+		}
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+	}
+
+	for _, record := range records {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			// Keep each record on a single line
+			cells[i] = strings.ReplaceAll(cellValue(record, column), "\n", " ")
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+}
+
+// formatCSV renders records as CSV with a header row of dotted column names
+func formatCSV(records []any, columns []string) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(columns); err != nil {
+		return "", fmt.Errorf("error writing CSV: %w", err)
+	}
+	for _, record := range records {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = cellValue(record, column)
+		}
+		if err := w.Write(row); err != nil {
+			return "", fmt.Errorf("error writing CSV: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("error writing CSV: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// RecordWriter writes records in an output format as they arrive, so long
+// listings can be printed page by page without holding every result in memory.
+// Table rows are the exception: they are aligned across every batch, so they
+// are held until Close.
+type RecordWriter struct {
+	w       io.Writer
+	format  string
+	columns []string
+	// Colorize, when set, is applied to each pretty-printed JSON record
+	Colorize func(string) string
+
+	started bool
+	table   *tabwriter.Writer
+}
+
+// NewRecordWriter returns a RecordWriter for format. columns selects the table
+// and CSV columns; when empty they are taken from the first batch of records,
+// and fields that only appear in later batches are left out.
+func NewRecordWriter(w io.Writer, format string, columns ...string) (*RecordWriter, error) {
+	if !slices.Contains(OutputFormats, format) {
+		return nil, fmt.Errorf("unsupported output format %q (supported: %s)", format, strings.Join(OutputFormats, ", "))
+	}
+	return &RecordWriter{w: w, format: format, columns: columns}, nil
+}
+
+// Write outputs a batch of records
+func (rw *RecordWriter) Write(records []any) error {
+	if len(records) == 0 {
+		return nil
+	}
+	normalized := make([]any, len(records))
+	for i, record := range records {
+		value, err := normalizeJSON(record)
+		if err != nil {
+			return err
+		}
+		normalized[i] = value
+	}
+	records = normalized
+
+	first := !rw.started
+	rw.started = true
+
+	switch rw.format {
+	case FormatJSON:
+		for i, record := range records {
+			out, err := json.MarshalIndent(record, "  ", "  ")
+			if err != nil {
+				return fmt.Errorf("error formatting JSON: %w", err)
+			}
+			text := string(out)
+			if rw.Colorize != nil {
+				text = rw.Colorize(text)
+			}
+			sep := ",\n  "
+			if first && i == 0 {
+				sep = "[\n  "
+			}
+			if _, err := io.WriteString(rw.w, sep+text); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatNDJSON:
+		for _, record := range records {
+			line, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("error formatting NDJSON: %w", err)
+			}
+			if _, err := fmt.Fprintln(rw.w, string(line)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatYAML:
+		out, err := yaml.Marshal(records)
+		if err != nil {
+			return fmt.Errorf("error formatting YAML: %w", err)
+		}
+		_, err = rw.w.Write(out)
+		return err
+	case FormatTable:
+		if len(rw.columns) == 0 {
+			rw.columns = flattenedColumns(records)
+		}
+		if rw.table == nil {
+			rw.table = tabwriter.NewWriter(rw.w, 0, 0, 2, ' ', 0)
+		}
+		// Only the first batch carries the header row
+		writeTableRows(rw.table, records, rw.columns, first)
+		return nil
+	default:
+		if len(rw.columns) == 0 {
+			rw.columns = flattenedColumns(records)
+		}
+		text, err := formatCSV(records, rw.columns)
+		if err != nil {
+			return err
+		}
+		if !first {
+			_, text, _ = strings.Cut(text, "\n")
+		}
+		_, err = fmt.Fprintln(rw.w, text)
+		return err
+	}
+}
+
+// Close finishes the output, writing anything that closes the document
+func (rw *RecordWriter) Close() error {
+	switch {
+	case rw.format == FormatJSON && rw.started:
+		_, err := io.WriteString(rw.w, "\n]\n")
+		return err
+	case rw.format == FormatJSON:
+		_, err := io.WriteString(rw.w, "[]\n")
+		return err
+	case rw.format == FormatYAML && !rw.started:
+		_, err := io.WriteString(rw.w, "[]\n")
+		return err
+	case rw.table != nil:
+		return rw.table.Flush()
+	}
+	return nil
+}
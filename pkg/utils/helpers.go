@@ -11,12 +11,6 @@ import (
 // Helper functions for the CLI application
 // Add your utility functions here
 
-// FormatOutput formats the output based on the given format
-func FormatOutput(data any, format string) (string, error) {
-	// Implement formatting logic based on your needs
-	return "", nil
-}
-
 // BuildQueryParams converts a struct to URL query parameters using reflection
 func BuildQueryParams(params any) url.Values {
 	queryParams := url.Values{}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// withFileLock runs fn holding an exclusive advisory lock on path, so
+// read-modify-write cycles of concurrent CLI processes do not interleave. The
+// lock is taken on a separate path+".lock" file, since the file itself is
+// replaced on every write.
+func withFileLock(path string, fn func() error) error {
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("error locking %s: %w", path, err)
+	}
+	defer unlock()
+	return fn()
+}
+
+// writeFileAtomic writes data to path through a temporary file in the same
+// directory and renames it into place, so readers never see a partly written
+// file and a crash leaves the previous contents intact
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
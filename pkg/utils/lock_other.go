@@ -0,0 +1,37 @@
+//go:build !unix
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// lockTimeout bounds how long a process waits for another to release a lock
+const lockTimeout = 30 * time.Second
+
+// lockFile takes a lock by creating path exclusively, polling while another
+// process holds it. A lock older than lockTimeout is assumed to be left by a
+// process that died and is broken.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > lockTimeout {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("timed out waiting for lock " + path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
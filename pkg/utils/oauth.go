@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/url"
 	"time"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 )
 
 // PrepareOAuthRequest prepares OAuth request parameters and headers
@@ -22,7 +24,7 @@ func PrepareOAuthTokenRequest(config *Config) (*OAuthRequest, error) {
 	}
 
 	if config.OAuth.ClientID == "" || config.OAuth.ClientSecret == "" {
-		return nil, fmt.Errorf("OAuth client ID and secret are required")
+		return nil, clierr.New(clierr.KindAuth, "OAuth client ID and secret are required. Run 'proof config set-oauth'")
 	}
 
 	// Prepare OAuth token request URL
@@ -65,22 +67,24 @@ func ParseOAuthTokenResponse(responseBody []byte) (*OAuthToken, error) {
 	return &token, nil
 }
 
-// SaveOAuthToken saves the OAuth token to config
+// SaveOAuthToken saves the OAuth token of the active profile: to the token
+// cache file, or to the credential store when one is in use
 func SaveOAuthToken(token *OAuthToken) error {
-	// Load current config
 	config, err := LoadConfig()
 	if err != nil {
 		return fmt.Errorf("error loading config: %w", err)
 	}
+	if usesTokenCache(config) {
+		return saveCachedToken(config, token)
+	}
 
-	// Update OAuth token
-	config.OAuthToken = token
-
-	// Save config
-	if err := SaveConfig(config); err != nil {
+	err = UpdateConfig(func(config *Config) error {
+		config.OAuthToken = token
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("error saving config: %w", err)
 	}
-
 	return nil
 }
 
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+)
+
+// PageStyle identifies how an endpoint pages through its results
+type PageStyle int
+
+const (
+	// PageOffset pages with limit and offset query parameters
+	PageOffset PageStyle = iota
+	// PageSCIM pages with SCIM's 1-based startIndex and count
+	PageSCIM
+	// PageCursor pages by passing back the cursor from the previous response's meta.next_cursor
+	PageCursor
+)
+
+// PageRequest describes a single page to fetch
+type PageRequest struct {
+	// Offset is the number of items to skip for PageOffset, or the 1-based
+	// start index for PageSCIM
+	Offset int
+	// Limit is the page size
+	Limit int
+	// Cursor is the opaque position for PageCursor; empty for the first page
+	Cursor string
+}
+
+// FetchPage retrieves one page and returns the raw response body
+type FetchPage func(ctx context.Context, req PageRequest) ([]byte, error)
+
+// Paginator walks every page of a list endpoint
+type Paginator struct {
+	Style PageStyle
+	// First is the request for the first page
+	First PageRequest
+	// MaxItems stops after this many items; zero means no limit
+	MaxItems int
+	Fetch    FetchPage
+}
+
+// Each calls fn with the records of each page as they arrive, so callers can
+// stream output without holding every result in memory. It stops when the
+// endpoint reports no more results or MaxItems is reached.
+func (p *Paginator) Each(ctx context.Context, fn func(records []any) error) error {
+	req := p.First
+	if req.Limit <= 0 {
+		return fmt.Errorf("page size must be positive")
+	}
+
+	seen := 0
+	for {
+		if p.MaxItems > 0 && p.MaxItems-seen < req.Limit {
+			req.Limit = p.MaxItems - seen
+		}
+
+		body, err := p.Fetch(ctx, req)
+		if err != nil {
+			return err
+		}
+		if len(body) == 0 {
+			// Nothing came back, as in a dry run, so there is no next page
+			return nil
+		}
+
+		value, err := normalizeJSON(body)
+		if err != nil {
+			return err
+		}
+		records := Records(value)
+		if obj, ok := value.(map[string]any); ok {
+			if _, isList := listField(obj); !isList {
+				// An object without a result list is an empty page
+				records = nil
+			}
+		}
+
+		if p.MaxItems > 0 && seen+len(records) > p.MaxItems {
+			records = records[:p.MaxItems-seen]
+		}
+		if len(records) > 0 {
+			if err := fn(records); err != nil {
+				return err
+			}
+		}
+		seen += len(records)
+
+		if p.MaxItems > 0 && seen >= p.MaxItems {
+			return nil
+		}
+		next, ok := p.next(req, value, len(records))
+		if !ok {
+			return nil
+		}
+		req = next
+	}
+}
+
+// next works out the request for the following page, reporting false when the
+// current page was the last
+func (p *Paginator) next(req PageRequest, page any, count int) (PageRequest, bool) {
+	if count == 0 {
+		return req, false
+	}
+
+	switch p.Style {
+	case PageCursor:
+		meta, _ := LookupPath(page, "meta")
+		cursor, _ := LookupPath(meta, "next_cursor")
+		next, _ := cursor.(string)
+		if hasMore, ok := LookupPath(meta, "has_more"); ok && hasMore == false {
+			return req, false
+		}
+		if next == "" || next == req.Cursor {
+			return req, false
+		}
+		req.Cursor = next
+		return req, true
+	case PageSCIM:
+		if total, ok := intField(page, "totalResults"); ok && req.Offset-1+count >= total {
+			return req, false
+		}
+		req.Offset += count
+		return req, true
+	default:
+		// Trust total_count when the endpoint reports it, since some cap the
+		// page size below what was asked for; otherwise a short page is the last
+		if total, ok := intField(page, "total_count"); ok {
+			if req.Offset+count >= total {
+				return req, false
+			}
+		} else if count < req.Limit {
+			return req, false
+		}
+		req.Offset += count
+		return req, true
+	}
+}
+
+// intField reads an integer field from the top level of a decoded response
+func intField(value any, key string) (int, bool) {
+	field, ok := LookupPath(value, key)
+	if !ok {
+		return 0, false
+	}
+	switch n := field.(type) {
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
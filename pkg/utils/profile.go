@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+)
+
+// DefaultProfile is the name of the settings at the top level of the config
+// file, used when no other profile is selected
+const DefaultProfile = "default"
+
+// profileNamePattern matches valid profile names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// selectedProfile is the profile chosen with --profile, which takes precedence
+// over PROOF_PROFILE and the current profile saved in the config file
+var selectedProfile string
+
+// configFile is the layout of the config file. The top-level settings form the
+// default profile, so files written before profiles existed load unchanged.
+type configFile struct {
+	Config
+	CurrentProfile string             `json:"current_profile,omitempty"`
+	Profiles       map[string]*Config `json:"profiles,omitempty"`
+	// CredentialStore names the store holding the secrets; empty means this file
+	CredentialStore string `json:"credential_store,omitempty"`
+	// CredentialHelper is the command run by the helper credential store
+	CredentialHelper string `json:"credential_helper,omitempty"`
+
+	// path is where the file was read from
+	path string
+}
+
+// SelectProfile makes name the active profile for this process. An empty name
+// leaves the choice to PROOF_PROFILE and the config file.
+func SelectProfile(name string) {
+	selectedProfile = name
+}
+
+// ActiveProfile returns the name of the profile LoadConfig uses
+func ActiveProfile() (string, error) {
+	file, err := readConfigFile()
+	if err != nil {
+		return "", err
+	}
+	return file.activeProfile(), nil
+}
+
+// ListProfiles returns the profile names, default first, and the name of the active profile
+func ListProfiles() ([]string, string, error) {
+	file, err := readConfigFile()
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...), file.activeProfile(), nil
+}
+
+// LoadProfile resolves the settings of the named profile from the config file
+// and the defaults, without environment variables or flags
+func LoadProfile(name string) (*Config, error) {
+	file, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := file.resolve(name)
+	return config, err
+}
+
+// AddProfile saves a new named profile
+func AddProfile(name string, config *Config) error {
+	if name == DefaultProfile {
+		return clierr.Input("%q is the name of the top-level settings; choose another profile name", DefaultProfile)
+	}
+	if !profileNamePattern.MatchString(name) {
+		return clierr.Input("invalid profile name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+
+	return updateConfigFile(func(file *configFile) error {
+		if _, ok := file.Profiles[name]; ok {
+			return clierr.Input("profile %q already exists", name)
+		}
+		return file.saveProfile(name, config)
+	})
+}
+
+// UseProfile makes name the current profile in the config file
+func UseProfile(name string) error {
+	return updateConfigFile(func(file *configFile) error {
+		if _, err := file.profile(name); err != nil {
+			return err
+		}
+
+		file.CurrentProfile = name
+		if name == DefaultProfile {
+			file.CurrentProfile = ""
+		}
+		return nil
+	})
+}
+
+// DeleteProfile removes a named profile. Deleting the current profile makes
+// the default profile current again.
+func DeleteProfile(name string) error {
+	if name == DefaultProfile {
+		return clierr.Input("the %q profile cannot be deleted", DefaultProfile)
+	}
+
+	return updateConfigFile(func(file *configFile) error {
+		if _, err := file.profile(name); err != nil {
+			return err
+		}
+
+		_, store, err := file.credentialStore()
+		if err != nil {
+			return err
+		}
+		if store != nil {
+			if err := deleteCredentials(store, name); err != nil {
+				return err
+			}
+		}
+
+		delete(file.Profiles, name)
+		if file.CurrentProfile == name {
+			file.CurrentProfile = ""
+		}
+		return nil
+	})
+}
+
+// activeProfile resolves the profile to use: --profile, then PROOF_PROFILE,
+// then the current profile saved in the file
+func (f *configFile) activeProfile() string {
+	if selectedProfile != "" {
+		return selectedProfile
+	}
+	if name := os.Getenv("PROOF_PROFILE"); name != "" {
+		return name
+	}
+	if f.CurrentProfile != "" {
+		return f.CurrentProfile
+	}
+	return DefaultProfile
+}
+
+// profileSource describes how the active profile was chosen
+func (f *configFile) profileSource() string {
+	switch {
+	case selectedProfile != "":
+		return "flag --profile"
+	case os.Getenv("PROOF_PROFILE") != "":
+		return "env PROOF_PROFILE"
+	case f.CurrentProfile != "":
+		return "config file " + f.path
+	default:
+		return "default"
+	}
+}
+
+// resolve layers the built-in defaults, the top-level settings and the named
+// profile. A profile inherits the top-level settings except for credentials
+// and the OAuth token, so it only ever uses its own.
+func (f *configFile) resolve(name string) (*Config, Sources, error) {
+	profile, err := f.profile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := defaultConfig()
+	sources := defaultSources()
+	mergeConfig(config, &f.Config, "config file "+f.path, sources, name == DefaultProfile)
+	if name != DefaultProfile {
+		mergeConfig(config, profile, "profile "+name, sources, true)
+	}
+	config.Profile = name
+	return config, sources, nil
+}
+
+// profile returns a copy of the named profile's settings
+func (f *configFile) profile(name string) (*Config, error) {
+	if name == DefaultProfile {
+		config := f.Config
+		return &config, nil
+	}
+	stored, ok := f.Profiles[name]
+	if !ok || stored == nil {
+		return nil, clierr.Input("profile %q not found; run 'proof config profiles list' to see the available profiles", name)
+	}
+	config := *stored
+	return &config, nil
+}
+
+// saveProfile stores the settings of the named profile, moving its
+// credentials into the credential store when one is in use
+func (f *configFile) saveProfile(name string, config *Config) error {
+	_, store, err := f.credentialStore()
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		stored := *config
+		if err := storeCredentials(store, name, &stored); err != nil {
+			return err
+		}
+		config = &stored
+	}
+	f.setProfile(name, config)
+	return nil
+}
+
+// setProfile stores the settings of the named profile
+func (f *configFile) setProfile(name string, config *Config) {
+	stored := *config
+	stored.Profile = ""
+	if name == DefaultProfile {
+		f.Config = stored
+		return
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string]*Config{}
+	}
+	f.Profiles[name] = &stored
+}
@@ -0,0 +1,522 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query is a compiled jq-style expression. The supported subset covers what is
+// typically needed to pull values out of API responses:
+//
+//	.                      identity
+//	.data[].id             field access and array iteration
+//	.data[0] / .data[-1]   array indexing
+//	.["Resources"]         quoted field access
+//	.data[] | .signer_info.email
+//	.data | length         length, keys, first, last
+//	.data[] | select(.detailed_status == "completed")
+//
+// select supports ==, !=, <, <=, > and >= against string, number, boolean and
+// null literals, or a bare path that is tested for truthiness.
+type Query struct {
+	stages []stage
+}
+
+// stage transforms one input value into zero or more output values
+type stage func(value any) ([]any, error)
+
+// CompileQuery parses a jq-style expression
+func CompileQuery(expr string) (*Query, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	q := &Query{}
+	for _, part := range splitTopLevel(expr, '|') {
+		st, err := compileStage(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid query %q: %w", expr, err)
+		}
+		q.stages = append(q.stages, st)
+	}
+	return q, nil
+}
+
+// Eval runs the query against a decoded JSON value and returns every output
+func (q *Query) Eval(value any) ([]any, error) {
+	outputs := []any{value}
+	for _, st := range q.stages {
+		var next []any
+		for _, v := range outputs {
+			results, err := st(v)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, results...)
+		}
+		outputs = next
+	}
+	return outputs, nil
+}
+
+// ApplyQuery evaluates a jq-style expression against a JSON document. The
+// results are always collected into an array so the output shape does not
+// depend on how many values matched.
+func ApplyQuery(body []byte, expr string) ([]byte, error) {
+	q, err := CompileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := normalizeJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := q.Eval(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if results == nil {
+		results = []any{}
+	}
+	return json.Marshal(results)
+}
+
+// SelectFields projects a JSON document onto the given dotted field paths. List
+// envelopes are unwrapped and each record is projected, so the result is an
+// array of objects; a single object is projected in place.
+func SelectFields(body []byte, fields []string) ([]byte, error) {
+	value, err := normalizeJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	project := func(record any) any {
+		out := map[string]any{}
+		for _, field := range fields {
+			if v, ok := LookupPath(record, field); ok {
+				setPath(out, field, v)
+			}
+		}
+		return out
+	}
+
+	if obj, ok := value.(map[string]any); ok {
+		if _, isList := listField(obj); !isList {
+			return json.Marshal(project(obj))
+		}
+	}
+
+	records := Records(value)
+	projected := make([]any, 0, len(records))
+	for _, record := range records {
+		projected = append(projected, project(record))
+	}
+	return json.Marshal(projected)
+}
+
+// setPath stores value under a dotted path, creating intermediate objects
+func setPath(target map[string]any, path string, value any) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := target[part].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			target[part] = next
+		}
+		target = next
+	}
+	target[parts[len(parts)-1]] = value
+}
+
+// splitTopLevel splits s on sep, ignoring separators inside quotes, brackets and parentheses
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth := 0
+	inString := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case inString:
+			if r == '\\' {
+				continue
+			}
+			if r == '"' && (i == 0 || s[i-1] != '\\') {
+				inString = false
+			}
+		case r == '"':
+			inString = true
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// compileStage compiles a single pipeline stage
+func compileStage(expr string) (stage, error) {
+	switch expr {
+	case "":
+		return nil, fmt.Errorf("empty pipeline stage")
+	case "length":
+		return func(v any) ([]any, error) {
+			switch val := v.(type) {
+			case nil:
+				return []any{int64(0)}, nil
+			case string:
+				return []any{int64(len([]rune(val)))}, nil
+			case []any:
+				return []any{int64(len(val))}, nil
+			case map[string]any:
+				return []any{int64(len(val))}, nil
+			default:
+				return nil, fmt.Errorf("cannot take length of %T", v)
+			}
+		}, nil
+	case "keys":
+		return func(v any) ([]any, error) {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("keys requires an object")
+			}
+			keys := make([]string, 0, len(obj))
+			for k := range obj {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			out := make([]any, len(keys))
+			for i, k := range keys {
+				out[i] = k
+			}
+			return []any{out}, nil
+		}, nil
+	case "first", "last":
+		return func(v any) ([]any, error) {
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s requires an array", expr)
+			}
+			if len(arr) == 0 {
+				return []any{nil}, nil
+			}
+			if expr == "first" {
+				return []any{arr[0]}, nil
+			}
+			return []any{arr[len(arr)-1]}, nil
+		}, nil
+	}
+
+	if strings.HasPrefix(expr, "select(") && strings.HasSuffix(expr, ")") {
+		return compileSelect(strings.TrimSpace(expr[len("select(") : len(expr)-1]))
+	}
+
+	return compilePath(expr)
+}
+
+// pathStep is one segment of a path expression
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+	iterate bool
+}
+
+// compilePath compiles an expression such as .data[].signer_info.email
+func compilePath(expr string) (stage, error) {
+	steps, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v any) ([]any, error) {
+		current := []any{v}
+		for _, step := range steps {
+			var next []any
+			for _, item := range current {
+				results, err := step.apply(item)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, results...)
+			}
+			current = next
+		}
+		return current, nil
+	}, nil
+}
+
+// parsePath tokenizes a path expression into steps
+func parsePath(expr string) ([]pathStep, error) {
+	if !strings.HasPrefix(expr, ".") {
+		return nil, fmt.Errorf("unsupported expression %q", expr)
+	}
+
+	var steps []pathStep
+	i := 0
+	for i < len(expr) {
+		switch expr[i] {
+		case '.':
+			i++
+			if i < len(expr) && expr[i] == '"' {
+				key, n, err := readQuoted(expr[i:])
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, pathStep{key: key})
+				i += n
+				continue
+			}
+			start := i
+			for i < len(expr) && isIdentRune(rune(expr[i])) {
+				i++
+			}
+			if i > start {
+				steps = append(steps, pathStep{key: expr[start:i]})
+			}
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in %q", expr)
+			}
+			inner := strings.TrimSpace(expr[i+1 : i+end])
+			switch {
+			case inner == "":
+				steps = append(steps, pathStep{iterate: true})
+			case strings.HasPrefix(inner, `"`):
+				key, _, err := readQuoted(inner)
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, pathStep{key: key})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q", inner)
+				}
+				steps = append(steps, pathStep{index: idx, isIndex: true})
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", expr[i], expr)
+		}
+	}
+	return steps, nil
+}
+
+// apply evaluates a single path step
+func (s pathStep) apply(v any) ([]any, error) {
+	switch {
+	case s.iterate:
+		switch val := v.(type) {
+		case []any:
+			return val, nil
+		case map[string]any:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			out := make([]any, len(keys))
+			for i, k := range keys {
+				out[i] = val[k]
+			}
+			return out, nil
+		case nil:
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("cannot iterate over %T", v)
+		}
+	case s.isIndex:
+		arr, ok := v.([]any)
+		if !ok {
+			if v == nil {
+				return []any{nil}, nil
+			}
+			return nil, fmt.Errorf("cannot index %T with a number", v)
+		}
+		idx := s.index
+		if idx < 0 {
+			idx += len(arr)
+		}
+		if idx < 0 || idx >= len(arr) {
+			return []any{nil}, nil
+		}
+		return []any{arr[idx]}, nil
+	default:
+		switch val := v.(type) {
+		case map[string]any:
+			return []any{val[s.key]}, nil
+		case nil:
+			return []any{nil}, nil
+		default:
+			return nil, fmt.Errorf("cannot access field %q of %T", s.key, v)
+		}
+	}
+}
+
+// compileSelect compiles the argument of select()
+func compileSelect(cond string) (stage, error) {
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		idx := indexOutsideQuotes(cond, op)
+		if idx < 0 {
+			continue
+		}
+
+		lhs, err := compilePath(strings.TrimSpace(cond[:idx]))
+		if err != nil {
+			return nil, err
+		}
+		var literal any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(cond[idx+len(op):])), &literal); err != nil {
+			return nil, fmt.Errorf("invalid literal in select: %s", strings.TrimSpace(cond[idx+len(op):]))
+		}
+		literal = convertNumbers(normalizeLiteral(literal))
+
+		return func(v any) ([]any, error) {
+			values, err := lhs(v)
+			if err != nil {
+				return nil, err
+			}
+			for _, val := range values {
+				if compareValues(val, literal, op) {
+					return []any{v}, nil
+				}
+			}
+			return nil, nil
+		}, nil
+	}
+
+	lhs, err := compilePath(cond)
+	if err != nil {
+		return nil, err
+	}
+	return func(v any) ([]any, error) {
+		values, err := lhs(v)
+		if err != nil {
+			return nil, err
+		}
+		for _, val := range values {
+			if val != nil && val != false {
+				return []any{v}, nil
+			}
+		}
+		return nil, nil
+	}, nil
+}
+
+// normalizeLiteral converts float64 literals to json.Number so they go through convertNumbers
+func normalizeLiteral(v any) any {
+	if f, ok := v.(float64); ok {
+		return json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	return v
+}
+
+// compareValues compares a value with a literal using op
+func compareValues(a, b any, op string) bool {
+	if af, aok := toFloat(a); aok {
+		if bf, bok := toFloat(b); bok {
+			switch op {
+			case "==":
+				return af == bf
+			case "!=":
+				return af != bf
+			case ">":
+				return af > bf
+			case ">=":
+				return af >= bf
+			case "<":
+				return af < bf
+			case "<=":
+				return af <= bf
+			}
+		}
+	}
+
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		switch op {
+		case ">":
+			return as > bs
+		case ">=":
+			return as >= bs
+		case "<":
+			return as < bs
+		case "<=":
+			return as <= bs
+		}
+	}
+
+	// Values of different JSON types are never equal, so "1" != 1
+	switch op {
+	case "==":
+		return reflect.DeepEqual(a, b)
+	case "!=":
+		return !reflect.DeepEqual(a, b)
+	}
+	return false
+}
+
+// toFloat converts numeric JSON values to float64
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// readQuoted reads a JSON string literal at the start of s and returns it with its length
+func readQuoted(s string) (string, int, error) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '"' {
+			var out string
+			if err := json.Unmarshal([]byte(s[:i+1]), &out); err != nil {
+				return "", 0, fmt.Errorf("invalid string %s", s[:i+1])
+			}
+			return out, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string in %q", s)
+}
+
+// indexOutsideQuotes finds sub in s, skipping quoted sections
+func indexOutsideQuotes(s, sub string) int {
+	inString := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case inString && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inString = !inString
+		case !inString && strings.HasPrefix(s[i:], sub):
+			return i
+		}
+	}
+	return -1
+}
+
+// isIdentRune reports whether r can appear in an unquoted field name
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
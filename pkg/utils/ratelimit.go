@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimit limits how fast the CLI sends requests. Zero values mean unlimited.
+type RateLimit struct {
+	// RPS is the sustained number of requests per second
+	RPS float64 `json:"rps,omitempty"`
+	// Burst is how many requests may be sent at once after a quiet period (default 1)
+	Burst int `json:"burst,omitempty"`
+	// Concurrency caps the number of requests in flight
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// RequestLimits returns the configured rate limits, or none when the config sets none
+func (c *Config) RequestLimits() RateLimit {
+	if c.RateLimit == nil {
+		return RateLimit{}
+	}
+	return *c.RateLimit
+}
+
+// TokenBucket is a token-bucket rate limiter that is safe for concurrent use
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// now and sleep are replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewTokenBucket returns a bucket refilling at rps tokens per second and holding
+// at most burst tokens. It starts full.
+func NewTokenBucket(rps float64, burst int) *TokenBucket {
+	burst = max(burst, 1)
+	return &TokenBucket{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	return b.sleep(ctx, delay)
+}
+
+// reserve takes a token, returning how long the caller must wait for it. The
+// balance may go negative so waiting callers queue up in order.
+func (b *TokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// RateLimitTransport is an http.RoundTripper that paces requests through a
+// token bucket and caps how many are in flight. A single instance is shared by
+// every client built on a ProofClient, so limits hold across all of them.
+type RateLimitTransport struct {
+	Base http.RoundTripper
+
+	mu     sync.RWMutex
+	limit  RateLimit
+	bucket *TokenBucket
+	slots  chan struct{}
+}
+
+// NewRateLimitTransport wraps base, or http.DefaultTransport when base is nil
+func NewRateLimitTransport(base http.RoundTripper, limit RateLimit) *RateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &RateLimitTransport{Base: base}
+	t.SetLimit(limit)
+	return t
+}
+
+// Limit returns the current limits
+func (t *RateLimitTransport) Limit() RateLimit {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.limit
+}
+
+// SetLimit replaces the limits. Requests already waiting keep the old ones.
+func (t *RateLimitTransport) SetLimit(limit RateLimit) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.limit = limit
+	t.bucket = nil
+	if limit.RPS > 0 {
+		t.bucket = NewTokenBucket(limit.RPS, limit.Burst)
+	}
+	t.slots = nil
+	if limit.Concurrency > 0 {
+		t.slots = make(chan struct{}, limit.Concurrency)
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	bucket, slots := t.bucket, t.slots
+	t.mu.RUnlock()
+
+	ctx := req.Context()
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+			// The slot is held until the response is returned; reading the body
+			// is up to the caller and does not count against the limit
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if bucket != nil {
+		if err := bucket.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return t.Base.RoundTrip(req)
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry defaults used when the config does not set them
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 500 * time.Millisecond
+	// DefaultRetryMaxWait caps a single backoff or Retry-After delay
+	DefaultRetryMaxWait = 30 * time.Second
+)
+
+// RetryConfig holds the retry settings stored in the config file
+type RetryConfig struct {
+	MaxRetries *int          `json:"max_retries,omitempty"`
+	Wait       time.Duration `json:"wait,omitempty"`
+}
+
+// RetryPolicy controls how the shared transport retries failed requests
+type RetryPolicy struct {
+	// MaxRetries is the number of attempts made after the first; zero disables retries
+	MaxRetries int
+	// Wait is the base backoff delay, doubled on every attempt
+	Wait time.Duration
+	// MaxWait caps each delay. A Retry-After longer than this is not waited out.
+	MaxWait time.Duration
+}
+
+// RetryPolicy returns the retry policy described by the config, filling in defaults
+func (c *Config) RetryPolicy() RetryPolicy {
+	policy := RetryPolicy{
+		MaxRetries: DefaultMaxRetries,
+		Wait:       DefaultRetryWait,
+		MaxWait:    DefaultRetryMaxWait,
+	}
+	if c.Retry != nil {
+		if c.Retry.MaxRetries != nil {
+			policy.MaxRetries = *c.Retry.MaxRetries
+		}
+		if c.Retry.Wait > 0 {
+			policy.Wait = c.Retry.Wait
+		}
+	}
+	return policy
+}
+
+// retryUnsafeKey marks a context whose requests may be retried regardless of method
+type retryUnsafeKey struct{}
+
+// WithRetryUnsafe returns a context under which POST and PATCH requests are
+// retried as well. Only use it for requests that are safe to repeat.
+func WithRetryUnsafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryUnsafeKey{}, true)
+}
+
+// RetryTransport is an http.RoundTripper that retries transient failures with
+// jittered exponential backoff. Connection errors and 429, 502, 503 and 504
+// responses are retried; Retry-After is honored on 429 and 503. Idempotent
+// methods are retried by default, POST and PATCH only when the request carries
+// an Idempotency-Key header or its context came from WithRetryUnsafe.
+type RetryTransport struct {
+	Base   http.RoundTripper
+	Policy RetryPolicy
+
+	// sleep waits between attempts; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryTransport wraps base, or http.DefaultTransport when base is nil
+func NewRetryTransport(base http.RoundTripper, policy RetryPolicy) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryTransport{Base: base, Policy: policy, sleep: sleepContext}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Policy.MaxRetries <= 0 || !retryable(req) {
+		return t.Base.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.Base.RoundTrip(attemptReq)
+		if attempt >= t.Policy.MaxRetries || req.Context().Err() != nil || errors.Is(err, ErrCassetteMiss) {
+			return resp, err
+		}
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			delay = t.backoff(attempt)
+		case retryStatus(resp.StatusCode):
+			delay = t.backoff(attempt)
+			if after, ok := retryAfter(resp); ok {
+				if after > t.Policy.MaxWait {
+					// Waiting that long would outlast the command; report the response
+					return resp, nil
+				}
+				delay = after
+			}
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the delay before retry attempt+1: Wait doubled per attempt,
+// capped at MaxWait, with jitter across its upper half so clients spread out
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	d := t.Policy.Wait << attempt
+	if d <= 0 || (t.Policy.MaxWait > 0 && d > t.Policy.MaxWait) {
+		d = t.Policy.MaxWait
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// retryable reports whether req may be sent more than once
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body cannot be replayed
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	if req.Header.Get("Idempotency-Key") != "" {
+		return true
+	}
+	unsafe, _ := req.Context().Value(retryUnsafeKey{}).(bool)
+	return unsafe
+}
+
+// retryStatus reports whether a response status is worth retrying
+func retryStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header of a 429 or 503 response, given
+// either in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// rewind returns the request to send for an attempt, with a fresh body after the first
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 {
+		return req, nil
+	}
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
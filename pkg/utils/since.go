@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
+)
+
+// ParseSince parses a point in time given as an RFC 3339 timestamp, a date
+// such as 2026-01-31, or an age relative to now such as 24h, 7d or 1d12h
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	age, err := parseAge(value)
+	if err != nil || age < 0 {
+		return time.Time{}, clierr.Input("invalid time %q: use an RFC 3339 timestamp, a date such as 2026-01-31, or an age such as 24h or 7d", value)
+	}
+	return now.Add(-age), nil
+}
+
+// parseAge parses a Go duration that may start with a number of days, such as 7d or 1d12h
+func parseAge(value string) (time.Duration, error) {
+	days, rest, found := strings.Cut(value, "d")
+	if !found {
+		return time.ParseDuration(value)
+	}
+	n, err := strconv.Atoi(days)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of days %q", days)
+	}
+	age := time.Duration(n) * 24 * time.Hour
+	if rest != "" {
+		extra, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, err
+		}
+		age += extra
+	}
+	return age, nil
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// templateColors maps the names accepted by the color template function to attributes
+var templateColors = map[string]color.Attribute{
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+	"bold":    color.Bold,
+}
+
+// TemplateFuncs returns the helper functions available to output templates
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"date":     templateDate,
+		"since":    templateSince,
+		"b64dec":   templateBase64Decode,
+		"b64enc":   func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"truncate": templateTruncate,
+		"color":    templateColor,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"join":     templateJoin,
+		"default":  templateDefault,
+		"json":     templateJSON,
+	}
+}
+
+// ParseTemplate parses an output template with the helper functions installed
+func ParseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ExecuteTemplate renders a JSON document through tmpl
+func ExecuteTemplate(tmpl *template.Template, body []byte) (string, error) {
+	value, err := normalizeJSON(body)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, value); err != nil {
+		return "", fmt.Errorf("error rendering template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// toTime converts an RFC 3339 string or a Unix timestamp in seconds to a time
+func toTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", v)
+		}
+		return t, nil
+	case int64:
+		return time.Unix(v, 0).UTC(), nil
+	case float64:
+		return time.Unix(int64(v), 0).UTC(), nil
+	case nil:
+		return time.Time{}, nil
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to a time", value)
+	}
+}
+
+// templateDate formats a time value with a Go reference layout, e.g. {{date "2006-01-02" .date_created}}
+func templateDate(layout string, value any) (string, error) {
+	t, err := toTime(value)
+	if err != nil || t.IsZero() {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
+
+// templateSince returns the time elapsed since value, rounded to the second
+func templateSince(value any) (string, error) {
+	t, err := toTime(value)
+	if err != nil || t.IsZero() {
+		return "", err
+	}
+	return time.Since(t).Round(time.Second).String(), nil
+}
+
+// templateBase64Decode decodes standard or URL-safe base64, padded or not
+func templateBase64Decode(s string) (string, error) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := enc.DecodeString(s); err == nil {
+			return string(decoded), nil
+		}
+	}
+	return "", fmt.Errorf("invalid base64 value")
+}
+
+// templateTruncate shortens s to at most n characters, ending with "..." when cut
+func templateTruncate(n int, value any) string {
+	s := templateString(value)
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// templateColor wraps value in the named terminal color
+func templateColor(name string, value any) (string, error) {
+	attr, ok := templateColors[name]
+	if !ok {
+		return "", fmt.Errorf("unknown color %q", name)
+	}
+	return color.New(attr).Sprint(templateString(value)), nil
+}
+
+// templateJoin joins the items of a list with sep
+func templateJoin(sep string, value any) string {
+	items, ok := value.([]any)
+	if !ok {
+		return templateString(value)
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = templateString(item)
+	}
+	return strings.Join(parts, sep)
+}
+
+// templateDefault returns fallback when value is empty
+func templateDefault(fallback, value any) any {
+	if value == nil || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// templateJSON renders value as compact JSON
+func templateJSON(value any) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// templateString renders a template argument as plain text
+func templateString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tokenCache is the layout of the OAuth token cache file. Tokens are keyed by
+// the token endpoint, client ID and scope that issued them, so every profile
+// and config file using the same OAuth client shares one token.
+type tokenCache struct {
+	Tokens map[string]*OAuthToken `json:"tokens"`
+}
+
+// tokenCachePath returns the location of the OAuth token cache file
+func tokenCachePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".proof-cli", "tokens.json"), nil
+}
+
+// tokenCacheKey identifies the OAuth client a token was issued to
+func tokenCacheKey(config *Config) string {
+	return strings.Join([]string{
+		strings.TrimRight(config.APIEndpoint, "/"),
+		config.OAuth.ClientID,
+		config.OAuth.Scope,
+	}, " ")
+}
+
+// usesTokenCache reports whether config keeps its OAuth token in the token
+// cache file. Credential stores keep tokens with the other secrets instead.
+func usesTokenCache(config *Config) bool {
+	return config.Credentials == StoreConfig && config.OAuth != nil && config.OAuth.ClientID != ""
+}
+
+// loadCachedToken returns the cached token for config's OAuth client, or nil
+func loadCachedToken(config *Config) (*OAuthToken, error) {
+	path, err := tokenCachePath()
+	if err != nil {
+		return nil, err
+	}
+	cache, err := readTokenCache(path)
+	if err != nil {
+		return nil, err
+	}
+	return cache.Tokens[tokenCacheKey(config)], nil
+}
+
+// saveCachedToken stores token for config's OAuth client. Expired tokens of
+// other clients are dropped while the file is rewritten.
+func saveCachedToken(config *Config, token *OAuthToken) error {
+	path, err := tokenCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating token cache directory: %w", err)
+	}
+
+	return withFileLock(path, func() error {
+		cache, err := readTokenCache(path)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for key, cached := range cache.Tokens {
+			if cached == nil || cached.ExpiresAt.Before(now) {
+				delete(cache.Tokens, key)
+			}
+		}
+		cache.Tokens[tokenCacheKey(config)] = token
+
+		data, err := json.MarshalIndent(cache, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling token cache: %w", err)
+		}
+		if err := writeFileAtomic(path, data, 0600); err != nil {
+			return fmt.Errorf("error writing token cache: %w", err)
+		}
+		return nil
+	})
+}
+
+// readTokenCache reads the token cache file. A missing or unreadable cache is
+// treated as empty, since tokens can always be fetched again.
+func readTokenCache(path string) (*tokenCache, error) {
+	cache := &tokenCache{Tokens: map[string]*OAuthToken{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading token cache: %w", err)
+	}
+	if err := json.Unmarshal(data, cache); err != nil || cache.Tokens == nil {
+		return &tokenCache{Tokens: map[string]*OAuthToken{}}, nil
+	}
+	return cache, nil
+}
+
+// withTokenRefreshLock runs fn holding the lock that serializes OAuth token
+// refreshes across processes
+func withTokenRefreshLock(fn func() error) error {
+	path, err := tokenCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating token cache directory: %w", err)
+	}
+	return withFileLock(strings.TrimSuffix(path, ".json")+".refresh", fn)
+}
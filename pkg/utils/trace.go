@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// traceReadLimit is how much of a body is read for logging; JSON up to this
+	// size is logged in full once base64 payloads are shortened
+	traceReadLimit = 8 << 20
+	// traceTextLimit is how much of a non-JSON body is logged
+	traceTextLimit = 16 << 10
+	// traceStringLimit is the longest base64-looking JSON string logged in full
+	traceStringLimit = 120
+)
+
+// redactedHeaders carry credentials and are never logged
+var redactedHeaders = []string{"Apikey", "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactedFields are JSON fields holding credentials
+var redactedFields = []string{"access_token", "refresh_token", "client_secret", "api_key", "password"}
+
+// base64Pattern matches strings made only of base64 characters
+var base64Pattern = regexp.MustCompile(`^[A-Za-z0-9+/_-]+={0,2}$`)
+
+// TraceTransport is an http.RoundTripper that logs each request and response
+// at debug level: method, URL, headers, body, status and timing. Credentials
+// are redacted and long base64 payloads such as documents are truncated.
+type TraceTransport struct {
+	Base   http.RoundTripper
+	Logger log.FieldLogger
+}
+
+// NewTraceTransport wraps base, or http.DefaultTransport when base is nil
+func NewTraceTransport(base http.RoundTripper, logger log.FieldLogger) *TraceTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &TraceTransport{Base: base, Logger: logger}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *TraceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "> %s %s\n", req.Method, req.URL)
+	writeHeaders(&buf, "> ", req.Header)
+	if body, err := peekRequestBody(req); err != nil {
+		return nil, err
+	} else if len(body) > 0 {
+		buf.WriteString(RedactBody(body, req.Header.Get("Content-Type")))
+		buf.WriteByte('\n')
+	}
+	t.Logger.Debug(strings.TrimSuffix(buf.String(), "\n"))
+
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.Logger.Debugf("< %s %s failed after %s: %v", req.Method, req.URL, elapsed, err)
+		return nil, err
+	}
+
+	buf.Reset()
+	fmt.Fprintf(&buf, "< %d %s (%s)\n", resp.StatusCode, http.StatusText(resp.StatusCode), elapsed)
+	writeHeaders(&buf, "< ", resp.Header)
+	body, err := peekResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > 0 {
+		buf.WriteString(RedactBody(body, resp.Header.Get("Content-Type")))
+		buf.WriteByte('\n')
+	}
+	t.Logger.Debug(strings.TrimSuffix(buf.String(), "\n"))
+	return resp, nil
+}
+
+// writeHeaders writes headers in a stable order with credentials redacted
+func writeHeaders(buf *strings.Builder, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			if containsFold(redactedHeaders, name) {
+				value = "[REDACTED]"
+			}
+			fmt.Fprintf(buf, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+// peekRequestBody returns up to traceReadLimit bytes of the request body without consuming it
+func peekRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(io.LimitReader(body, traceReadLimit+1))
+	}
+	prefix, err := io.ReadAll(io.LimitReader(req.Body, traceReadLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	req.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), req.Body), req.Body}
+	return prefix, nil
+}
+
+// peekResponseBody returns up to traceReadLimit bytes of the response body,
+// leaving the full body for the caller
+func peekResponseBody(resp *http.Response) ([]byte, error) {
+	if resp.Body == nil {
+		return nil, nil
+	}
+	prefix, err := io.ReadAll(io.LimitReader(resp.Body, traceReadLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+	return prefix, nil
+}
+
+// readCloser pairs a reader with the Closer of the body it replaces
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// RedactBody renders a body for logging. JSON and form bodies have credential
+// fields redacted and base64 payloads shortened; other text is cut short and
+// binary content is summarized.
+func RedactBody(body []byte, contentType string) string {
+	complete := len(body) <= traceReadLimit
+
+	if complete {
+		if redacted, ok := redactFields(body, contentType, true); ok {
+			return redacted
+		}
+	}
+
+	if !isText(body, contentType) {
+		size := fmt.Sprintf("%d bytes", len(body))
+		if !complete {
+			size = fmt.Sprintf("over %d bytes", traceReadLimit)
+		}
+		return fmt.Sprintf("[%s body, %s]", contentTypeOrBinary(contentType), size)
+	}
+	if len(body) > traceTextLimit {
+		return string(body[:traceTextLimit]) + fmt.Sprintf("... [truncated after %d bytes]", traceTextLimit)
+	}
+	return string(body)
+}
+
+// redactFields redacts the credential fields of a JSON or form body, also
+// shortening base64 strings when shorten is set. It reports false for any
+// other kind of body.
+func redactFields(body []byte, contentType string, shorten bool) (string, bool) {
+	if json.Valid(body) {
+		// UseNumber keeps numbers exactly as sent
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if decoder.Decode(&value) == nil {
+			var out bytes.Buffer
+			encoder := json.NewEncoder(&out)
+			encoder.SetEscapeHTML(false)
+			if encoder.Encode(redactValue(value, shorten)) == nil {
+				return strings.TrimSuffix(out.String(), "\n"), true
+			}
+		}
+	}
+	if strings.Contains(contentType, "x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for key := range form {
+				if containsFold(redactedFields, key) {
+					form.Set(key, "[REDACTED]")
+				}
+			}
+			return form.Encode(), true
+		}
+	}
+	return "", false
+}
+
+// redactValue walks a decoded JSON value, redacting credentials and, when
+// shorten is set, shortening base64 strings
+func redactValue(value any, shorten bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if containsFold(redactedFields, key) {
+				v[key] = "[REDACTED]"
+				continue
+			}
+			v[key] = redactValue(field, shorten)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item, shorten)
+		}
+		return v
+	case string:
+		if shorten && len(v) > traceStringLimit && base64Pattern.MatchString(v) {
+			return fmt.Sprintf("%s... [base64, %d bytes]", v[:32], len(v))
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isText reports whether a body can be logged as text
+func isText(body []byte, contentType string) bool {
+	if strings.HasPrefix(contentType, "text/") || strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "xml") || strings.Contains(contentType, "x-www-form-urlencoded") {
+		return true
+	}
+	return contentType == "" && !bytes.ContainsRune(body, 0)
+}
+
+// contentTypeOrBinary names a body's content type for a summary line
+func contentTypeOrBinary(contentType string) string {
+	if contentType == "" {
+		return "binary"
+	}
+	return contentType
+}
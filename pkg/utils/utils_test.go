@@ -2,17 +2,25 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tsarlewey/proof-cli/openapi"
+	"github.com/tsarlewey/proof-cli/pkg/clierr"
 )
 
 // MockRoundTripper is a mock implementation of http.RoundTripper for testing
@@ -27,6 +35,11 @@ func (m *MockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	return m.Response, m.Err
 }
 
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
 // mockJSONResponse creates a mock HTTP response with JSON body
 func mockJSONResponse(statusCode int, body any) *http.Response {
 	jsonBytes, _ := json.Marshal(body)
@@ -150,463 +163,2260 @@ func TestBuildQueryParams_HandlesOmitempty(t *testing.T) {
 	assert.Equal(t, "john", result.Get("name"))
 }
 
-func TestFormatOutput(t *testing.T) {
-	// FormatOutput is a stub that returns empty string
-	result, err := FormatOutput("data", "json")
-	assert.NoError(t, err)
-	assert.Empty(t, result)
-}
-
 // ============================================================================
-// oauth.go tests
+// query.go tests
 // ============================================================================
 
-func TestPrepareOAuthTokenRequest_Success(t *testing.T) {
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		OAuth: &OAuthConfig{
-			Enabled:      true,
-			ClientID:     "test-client-id",
-			ClientSecret: "test-client-secret",
-			Scope:        "read write",
-		},
-	}
+const queryTestBody = `{"data":[` +
+	`{"id":"ot_1","detailed_status":"completed","signer_info":{"email":"a@example.com"},"documents":2},` +
+	`{"id":"ot_2","detailed_status":"sent","signer_info":{"email":"b@example.com"},"documents":5}],` +
+	`"Resources":[{"userName":"jdoe"}]}`
 
-	req, err := PrepareOAuthTokenRequest(config)
+func TestApplyQuery(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected string
+	}{
+		{".", `[{"Resources":[{"userName":"jdoe"}],"data":[{"detailed_status":"completed","documents":2,"id":"ot_1","signer_info":{"email":"a@example.com"}},{"detailed_status":"sent","documents":5,"id":"ot_2","signer_info":{"email":"b@example.com"}}]}]`},
+		{".data[].id", `["ot_1","ot_2"]`},
+		{".data[0].signer_info.email", `["a@example.com"]`},
+		{".data[-1].id", `["ot_2"]`},
+		{`.["Resources"][0].userName`, `["jdoe"]`},
+		{".data | length", `[2]`},
+		{".data | first | keys", `[["detailed_status","documents","id","signer_info"]]`},
+		{`.data[] | select(.detailed_status == "sent") | .id`, `["ot_2"]`},
+		{`.data[] | select(.documents > 2) | .id`, `["ot_2"]`},
+		{`.data[] | select(.detailed_status != "sent") | .signer_info.email`, `["a@example.com"]`},
+		{`.data[] | select(.missing) | .id`, `[]`},
+		{".missing.field", `[null]`},
+	}
+
+	for _, tc := range testCases {
+		result, err := ApplyQuery([]byte(queryTestBody), tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.JSONEq(t, tc.expected, string(result), tc.expr)
+	}
+}
+
+func TestApplyQuery_TypedEquality(t *testing.T) {
+	body := []byte(`[{"id":"a","n":1,"s":"1","b":true,"t":"true","z":null}]`)
+	testCases := []struct {
+		expr     string
+		expected string
+	}{
+		{`.[] | select(.n == 1) | .id`, `["a"]`},
+		{`.[] | select(.n == 1.0) | .id`, `["a"]`},
+		{`.[] | select(.s == 1) | .id`, `[]`},
+		{`.[] | select(.n == "1") | .id`, `[]`},
+		{`.[] | select(.s == "1") | .id`, `["a"]`},
+		{`.[] | select(.t == true) | .id`, `[]`},
+		{`.[] | select(.b == true) | .id`, `["a"]`},
+		{`.[] | select(.z == null) | .id`, `["a"]`},
+		{`.[] | select(.s != 1) | .id`, `["a"]`},
+		{`.[] | select(.missing == "null") | .id`, `[]`},
+	}
 
-	require.NoError(t, err)
-	assert.Equal(t, "https://api.proof.com/oauth/v2/token", req.URL)
-	assert.Contains(t, req.FormData, "grant_type=client_credentials")
-	assert.Contains(t, req.FormData, "scope=read+write")
-	assert.Equal(t, "application/x-www-form-urlencoded", req.Headers["Content-Type"])
-	assert.Equal(t, "application/json", req.Headers["Accept"])
-	assert.Contains(t, req.Headers["Authorization"], "Basic ")
+	for _, tc := range testCases {
+		result, err := ApplyQuery(body, tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.JSONEq(t, tc.expected, string(result), tc.expr)
+	}
 }
 
-func TestPrepareOAuthTokenRequest_OAuthDisabled(t *testing.T) {
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		OAuth: &OAuthConfig{
-			Enabled: false,
-		},
+func TestCompileQuery_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "data", ".data[", ".data | ", "select(.id == nope)"} {
+		_, err := CompileQuery(expr)
+		assert.Error(t, err, expr)
 	}
+}
 
-	_, err := PrepareOAuthTokenRequest(config)
+func TestApplyQuery_TypeErrors(t *testing.T) {
+	_, err := ApplyQuery([]byte(`{"id":"ot_1"}`), ".id[]")
+	assert.Error(t, err)
 
+	_, err = ApplyQuery([]byte(`not json`), ".")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "OAuth not enabled")
 }
 
-func TestPrepareOAuthTokenRequest_NilOAuth(t *testing.T) {
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		OAuth:       nil,
-	}
+func TestSelectFields(t *testing.T) {
+	result, err := SelectFields([]byte(queryTestBody), []string{"id", "signer_info.email"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id":"ot_1","signer_info":{"email":"a@example.com"}},{"id":"ot_2","signer_info":{"email":"b@example.com"}}]`, string(result))
 
-	_, err := PrepareOAuthTokenRequest(config)
+	result, err = SelectFields([]byte(`{"id":"ot_1","detailed_status":"sent","extra":true}`), []string{"id", "detailed_status", "missing"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"ot_1","detailed_status":"sent"}`, string(result))
+}
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "OAuth not enabled")
+// ============================================================================
+// template.go tests
+// ============================================================================
+
+func renderTestTemplate(t *testing.T, text, body string) string {
+	t.Helper()
+	tmpl, err := ParseTemplate("test", text)
+	require.NoError(t, err)
+	out, err := ExecuteTemplate(tmpl, []byte(body))
+	require.NoError(t, err)
+	return out
 }
 
-func TestPrepareOAuthTokenRequest_MissingCredentials(t *testing.T) {
-	tests := []struct {
-		name   string
-		config *Config
+func TestExecuteTemplate_Range(t *testing.T) {
+	body := `{"data":[{"id":"ot_1","detailed_status":"completed"},{"id":"ot_2","detailed_status":"sent"}]}`
+
+	out := renderTestTemplate(t, "{{range .data}}{{.id}}\t{{.detailed_status}}\n{{end}}", body)
+
+	assert.Equal(t, "ot_1\tcompleted\not_2\tsent\n", out)
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	body := `{"date_created":"2024-03-05T14:30:00Z","epoch":1709649000,"payload":"aGVsbG8=","name":"Quarterly refinance packet","tags":["a","b"],"empty":""}`
+
+	testCases := []struct {
+		text     string
+		expected string
 	}{
-		{
-			name: "missing client ID",
-			config: &Config{
-				APIEndpoint: "https://api.proof.com",
-				OAuth: &OAuthConfig{
-					Enabled:      true,
-					ClientID:     "",
-					ClientSecret: "secret",
-				},
-			},
-		},
-		{
-			name: "missing client secret",
-			config: &Config{
-				APIEndpoint: "https://api.proof.com",
-				OAuth: &OAuthConfig{
-					Enabled:      true,
-					ClientID:     "client-id",
-					ClientSecret: "",
-				},
-			},
-		},
+		{`{{date "2006-01-02" .date_created}}`, "2024-03-05"},
+		{`{{date "2006-01-02 15:04" .epoch}}`, "2024-03-05 14:30"},
+		{`{{b64dec .payload}}`, "hello"},
+		{`{{b64enc "hello"}}`, "aGVsbG8="},
+		{`{{truncate 12 .name}}`, "Quarterly..."},
+		{`{{.name | truncate 100}}`, "Quarterly refinance packet"},
+		{`{{upper "sent"}}`, "SENT"},
+		{`{{join ", " .tags}}`, "a, b"},
+		{`{{default "n/a" .empty}}`, "n/a"},
+		{`{{json .tags}}`, `["a","b"]`},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			_, err := PrepareOAuthTokenRequest(tt.config)
-			assert.Error(t, err)
-			assert.Contains(t, err.Error(), "client ID and secret are required")
-		})
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, renderTestTemplate(t, tc.text, body), tc.text)
 	}
 }
 
-func TestPrepareOAuthTokenRequest_NoScope(t *testing.T) {
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		OAuth: &OAuthConfig{
-			Enabled:      true,
-			ClientID:     "test-client-id",
-			ClientSecret: "test-client-secret",
-			Scope:        "",
-		},
-	}
+func TestTemplateFuncs_Color(t *testing.T) {
+	out := renderTestTemplate(t, `{{color "green" .status}}`, `{"status":"completed"}`)
+	assert.Contains(t, out, "completed")
 
-	req, err := PrepareOAuthTokenRequest(config)
+	tmpl, err := ParseTemplate("test", `{{color "chartreuse" .status}}`)
+	require.NoError(t, err)
+	_, err = ExecuteTemplate(tmpl, []byte(`{"status":"completed"}`))
+	assert.Error(t, err)
+}
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	_, err := ParseTemplate("test", "{{range .data}")
+	assert.Error(t, err)
+}
+
+// ============================================================================
+// format.go tests
+// ============================================================================
+
+const formatTestBody = `{"count":2,"data":[` +
+	`{"id":"ot_1","detailed_status":"completed","signer_info":{"email":"a@example.com"},"time":1700000000},` +
+	`{"id":"ot_2","detailed_status":"sent_to_signer","signer_info":{"email":"b@example.com"},"time":1700000001}]}`
+
+func TestFormatOutput_JSON(t *testing.T) {
+	result, err := FormatOutput([]byte(`{"b":1,"a":"x"}`), FormatJSON)
 
 	require.NoError(t, err)
-	assert.NotContains(t, req.FormData, "scope=")
+	assert.Equal(t, "{\n  \"a\": \"x\",\n  \"b\": 1\n}", result)
 }
 
-func TestParseOAuthTokenResponse_Success(t *testing.T) {
-	response := map[string]any{
-		"access_token": "test-access-token",
-		"token_type":   "Bearer",
-		"expires_in":   3600,
-		"scope":        "read write",
-	}
-	responseBody, _ := json.Marshal(response)
+func TestFormatOutput_YAML(t *testing.T) {
+	result, err := FormatOutput([]byte(`{"id":"ot_1","time":1700000000,"tags":["a"]}`), FormatYAML)
 
-	token, err := ParseOAuthTokenResponse(responseBody)
+	require.NoError(t, err)
+	assert.Contains(t, result, "id: ot_1")
+	assert.Contains(t, result, "time: 1700000000")
+	assert.Contains(t, result, "- a")
+}
+
+func TestFormatOutput_NDJSON(t *testing.T) {
+	result, err := FormatOutput([]byte(formatTestBody), FormatNDJSON)
 
 	require.NoError(t, err)
-	assert.Equal(t, "test-access-token", token.AccessToken)
-	assert.Equal(t, "Bearer", token.TokenType)
-	assert.Equal(t, 3600, token.ExpiresIn)
-	assert.Equal(t, "read write", token.Scope)
-	// ExpiresAt should be set to approximately now + 3600 seconds
-	assert.WithinDuration(t, time.Now().Add(3600*time.Second), token.ExpiresAt, 5*time.Second)
+	lines := strings.Split(result, "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], `{"detailed_status":"completed"`))
 }
 
-func TestParseOAuthTokenResponse_InvalidJSON(t *testing.T) {
-	_, err := ParseOAuthTokenResponse([]byte("invalid json"))
+func TestFormatOutput_TableWithColumns(t *testing.T) {
+	result, err := FormatOutput([]byte(formatTestBody), FormatTable, "id", "detailed_status", "signer_info.email")
+
+	require.NoError(t, err)
+	lines := strings.Split(result, "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"ID", "DETAILED_STATUS", "SIGNER_INFO.EMAIL"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"ot_1", "completed", "a@example.com"}, strings.Fields(lines[1]))
+}
+
+func TestFormatOutput_CSVFlattensNestedFields(t *testing.T) {
+	result, err := FormatOutput([]byte(formatTestBody), FormatCSV)
+
+	require.NoError(t, err)
+	lines := strings.Split(result, "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "detailed_status,id,signer_info.email,time", lines[0])
+	assert.Equal(t, "completed,ot_1,a@example.com,1700000000", lines[1])
+}
+
+func TestFormatOutput_GoValue(t *testing.T) {
+	result, err := FormatOutput([]map[string]any{{"id": "n_1"}}, FormatCSV)
+
+	require.NoError(t, err)
+	assert.Equal(t, "id\nn_1", result)
+}
 
+func TestFormatOutput_UnsupportedFormat(t *testing.T) {
+	_, err := FormatOutput([]byte(`{}`), "xml")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "error parsing OAuth token")
 }
 
-func TestOAuthToken_IsExpired(t *testing.T) {
-	tests := []struct {
-		name      string
-		expiresAt time.Time
-		expected  bool
-	}{
-		{
-			name:      "expired token",
-			expiresAt: time.Now().Add(-1 * time.Hour),
-			expected:  true,
-		},
-		{
-			name:      "token expiring soon (within 5 min buffer)",
-			expiresAt: time.Now().Add(3 * time.Minute),
-			expected:  true,
-		},
-		{
-			name:      "valid token",
-			expiresAt: time.Now().Add(1 * time.Hour),
-			expected:  false,
-		},
-		{
-			name:      "token at exactly 5 min boundary",
-			expiresAt: time.Now().Add(5 * time.Minute),
-			expected:  true, // buffer is >= 5 minutes
-		},
-		{
-			name:      "token just past 5 min boundary",
-			expiresAt: time.Now().Add(6 * time.Minute),
-			expected:  false,
-		},
-	}
+func TestRecords(t *testing.T) {
+	assert.Len(t, Records([]any{1, 2}), 2)
+	assert.Len(t, Records(map[string]any{"Resources": []any{1, 2, 3}}), 3)
+	assert.Len(t, Records(map[string]any{"templates": []any{1}, "total_count": 1}), 1)
+	assert.Equal(t, []any{map[string]any{"id": "x"}}, Records(map[string]any{"id": "x"}))
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			token := &OAuthToken{
-				AccessToken: "test-token",
-				ExpiresAt:   tt.expiresAt,
-			}
-			assert.Equal(t, tt.expected, token.IsExpired())
-		})
+func TestRecords_SingleObjectWithOneArray(t *testing.T) {
+	body := `{"id":"ot_1","detailed_status":"sent","documents":[{"id":"d1"},{"id":"d2"}]}`
+
+	value, err := normalizeJSON([]byte(body))
+	require.NoError(t, err)
+	records := Records(value)
+	require.Len(t, records, 1, "a record's own array is not a list envelope")
+
+	result, err := FormatOutput([]byte(body), FormatTable, "id", "detailed_status")
+	require.NoError(t, err)
+	lines := strings.Split(result, "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"ot_1", "sent"}, strings.Fields(lines[1]))
+
+	selected, err := SelectFields([]byte(body), []string{"id"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"ot_1"}`, string(selected))
+}
+
+func TestLookupPath(t *testing.T) {
+	value := map[string]any{
+		"signer": map[string]any{"email": "a@example.com"},
+		"docs":   []any{map[string]any{"id": "d1"}},
 	}
+
+	email, ok := LookupPath(value, "signer.email")
+	assert.True(t, ok)
+	assert.Equal(t, "a@example.com", email)
+
+	id, ok := LookupPath(value, "docs.0.id")
+	assert.True(t, ok)
+	assert.Equal(t, "d1", id)
+
+	_, ok = LookupPath(value, "signer.phone")
+	assert.False(t, ok)
+}
+
+func TestRecordWriter_JSONStreamsArray(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRecordWriter(&buf, FormatJSON)
+	require.NoError(t, err)
+
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "a"}}))
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "b"}, map[string]any{"id": "c"}}))
+	require.NoError(t, rw.Close())
+
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Len(t, decoded, 3)
+	assert.Equal(t, "c", decoded[2]["id"])
+}
+
+func TestRecordWriter_EmptyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRecordWriter(&buf, FormatJSON)
+	require.NoError(t, err)
+
+	require.NoError(t, rw.Close())
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestRecordWriter_TableHeaderOnce(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRecordWriter(&buf, FormatTable, "id")
+	require.NoError(t, err)
+
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "a"}}))
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "b"}}))
+	require.NoError(t, rw.Close())
+	assert.Equal(t, "ID\na\nb\n", buf.String())
+}
+
+func TestRecordWriter_TableAlignedAcrossBatches(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRecordWriter(&buf, FormatTable, "id", "status")
+	require.NoError(t, err)
+
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "a", "status": "sent"}}))
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "ot_123456", "status": "completed"}}))
+	require.NoError(t, rw.Close())
+	assert.Equal(t, "ID         STATUS\na          sent\not_123456  completed\n", buf.String())
+}
+
+func TestRecordWriter_CSVColumnsFromFirstBatch(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRecordWriter(&buf, FormatCSV)
+	require.NoError(t, err)
+
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "a"}}))
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "b", "n": 2}}))
+	assert.Equal(t, "id\na\nb\n", buf.String(), "later fields need explicit columns")
+}
+
+func TestRecordWriter_CSVHeaderOnce(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRecordWriter(&buf, FormatCSV)
+	require.NoError(t, err)
+
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "a", "n": 1}}))
+	require.NoError(t, rw.Write([]any{map[string]any{"id": "b", "n": 2}}))
+	assert.Equal(t, "id,n\na,1\nb,2\n", buf.String())
+}
+
+func TestRecordWriter_UnsupportedFormat(t *testing.T) {
+	_, err := NewRecordWriter(io.Discard, "xml")
+	assert.Error(t, err)
 }
 
 // ============================================================================
-// config.go tests - using temp directory
+// paginate.go tests
 // ============================================================================
 
-// setupTestConfigDir creates a temp directory and sets HOME to use it
-func setupTestConfigDir(t *testing.T) (string, func()) {
-	t.Helper()
-
-	// Create temp directory
-	tempDir, err := os.MkdirTemp("", "proof-cli-test")
+// offsetPages serves total items in pages of at most limit, as an offset-paged endpoint would
+func offsetPages(total int, withCount bool, requests *[]PageRequest) FetchPage {
+	return func(_ context.Context, req PageRequest) ([]byte, error) {
+		*requests = append(*requests, req)
+		data := []any{}
+		for i := req.Offset; i < total && i < req.Offset+req.Limit; i++ {
+			data = append(data, map[string]any{"id": i})
+		}
+		page := map[string]any{"data": data, "count": len(data)}
+		if withCount {
+			page["total_count"] = total
+		}
+		return json.Marshal(page)
+	}
+}
+
+// collect runs p and returns the ids of every record it produced
+func collect(t *testing.T, p *Paginator) []int {
+	var ids []int
+	err := p.Each(context.Background(), func(records []any) error {
+		for _, record := range records {
+			id, _ := LookupPath(record, "id")
+			ids = append(ids, int(id.(int64)))
+		}
+		return nil
+	})
 	require.NoError(t, err)
+	return ids
+}
 
-	// Save original HOME
-	originalHome := os.Getenv("HOME")
+func TestPaginator_OffsetStopsOnShortPage(t *testing.T) {
+	var requests []PageRequest
+	p := &Paginator{Style: PageOffset, First: PageRequest{Limit: 4}, Fetch: offsetPages(10, false, &requests)}
 
-	// Set HOME to temp directory
-	os.Setenv("HOME", tempDir)
+	ids := collect(t, p)
+	assert.Len(t, ids, 10)
+	assert.Equal(t, 9, ids[9])
+	assert.Len(t, requests, 3)
+	assert.Equal(t, 8, requests[2].Offset)
+}
 
-	// Return cleanup function
-	cleanup := func() {
-		os.Setenv("HOME", originalHome)
-		os.RemoveAll(tempDir)
+func TestPaginator_OffsetUsesTotalCount(t *testing.T) {
+	var requests []PageRequest
+	p := &Paginator{Style: PageOffset, First: PageRequest{Limit: 5}, Fetch: offsetPages(10, true, &requests)}
+
+	ids := collect(t, p)
+	assert.Len(t, ids, 10)
+	// total_count ends the walk without requesting an empty page
+	assert.Len(t, requests, 2)
+}
+
+func TestPaginator_MaxItems(t *testing.T) {
+	var requests []PageRequest
+	p := &Paginator{Style: PageOffset, First: PageRequest{Offset: 2, Limit: 4}, MaxItems: 6, Fetch: offsetPages(100, true, &requests)}
+
+	ids := collect(t, p)
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7}, ids)
+	require.Len(t, requests, 2)
+	assert.Equal(t, 2, requests[1].Limit, "last page only asks for what is left")
+}
+
+func TestPaginator_SCIM(t *testing.T) {
+	var requests []PageRequest
+	fetch := func(_ context.Context, req PageRequest) ([]byte, error) {
+		requests = append(requests, req)
+		resources := []any{}
+		for i := req.Offset; i <= 5 && i < req.Offset+req.Limit; i++ {
+			resources = append(resources, map[string]any{"id": i})
+		}
+		return json.Marshal(map[string]any{"totalResults": 5, "startIndex": req.Offset, "Resources": resources})
+	}
+	p := &Paginator{Style: PageSCIM, First: PageRequest{Offset: 1, Limit: 2}, Fetch: fetch}
+
+	ids := collect(t, p)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+	assert.Len(t, requests, 3)
+	assert.Equal(t, 5, requests[2].Offset)
+}
+
+func TestPaginator_Cursor(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"data":[{"id":1},{"id":2}],"meta":{"next_cursor":"c1","has_more":true}}`,
+		"c1": `{"data":[{"id":3}],"meta":{"next_cursor":"c2","has_more":false}}`,
 	}
+	var cursors []string
+	fetch := func(_ context.Context, req PageRequest) ([]byte, error) {
+		cursors = append(cursors, req.Cursor)
+		return []byte(pages[req.Cursor]), nil
+	}
+	p := &Paginator{Style: PageCursor, First: PageRequest{Limit: 2}, Fetch: fetch}
+
+	ids := collect(t, p)
+	assert.Equal(t, []int{1, 2, 3}, ids)
+	assert.Equal(t, []string{"", "c1"}, cursors)
+}
+
+func TestPaginator_FetchError(t *testing.T) {
+	p := &Paginator{
+		Style: PageOffset,
+		First: PageRequest{Limit: 1},
+		Fetch: func(context.Context, PageRequest) ([]byte, error) { return nil, assert.AnError },
+	}
+
+	err := p.Each(context.Background(), func([]any) error { return nil })
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestPaginator_RequiresPageSize(t *testing.T) {
+	p := &Paginator{Fetch: func(context.Context, PageRequest) ([]byte, error) { return nil, nil }}
+	assert.Error(t, p.Each(context.Background(), func([]any) error { return nil }))
+}
+
+// ============================================================================
+// retry.go tests
+// ============================================================================
+
+// scriptedTransport answers requests with the given statuses in turn, recording each request body
+type scriptedTransport struct {
+	statuses []int
+	headers  http.Header
+	err      error
+	bodies   []string
+}
+
+func (s *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		data, _ := io.ReadAll(req.Body)
+		body = string(data)
+	}
+	s.bodies = append(s.bodies, body)
+	if s.err != nil {
+		return nil, s.err
+	}
+	status := s.statuses[min(len(s.bodies), len(s.statuses))-1]
+	resp := mockJSONResponse(status, map[string]string{})
+	for k, v := range s.headers {
+		resp.Header[k] = v
+	}
+	return resp, nil
+}
+
+// newTestRetryTransport returns a RetryTransport that records its delays instead of sleeping
+func newTestRetryTransport(base http.RoundTripper, retries int) (*RetryTransport, *[]time.Duration) {
+	var delays []time.Duration
+	rt := NewRetryTransport(base, RetryPolicy{MaxRetries: retries, Wait: 100 * time.Millisecond, MaxWait: 10 * time.Second})
+	rt.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return rt, &delays
+}
+
+func TestRetryTransport_RetriesTransientStatus(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{502, 503, 200}}
+	rt, delays := newTestRetryTransport(base, 3)
+
+	req, _ := http.NewRequest("GET", "https://api.proof.com/v1/transactions", nil)
+	resp, err := rt.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Len(t, base.bodies, 3)
+	require.Len(t, *delays, 2)
+	// Jittered exponential backoff: within the upper half of 100ms, then of 200ms
+	assert.True(t, (*delays)[0] >= 50*time.Millisecond && (*delays)[0] <= 100*time.Millisecond)
+	assert.True(t, (*delays)[1] >= 100*time.Millisecond && (*delays)[1] <= 200*time.Millisecond)
+}
+
+func TestRetryTransport_GivesUpAfterMaxRetries(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{503}}
+	rt, _ := newTestRetryTransport(base, 2)
+
+	req, _ := http.NewRequest("GET", "https://api.proof.com/v1/transactions", nil)
+	resp, err := rt.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+	assert.Len(t, base.bodies, 3)
+}
+
+func TestRetryTransport_HonorsRetryAfter(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{429, 200}, headers: http.Header{"Retry-After": {"2"}}}
+	rt, delays := newTestRetryTransport(base, 3)
+
+	req, _ := http.NewRequest("GET", "https://api.proof.com/v1/transactions", nil)
+	_, err := rt.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{2 * time.Second}, *delays)
+}
+
+func TestRetryTransport_RetryAfterBeyondMaxWait(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{429, 200}, headers: http.Header{"Retry-After": {"3600"}}}
+	rt, _ := newTestRetryTransport(base, 3)
+
+	req, _ := http.NewRequest("GET", "https://api.proof.com/v1/transactions", nil)
+	resp, err := rt.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Len(t, base.bodies, 1)
+}
+
+func TestRetryTransport_DoesNotRetryClientErrors(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{400, 200}}
+	rt, _ := newTestRetryTransport(base, 3)
+
+	req, _ := http.NewRequest("GET", "https://api.proof.com/v1/transactions", nil)
+	resp, err := rt.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	assert.Len(t, base.bodies, 1)
+}
+
+func TestRetryTransport_RetriesConnectionErrors(t *testing.T) {
+	base := &scriptedTransport{err: io.ErrUnexpectedEOF}
+	rt, _ := newTestRetryTransport(base, 2)
+
+	req, _ := http.NewRequest("DELETE", "https://api.proof.com/v1/webhooks/wh_1", nil)
+	_, err := rt.RoundTrip(req)
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Len(t, base.bodies, 3)
+}
+
+func TestRetryTransport_PostRequiresOptIn(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{503, 200}}
+	rt, _ := newTestRetryTransport(base, 3)
+
+	req, _ := http.NewRequest("POST", "https://api.proof.com/v1/transactions", strings.NewReader(`{"a":1}`))
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+	assert.Len(t, base.bodies, 1)
+
+	base = &scriptedTransport{statuses: []int{503, 200}}
+	rt, _ = newTestRetryTransport(base, 3)
+	req, _ = http.NewRequestWithContext(WithRetryUnsafe(context.Background()), "POST", "https://api.proof.com/v1/transactions", strings.NewReader(`{"a":1}`))
+	resp, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	// The body is replayed on every attempt
+	assert.Equal(t, []string{`{"a":1}`, `{"a":1}`}, base.bodies)
+}
+
+func TestRetryTransport_IdempotencyKeyAllowsPost(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{502, 201}}
+	rt, _ := newTestRetryTransport(base, 3)
+
+	req, _ := http.NewRequest("POST", "https://api.proof.com/v1/transactions", strings.NewReader(`{}`))
+	req.Header.Set("Idempotency-Key", "abc")
+	resp, err := rt.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+}
+
+func TestConfig_RetryPolicy(t *testing.T) {
+	policy := (&Config{}).RetryPolicy()
+	assert.Equal(t, DefaultMaxRetries, policy.MaxRetries)
+	assert.Equal(t, DefaultRetryWait, policy.Wait)
+
+	zero := 0
+	policy = (&Config{Retry: &RetryConfig{MaxRetries: &zero, Wait: time.Second}}).RetryPolicy()
+	assert.Equal(t, 0, policy.MaxRetries)
+	assert.Equal(t, time.Second, policy.Wait)
+}
+
+func TestProofClient_Request_RetriesThroughSharedTransport(t *testing.T) {
+	base := &scriptedTransport{statuses: []int{503, 200}}
+	client := &ProofClient{
+		config:     &Config{APIEndpoint: "https://api.proof.com"},
+		httpClient: &http.Client{Transport: base},
+		apiKey:     "test-api-key",
+	}
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 2, Wait: time.Millisecond, MaxWait: time.Millisecond})
+
+	_, err := client.Get("/test")
+	require.NoError(t, err)
+	assert.Len(t, base.bodies, 2)
+
+	base.bodies = nil
+	_, err = client.Post("/test", map[string]string{"a": "b"})
+	assert.Error(t, err, "POST is not retried without opting in")
+	assert.Len(t, base.bodies, 1)
+
+	base.bodies = nil
+	_, err = client.Post("/test", map[string]string{"a": "b"}, &RequestOptions{RetryUnsafe: true})
+	require.NoError(t, err)
+	assert.Len(t, base.bodies, 2)
+}
+
+// ============================================================================
+// ratelimit.go tests
+// ============================================================================
+
+// fakeClock is a manually advanced clock for TokenBucket tests
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestBucket(rps float64, burst int) (*TokenBucket, *fakeClock, *[]time.Duration) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	var waits []time.Duration
+	b := NewTokenBucket(rps, burst)
+	b.now = clock.Now
+	b.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return b, clock, &waits
+}
+
+func TestTokenBucket_BurstThenPaced(t *testing.T) {
+	b, _, waits := newTestBucket(2, 2)
+
+	for range 4 {
+		require.NoError(t, b.Wait(context.Background()))
+	}
+
+	// Two requests fit in the burst, then they queue at 2 per second
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second}, *waits)
+}
+
+func TestTokenBucket_Refills(t *testing.T) {
+	b, clock, waits := newTestBucket(1, 1)
+
+	require.NoError(t, b.Wait(context.Background()))
+	clock.now = clock.now.Add(time.Second)
+	require.NoError(t, b.Wait(context.Background()))
+	clock.now = clock.now.Add(time.Hour)
+	require.NoError(t, b.Wait(context.Background()))
+
+	assert.Empty(t, *waits, "a refilled bucket never exceeds its burst")
+}
+
+func TestRateLimitTransport_Concurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	release := make(chan struct{})
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return mockJSONResponse(200, map[string]string{}), nil
+	})
+	rt := NewRateLimitTransport(base, RateLimit{Concurrency: 2})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "https://api.proof.com/test", nil)
+			_, _ = rt.RoundTrip(req)
+		}()
+	}
+	// Two requests get through and the rest wait for a free slot
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return inFlight == 2
+	}, time.Second, time.Millisecond)
+	for range 5 {
+		release <- struct{}{}
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, peak)
+}
+
+func TestRateLimitTransport_ContextCancelled(t *testing.T) {
+	rt := NewRateLimitTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return mockJSONResponse(200, map[string]string{}), nil
+	}), RateLimit{RPS: 0.001})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.proof.com/test", nil)
+	_, err := rt.RoundTrip(req)
+	require.NoError(t, err, "the first request uses the initial token")
+
+	cancel()
+	_, err = rt.RoundTrip(req)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestProofClient_SetRateLimit_SharedAcrossClients(t *testing.T) {
+	client := &ProofClient{
+		config:     &Config{APIEndpoint: "https://api.proof.com"},
+		httpClient: &http.Client{Transport: &MockRoundTripper{Response: mockJSONResponse(200, map[string]string{})}},
+	}
+	client.SetRateLimit(RateLimit{RPS: 5, Concurrency: 3})
+
+	assert.Equal(t, RateLimit{RPS: 5, Concurrency: 3}, client.RateLimit())
+	// Every SDK client uses HTTPClient(), so they all share the one limiter
+	assert.Same(t, client.HTTPClient(), client.HTTPClient())
+	assert.IsType(t, &RateLimitTransport{}, client.HTTPClient().Transport)
+}
+
+func TestConfig_RequestLimits(t *testing.T) {
+	assert.Equal(t, RateLimit{}, (&Config{}).RequestLimits())
+	assert.Equal(t, RateLimit{RPS: 2}, (&Config{RateLimit: &RateLimit{RPS: 2}}).RequestLimits())
+}
+
+// ============================================================================
+// trace.go tests
+// ============================================================================
+
+// newTraceLogger returns a debug logger writing plain messages to buf
+func newTraceLogger(buf *bytes.Buffer) *log.Logger {
+	logger := log.New()
+	logger.Out = buf
+	logger.Level = log.DebugLevel
+	logger.Formatter = &log.TextFormatter{DisableQuote: true, DisableTimestamp: true}
+	return logger
+}
+
+func TestTraceTransport_RedactsCredentials(t *testing.T) {
+	var buf bytes.Buffer
+	base := &MockRoundTripper{Response: mockJSONResponse(200, map[string]string{"access_token": "secret-token", "id": "ot_1"})}
+	rt := NewTraceTransport(base, newTraceLogger(&buf))
+
+	req, _ := http.NewRequest("POST", "https://api.proof.com/v1/transactions", strings.NewReader(`{"signer":{"email":"a@example.com"}}`))
+	req.Header.Set("ApiKey", "super-secret-key")
+	req.Header.Set("Authorization", "Bearer abc")
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "> POST https://api.proof.com/v1/transactions")
+	assert.Contains(t, out, "> Apikey: [REDACTED]")
+	assert.Contains(t, out, "> Authorization: [REDACTED]")
+	assert.Contains(t, out, `{"signer":{"email":"a@example.com"}}`)
+	assert.Contains(t, out, "< 200")
+	assert.Contains(t, out, `"access_token":"[REDACTED]"`)
+	assert.NotContains(t, out, "super-secret-key")
+	assert.NotContains(t, out, "secret-token")
+
+	// The caller still receives the full body
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "secret-token")
+}
+
+func TestTraceTransport_RequestBodyStillSent(t *testing.T) {
+	var buf bytes.Buffer
+	var sent string
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		data, _ := io.ReadAll(req.Body)
+		sent = string(data)
+		return mockJSONResponse(200, map[string]string{}), nil
+	})
+	rt := NewTraceTransport(base, newTraceLogger(&buf))
+
+	// A reader without GetBody has to be peeked and put back
+	req, _ := http.NewRequest("PUT", "https://api.proof.com/v1/test", io.NopCloser(strings.NewReader(`{"a":1}`)))
+	_, err := rt.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, sent)
+	assert.Contains(t, buf.String(), `{"a":1}`)
+}
+
+func TestTraceTransport_LogsFailures(t *testing.T) {
+	var buf bytes.Buffer
+	rt := NewTraceTransport(&MockRoundTripper{Err: io.ErrUnexpectedEOF}, newTraceLogger(&buf))
+
+	req, _ := http.NewRequest("GET", "https://api.proof.com/v1/test", nil)
+	_, err := rt.RoundTrip(req)
+
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "failed after")
+}
+
+func TestRedactBody(t *testing.T) {
+	document := strings.Repeat("QUJD", 100)
+	out := RedactBody([]byte(`{"documents":[{"resource":"`+document+`","filename":"a.pdf"}],"n":12345678901234567890}`), "application/json")
+	assert.Contains(t, out, "[base64, 400 bytes]")
+	assert.Contains(t, out, `"filename":"a.pdf"`)
+	assert.Contains(t, out, "12345678901234567890", "numbers are kept exactly")
+	assert.NotContains(t, out, document)
+
+	assert.Equal(t, "client_secret=%5BREDACTED%5D&grant_type=client_credentials",
+		RedactBody([]byte("grant_type=client_credentials&client_secret=s3cret"), "application/x-www-form-urlencoded"))
+
+	assert.Equal(t, "[application/pdf body, 4 bytes]", RedactBody([]byte("%PDF"), "application/pdf"))
+
+	long := strings.Repeat("x", traceTextLimit+10)
+	assert.Contains(t, RedactBody([]byte(long), "text/plain"), "[truncated after")
+}
+
+func TestProofClient_EnableTracing(t *testing.T) {
+	var buf bytes.Buffer
+	client := &ProofClient{
+		config:     &Config{APIEndpoint: "https://api.proof.com"},
+		httpClient: &http.Client{Transport: &MockRoundTripper{Response: mockJSONResponse(200, map[string]string{})}},
+		apiKey:     "test-api-key",
+	}
+	client.EnableTracing(newTraceLogger(&buf))
+
+	_, err := client.Get("/v1/test")
+
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "> GET https://api.proof.com/v1/test")
+	assert.NotContains(t, buf.String(), "test-api-key")
+}
+
+// ============================================================================
+// dryrun.go tests
+// ============================================================================
+
+func TestCurlCommand(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.proof.com/v1/transactions", nil)
+	req.Header.Set("ApiKey", APIKeyPlaceholder)
+	req.Header.Set("Content-Type", "application/json")
+
+	out := CurlCommand(req, []byte(`{"name":"O'Neil"}`))
+
+	assert.Equal(t, `curl -X POST 'https://api.proof.com/v1/transactions' \
+  -H "Apikey: $PROOF_API_KEY" \
+  -H 'Content-Type: application/json' \
+  --data-raw '{"name":"O'\''Neil"}'`, out)
+}
+
+func TestCurlCommand_BinaryBody(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "https://api.proof.com/v1/documents/d_1", nil)
+
+	out := CurlCommand(req, []byte{0xff, 0xfe, 0x00})
+
+	assert.Contains(t, out, "--data-binary @request-body.bin")
+}
+
+func TestDryRunTransport(t *testing.T) {
+	var buf bytes.Buffer
+	rt := &DryRunTransport{Out: &buf}
+
+	req, _ := http.NewRequest("PATCH", "https://api.proof.com/v1/test", strings.NewReader(`{"a":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := rt.RoundTrip(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(DryRunHeader))
+	assert.Equal(t, "PATCH https://api.proof.com/v1/test\nContent-Type: application/json\n\n{\n  \"a\": 1\n}\n", buf.String())
+}
+
+func TestProofClient_EnableDryRun(t *testing.T) {
+	var buf bytes.Buffer
+	base := &MockRoundTripper{Response: mockJSONResponse(200, map[string]string{})}
+	limiter := NewRateLimitTransport(base, RateLimit{})
+	client := &ProofClient{
+		config:     &Config{APIEndpoint: "https://api.proof.com"},
+		httpClient: &http.Client{Transport: NewRetryTransport(limiter, RetryPolicy{MaxRetries: 3})},
+		limiter:    limiter,
+		apiKey:     "test-api-key",
+	}
+	client.EnableDryRun(&buf, true)
+
+	body, err := client.Delete("/v1/transactions/ot_1")
+
+	require.NoError(t, err)
+	assert.Empty(t, body)
+	assert.Nil(t, base.LastReq, "nothing is sent")
+	assert.Equal(t, 1, strings.Count(buf.String(), "curl "), "a dry run is never retried")
+	assert.Contains(t, buf.String(), `-H "Apikey: $PROOF_API_KEY"`)
+	assert.NotContains(t, buf.String(), "test-api-key")
+}
+
+func TestProofClient_EnableDryRun_OAuthSkipsTokenFetch(t *testing.T) {
+	var buf bytes.Buffer
+	client := &ProofClient{
+		config: &Config{
+			APIEndpoint: "https://api.proof.com",
+			OAuth:       &OAuthConfig{Enabled: true, ClientID: "id", ClientSecret: "secret"},
+		},
+		httpClient: &http.Client{Transport: &MockRoundTripper{Err: io.ErrUnexpectedEOF}},
+	}
+	client.EnableDryRun(&buf, false)
+
+	_, err := client.Get("/v1/test")
+
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Authorization: Bearer $PROOF_ACCESS_TOKEN")
+}
+
+func TestProofClient_EnableDryRun_KeepsTracing(t *testing.T) {
+	var out, trace bytes.Buffer
+	base := &MockRoundTripper{Response: mockJSONResponse(200, map[string]string{})}
+	limiter := NewRateLimitTransport(base, RateLimit{})
+	client := &ProofClient{
+		config:     &Config{APIEndpoint: "https://api.proof.com"},
+		httpClient: &http.Client{Transport: NewRetryTransport(limiter, RetryPolicy{})},
+		limiter:    limiter,
+	}
+	client.EnableTracing(newTraceLogger(&trace))
+	client.EnableDryRun(&out, false)
+
+	_, err := client.Get("/v1/test")
+	require.NoError(t, err)
+	_, err = client.Get("/v1/other")
+	require.NoError(t, err)
+
+	assert.Nil(t, base.LastReq, "nothing is sent")
+	assert.Contains(t, out.String(), "GET https://api.proof.com/v1/other")
+	assert.Contains(t, trace.String(), "> GET https://api.proof.com/v1/test")
+	assert.Contains(t, trace.String(), "> GET https://api.proof.com/v1/other")
+}
+
+// ============================================================================
+// cassette.go tests
+// ============================================================================
+
+func TestRecordTransport_ScrubsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	base := &MockRoundTripper{Response: mockJSONResponse(200, map[string]string{"access_token": "secret-token", "id": "ot_1"})}
+	rt, err := NewRecordTransport(base, path, "super-secret-key")
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "https://api.proof.com/v1/transactions?api_key=abc", strings.NewReader(`{"note":"key super-secret-key","password":"hunter2"}`))
+	req.Header.Set("ApiKey", "super-secret-key")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+
+	// The caller still receives the real response
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "secret-token")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, secret := range []string{"super-secret-key", "secret-token", "hunter2", "api_key=abc"} {
+		assert.NotContains(t, string(data), secret)
+	}
+
+	cassette, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, cassette.Interactions, 1)
+	recorded := cassette.Interactions[0]
+	assert.Equal(t, "POST", recorded.Request.Method)
+	assert.Equal(t, "[REDACTED]", recorded.Request.Headers.Get("ApiKey"))
+	assert.Equal(t, 200, recorded.Response.StatusCode)
+	assert.Contains(t, recorded.Response.Body, `"id":"ot_1"`)
+}
+
+func TestRecordTransport_AppendsToExistingCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	for _, id := range []string{"ot_1", "ot_2"} {
+		rt, err := NewRecordTransport(&MockRoundTripper{Response: mockJSONResponse(200, map[string]string{"id": id})}, path)
+		require.NoError(t, err)
+		req, _ := http.NewRequest("GET", "https://api.proof.com/v1/transactions/"+id, nil)
+		_, err = rt.RoundTrip(req)
+		require.NoError(t, err)
+	}
+
+	cassette, err := LoadCassette(path)
+	require.NoError(t, err)
+	assert.Len(t, cassette.Interactions, 2)
+}
+
+func TestReplayTransport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := &Cassette{Interactions: []Interaction{
+		{
+			Request:  RecordedRequest{Method: "GET", URL: "https://api.proof.com/v1/transactions/ot_1"},
+			Response: RecordedResponse{StatusCode: 200, Body: `{"status":"started"}`},
+		},
+		{
+			Request:  RecordedRequest{Method: "GET", URL: "https://api.proof.com/v1/transactions/ot_1"},
+			Response: RecordedResponse{StatusCode: 200, Body: `{"status":"completed"}`},
+		},
+		{
+			Request:  RecordedRequest{Method: "GET", URL: "https://api.proof.com/v1/documents/d_1"},
+			Response: RecordedResponse{StatusCode: 200, Body: "JVBERi0=", Encoding: "base64"},
+		},
+	}}
+	require.NoError(t, cassette.Save(path))
+	rt, err := NewReplayTransport(path)
+	require.NoError(t, err)
+
+	get := func(path string) string {
+		req, _ := http.NewRequest("GET", "https://api.proof.com"+path, nil)
+		resp, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	// Repeated requests are answered in recorded order, then settle on the last
+	assert.Equal(t, `{"status":"started"}`, get("/v1/transactions/ot_1"))
+	assert.Equal(t, `{"status":"completed"}`, get("/v1/transactions/ot_1"))
+	assert.Equal(t, `{"status":"completed"}`, get("/v1/transactions/ot_1"))
+	assert.Equal(t, "%PDF-", get("/v1/documents/d_1"))
+
+	req, _ := http.NewRequest("DELETE", "https://api.proof.com/v1/transactions/ot_1", nil)
+	_, err = rt.RoundTrip(req)
+	assert.ErrorIs(t, err, ErrCassetteMiss)
+}
+
+func TestReplayTransport_PrefersMatchingBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := &Cassette{Interactions: []Interaction{
+		{
+			Request:  RecordedRequest{Method: "POST", URL: "https://api.proof.com/v1/notaries", Body: `{"email":"a@example.com"}`},
+			Response: RecordedResponse{StatusCode: 200, Body: `{"id":"n_a"}`},
+		},
+		{
+			Request:  RecordedRequest{Method: "POST", URL: "https://api.proof.com/v1/notaries", Body: `{"email":"b@example.com"}`},
+			Response: RecordedResponse{StatusCode: 200, Body: `{"id":"n_b"}`},
+		},
+	}}
+	require.NoError(t, cassette.Save(path))
+	rt, err := NewReplayTransport(path)
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "https://api.proof.com/v1/notaries", strings.NewReader(`{ "email": "b@example.com" }`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"id":"n_b"}`, string(body))
+}
+
+func TestProofClient_RecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	base := &MockRoundTripper{Response: mockJSONResponse(200, map[string]string{"id": "ot_1"})}
+	recorder := &ProofClient{
+		config:     &Config{APIEndpoint: "https://api.proof.com"},
+		httpClient: &http.Client{Transport: base},
+		apiKey:     "test-api-key",
+	}
+	require.NoError(t, recorder.EnableRecording(path))
+	recorded, err := recorder.Get("/v1/transactions/ot_1")
+	require.NoError(t, err)
+
+	data, _ := os.ReadFile(path)
+	assert.NotContains(t, string(data), "test-api-key")
+
+	// Replaying needs no credentials and never touches the network
+	replayer := &ProofClient{
+		config:     &Config{APIEndpoint: "https://api.proof.com", OAuth: &OAuthConfig{Enabled: true}},
+		httpClient: &http.Client{Transport: &MockRoundTripper{Err: io.ErrUnexpectedEOF}},
+	}
+	require.NoError(t, replayer.EnableReplay(path))
+	replayed, err := replayer.Get("/v1/transactions/ot_1")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(recorded), string(replayed))
+}
+
+func TestNewProofClient_RecordAndReplayConflict(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("PROOF_RECORD", filepath.Join(tempDir, "a.json"))
+	t.Setenv("PROOF_REPLAY", filepath.Join(tempDir, "b.json"))
+
+	_, err := NewProofClient()
+
+	assert.ErrorContains(t, err, "cannot both be set")
+}
+
+// ============================================================================
+// oauth.go tests
+// ============================================================================
+
+func TestPrepareOAuthTokenRequest_Success(t *testing.T) {
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		OAuth: &OAuthConfig{
+			Enabled:      true,
+			ClientID:     "test-client-id",
+			ClientSecret: "test-client-secret",
+			Scope:        "read write",
+		},
+	}
+
+	req, err := PrepareOAuthTokenRequest(config)
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.proof.com/oauth/v2/token", req.URL)
+	assert.Contains(t, req.FormData, "grant_type=client_credentials")
+	assert.Contains(t, req.FormData, "scope=read+write")
+	assert.Equal(t, "application/x-www-form-urlencoded", req.Headers["Content-Type"])
+	assert.Equal(t, "application/json", req.Headers["Accept"])
+	assert.Contains(t, req.Headers["Authorization"], "Basic ")
+}
+
+func TestPrepareOAuthTokenRequest_OAuthDisabled(t *testing.T) {
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		OAuth: &OAuthConfig{
+			Enabled: false,
+		},
+	}
+
+	_, err := PrepareOAuthTokenRequest(config)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "OAuth not enabled")
+}
+
+func TestPrepareOAuthTokenRequest_NilOAuth(t *testing.T) {
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		OAuth:       nil,
+	}
+
+	_, err := PrepareOAuthTokenRequest(config)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "OAuth not enabled")
+}
+
+func TestPrepareOAuthTokenRequest_MissingCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{
+			name: "missing client ID",
+			config: &Config{
+				APIEndpoint: "https://api.proof.com",
+				OAuth: &OAuthConfig{
+					Enabled:      true,
+					ClientID:     "",
+					ClientSecret: "secret",
+				},
+			},
+		},
+		{
+			name: "missing client secret",
+			config: &Config{
+				APIEndpoint: "https://api.proof.com",
+				OAuth: &OAuthConfig{
+					Enabled:      true,
+					ClientID:     "client-id",
+					ClientSecret: "",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := PrepareOAuthTokenRequest(tt.config)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "client ID and secret are required")
+			assert.Equal(t, clierr.KindAuth, clierr.From(err, "").Kind)
+		})
+	}
+}
+
+func TestPrepareOAuthTokenRequest_NoScope(t *testing.T) {
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		OAuth: &OAuthConfig{
+			Enabled:      true,
+			ClientID:     "test-client-id",
+			ClientSecret: "test-client-secret",
+			Scope:        "",
+		},
+	}
+
+	req, err := PrepareOAuthTokenRequest(config)
+
+	require.NoError(t, err)
+	assert.NotContains(t, req.FormData, "scope=")
+}
+
+func TestParseOAuthTokenResponse_Success(t *testing.T) {
+	response := map[string]any{
+		"access_token": "test-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"scope":        "read write",
+	}
+	responseBody, _ := json.Marshal(response)
+
+	token, err := ParseOAuthTokenResponse(responseBody)
+
+	require.NoError(t, err)
+	assert.Equal(t, "test-access-token", token.AccessToken)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, 3600, token.ExpiresIn)
+	assert.Equal(t, "read write", token.Scope)
+	// ExpiresAt should be set to approximately now + 3600 seconds
+	assert.WithinDuration(t, time.Now().Add(3600*time.Second), token.ExpiresAt, 5*time.Second)
+}
+
+func TestParseOAuthTokenResponse_InvalidJSON(t *testing.T) {
+	_, err := ParseOAuthTokenResponse([]byte("invalid json"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error parsing OAuth token")
+}
+
+func TestOAuthToken_IsExpired(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		expected  bool
+	}{
+		{
+			name:      "expired token",
+			expiresAt: time.Now().Add(-1 * time.Hour),
+			expected:  true,
+		},
+		{
+			name:      "token expiring soon (within 5 min buffer)",
+			expiresAt: time.Now().Add(3 * time.Minute),
+			expected:  true,
+		},
+		{
+			name:      "valid token",
+			expiresAt: time.Now().Add(1 * time.Hour),
+			expected:  false,
+		},
+		{
+			name:      "token at exactly 5 min boundary",
+			expiresAt: time.Now().Add(5 * time.Minute),
+			expected:  true, // buffer is >= 5 minutes
+		},
+		{
+			name:      "token just past 5 min boundary",
+			expiresAt: time.Now().Add(6 * time.Minute),
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &OAuthToken{
+				AccessToken: "test-token",
+				ExpiresAt:   tt.expiresAt,
+			}
+			assert.Equal(t, tt.expected, token.IsExpired())
+		})
+	}
+}
+
+// ============================================================================
+// config.go tests - using temp directory
+// ============================================================================
+
+// setupTestConfigDir creates a temp directory and sets HOME to use it
+func setupTestConfigDir(t *testing.T) (string, func()) {
+	t.Helper()
+
+	// Create temp directory
+	tempDir, err := os.MkdirTemp("", "proof-cli-test")
+	require.NoError(t, err)
+
+	// Save original HOME
+	originalHome := os.Getenv("HOME")
+
+	// Set HOME to temp directory
+	os.Setenv("HOME", tempDir)
+
+	// Return cleanup function
+	cleanup := func() {
+		os.Setenv("HOME", originalHome)
+		os.RemoveAll(tempDir)
+	}
+
+	return tempDir, cleanup
+}
+
+func TestLoadConfig_CreatesDefaultConfig(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	config, err := LoadConfig()
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.proof.com", config.APIEndpoint)
+	assert.Equal(t, 30*time.Second, config.Timeout)
+}
+
+func TestSaveConfig_AndLoadConfig(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	// Save config
+	config := &Config{
+		APIEndpoint: "https://custom.api.com",
+		Timeout:     60 * time.Second,
+		OAuth: &OAuthConfig{
+			Enabled:      true,
+			ClientID:     "my-client-id",
+			ClientSecret: "my-secret",
+			Scope:        "read",
+		},
+		APIKey: "test-api-key",
+	}
+	err := SaveConfig(config)
+	require.NoError(t, err)
+
+	// Load config
+	loaded, err := LoadConfig()
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://custom.api.com", loaded.APIEndpoint)
+	assert.Equal(t, 60*time.Second, loaded.Timeout)
+	assert.True(t, loaded.OAuth.Enabled)
+	assert.Equal(t, "my-client-id", loaded.OAuth.ClientID)
+	assert.Equal(t, "my-secret", loaded.OAuth.ClientSecret)
+	assert.Equal(t, "read", loaded.OAuth.Scope)
+	assert.Equal(t, "test-api-key", loaded.APIKey)
+}
+
+func TestLoadConfig_FilePermissions(t *testing.T) {
+	tempDir, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	// Load config (creates default)
+	_, err := LoadConfig()
+	require.NoError(t, err)
+
+	// Check file permissions
+	configFile := filepath.Join(tempDir, ".proof-cli", "config.json")
+	info, err := os.Stat(configFile)
+	require.NoError(t, err)
+
+	// File should have restricted permissions (0600)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestGetAPIKey_FromEnvironment(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	// Set environment variable
+	os.Setenv("PROOF_API_KEY", "env-api-key")
+	defer os.Unsetenv("PROOF_API_KEY")
+
+	apiKey, err := GetAPIKey()
+
+	require.NoError(t, err)
+	assert.Equal(t, "env-api-key", apiKey)
+}
+
+func TestGetAPIKey_FromConfig(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	// Ensure env var is not set
+	os.Unsetenv("PROOF_API_KEY")
+
+	// Save config with API key
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		Timeout:     30 * time.Second,
+		APIKey:      "config-api-key",
+	}
+	err := SaveConfig(config)
+	require.NoError(t, err)
+
+	apiKey, err := GetAPIKey()
+
+	require.NoError(t, err)
+	assert.Equal(t, "config-api-key", apiKey)
+}
+
+func TestGetAPIKey_NotFound(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	// Ensure env var is not set
+	os.Unsetenv("PROOF_API_KEY")
+
+	_, err := GetAPIKey()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "API key not found")
+	assert.Equal(t, clierr.KindAuth, clierr.From(err, "").Kind)
+
+	// The kind survives the wrapping on the way to the exit code
+	_, err = NewProofClient()
+	cliErr := clierr.From(err, "Failed to create client")
+	assert.Equal(t, clierr.KindAuth, cliErr.Kind)
+	assert.Equal(t, clierr.ExitAuth, cliErr.ExitCode())
+}
+
+func TestSaveAPIKey(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	err := SaveAPIKey("new-api-key")
+	require.NoError(t, err)
+
+	// Verify by loading config
+	os.Unsetenv("PROOF_API_KEY")
+	apiKey, err := GetAPIKey()
+	require.NoError(t, err)
+	assert.Equal(t, "new-api-key", apiKey)
+}
+
+func TestSaveOAuthToken_AndLoadOAuthToken(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	// First load config to create the file
+	_, err := LoadConfig()
+	require.NoError(t, err)
+
+	token := &OAuthToken{
+		AccessToken: "test-token",
+		TokenType:   "Bearer",
+		ExpiresIn:   3600,
+		ExpiresAt:   time.Now().Add(1 * time.Hour),
+		Scope:       "read write",
+	}
+
+	err = SaveOAuthToken(token)
+	require.NoError(t, err)
+
+	loaded, err := LoadOAuthToken()
+	require.NoError(t, err)
+	assert.Equal(t, "test-token", loaded.AccessToken)
+	assert.Equal(t, "Bearer", loaded.TokenType)
+	assert.Equal(t, 3600, loaded.ExpiresIn)
+}
+
+func TestLoadOAuthToken_NotFound(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	_, err := LoadOAuthToken()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "OAuth token not found")
+}
+
+func TestShouldRefreshToken_OAuthDisabled(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		OAuth:       nil,
+	}
+
+	_, err := ShouldRefreshToken(config)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "OAuth not enabled")
+}
+
+func TestShouldRefreshToken_NoExistingToken(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		OAuth: &OAuthConfig{
+			Enabled: true,
+		},
+	}
+
+	needsRefresh, err := ShouldRefreshToken(config)
+
+	require.NoError(t, err)
+	assert.True(t, needsRefresh) // No token means we need to refresh
+}
+
+func TestShouldRefreshToken_ValidToken(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	// Create config with valid token
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		OAuth: &OAuthConfig{
+			Enabled: true,
+		},
+	}
+	err := SaveConfig(config)
+	require.NoError(t, err)
+
+	// Save a valid token
+	token := &OAuthToken{
+		AccessToken: "valid-token",
+		ExpiresAt:   time.Now().Add(1 * time.Hour), // Valid for 1 hour
+	}
+	err = SaveOAuthToken(token)
+	require.NoError(t, err)
+
+	needsRefresh, err := ShouldRefreshToken(config)
+
+	require.NoError(t, err)
+	assert.False(t, needsRefresh) // Token is valid, no refresh needed
+}
+
+func TestShouldRefreshToken_ExpiredToken(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	// Create config with expired token
+	config := &Config{
+		APIEndpoint: "https://api.proof.com",
+		OAuth: &OAuthConfig{
+			Enabled: true,
+		},
+	}
+	err := SaveConfig(config)
+	require.NoError(t, err)
+
+	// Save an expired token
+	token := &OAuthToken{
+		AccessToken: "expired-token",
+		ExpiresAt:   time.Now().Add(-1 * time.Hour), // Expired 1 hour ago
+	}
+	err = SaveOAuthToken(token)
+	require.NoError(t, err)
+
+	needsRefresh, err := ShouldRefreshToken(config)
+
+	require.NoError(t, err)
+	assert.True(t, needsRefresh) // Token is expired, needs refresh
+}
+
+// ============================================================================
+// profile.go tests
+// ============================================================================
+
+// writeTestConfigFile writes raw JSON as the config file under tempDir
+func writeTestConfigFile(t *testing.T, tempDir, content string) {
+	t.Helper()
+	configDir := filepath.Join(tempDir, ".proof-cli")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.json"), []byte(content), 0600))
+}
+
+func TestLoadConfig_TopLevelSettingsAreDefaultProfile(t *testing.T) {
+	tempDir, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+	writeTestConfigFile(t, tempDir, `{"api_endpoint":"https://api.proof.com","timeout":60000000000,"api_key":"legacy-key"}`)
+
+	config, err := LoadConfig()
+
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfile, config.Profile)
+	assert.Equal(t, "legacy-key", config.APIKey)
+	assert.Equal(t, 60*time.Second, config.Timeout)
+}
+
+func TestProfiles_AddUseDelete(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+	t.Setenv("PROOF_PROFILE", "")
+
+	require.NoError(t, AddProfile("sandbox", &Config{
+		APIEndpoint:    "https://api.fairfax.proof.com",
+		APIKey:         "sandbox-key",
+		OrganizationID: "org_123",
+	}))
+
+	names, active, err := ListProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultProfile, "sandbox"}, names)
+	assert.Equal(t, DefaultProfile, active)
+
+	require.NoError(t, UseProfile("sandbox"))
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "sandbox", config.Profile)
+	assert.Equal(t, "https://api.fairfax.proof.com", config.APIEndpoint)
+	assert.Equal(t, "org_123", config.OrganizationID)
+	assert.Equal(t, 30*time.Second, config.Timeout, "unset settings take their defaults")
+
+	require.NoError(t, DeleteProfile("sandbox"))
+	config, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfile, config.Profile)
+	assert.Equal(t, "https://api.proof.com", config.APIEndpoint)
+}
+
+func TestAddProfile_Rejected(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	require.NoError(t, AddProfile("prod", &Config{}))
+
+	for _, name := range []string{DefaultProfile, "prod", "has space", ""} {
+		err := AddProfile(name, &Config{})
+		var cliErr *clierr.Error
+		require.ErrorAs(t, err, &cliErr, name)
+		assert.Equal(t, clierr.KindInput, cliErr.Kind)
+	}
+	assert.Error(t, DeleteProfile(DefaultProfile))
+	assert.Error(t, UseProfile("missing"))
+}
+
+func TestActiveProfile_Precedence(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+	defer SelectProfile("")
+
+	for _, name := range []string{"saved", "env", "flag"} {
+		require.NoError(t, AddProfile(name, &Config{}))
+	}
+	require.NoError(t, UseProfile("saved"))
+
+	active, err := ActiveProfile()
+	require.NoError(t, err)
+	assert.Equal(t, "saved", active)
+
+	t.Setenv("PROOF_PROFILE", "env")
+	active, _ = ActiveProfile()
+	assert.Equal(t, "env", active)
+
+	SelectProfile("flag")
+	active, _ = ActiveProfile()
+	assert.Equal(t, "flag", active)
+}
+
+func TestLoadConfig_UnknownProfile(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+	t.Setenv("PROOF_PROFILE", "missing")
+
+	_, err := LoadConfig()
+
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
+	assert.Contains(t, err.Error(), `profile "missing" not found`)
+}
+
+func TestSaveOAuthToken_StoredPerProfile(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	require.NoError(t, AddProfile("child", &Config{OAuth: &OAuthConfig{Enabled: true, ClientID: "id", ClientSecret: "secret"}}))
+	t.Setenv("PROOF_PROFILE", "child")
+
+	require.NoError(t, SaveOAuthToken(&OAuthToken{AccessToken: "child-token", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	token, err := LoadOAuthToken()
+	require.NoError(t, err)
+	assert.Equal(t, "child-token", token.AccessToken)
+
+	defaults, err := LoadProfile(DefaultProfile)
+	require.NoError(t, err)
+	assert.Nil(t, defaults.OAuthToken, "other profiles keep their own token cache")
+}
+
+// ============================================================================
+// configfile.go tests - precedence and config file selection
+// ============================================================================
+
+func TestResolveConfig_Precedence(t *testing.T) {
+	tempDir, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+	defer SetOverrides(Overrides{})
+	writeTestConfigFile(t, tempDir, `{
+		"api_endpoint": "https://top.example",
+		"timeout": 60000000000,
+		"organization_id": "org_top",
+		"api_key": "top-key",
+		"current_profile": "child",
+		"profiles": {"child": {"api_endpoint": "https://child.example"}}
+	}`)
+	path := filepath.Join(tempDir, ".proof-cli", "config.json")
+
+	config, sources, err := ResolveConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://child.example", config.APIEndpoint)
+	assert.Equal(t, "profile child", sources["api_endpoint"])
+	assert.Equal(t, 60*time.Second, config.Timeout)
+	assert.Equal(t, "config file "+path, sources["timeout"])
+	assert.Equal(t, "org_top", config.OrganizationID)
+	assert.Equal(t, "default", sources["retry.max_retries"])
+	assert.Empty(t, config.APIKey, "named profiles do not inherit credentials")
+	assert.Equal(t, "config file "+path, sources["profile"])
+
+	t.Setenv("PROOF_ENDPOINT", "https://env.example")
+	t.Setenv("PROOF_TIMEOUT", "90")
+	config, sources, err = ResolveConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://env.example", config.APIEndpoint)
+	assert.Equal(t, "env PROOF_ENDPOINT", sources["api_endpoint"])
+	assert.Equal(t, 90*time.Second, config.Timeout)
+
+	retries := 1
+	SetOverrides(Overrides{APIEndpoint: "https://flag.example", Timeout: 5 * time.Second, MaxRetries: &retries})
+	config, sources, err = ResolveConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://flag.example", config.APIEndpoint)
+	assert.Equal(t, "flag --endpoint", sources["api_endpoint"])
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, 1, config.RetryPolicy().MaxRetries)
+	assert.Equal(t, "flag --max-retries", sources["retry.max_retries"])
+}
+
+func TestResolveConfig_Timeout(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"45", 45 * time.Second, false},
+		{"2m", 2 * time.Minute, false},
+		{"1.5s", 1500 * time.Millisecond, false},
+		{"soon", 0, true},
+		{"0", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("PROOF_TIMEOUT", tt.value)
+
+			config, err := LoadConfig()
+
+			if tt.wantErr {
+				var cliErr *clierr.Error
+				require.ErrorAs(t, err, &cliErr)
+				assert.Equal(t, clierr.KindInput, cliErr.Kind)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config.Timeout)
+		})
+	}
+}
+
+func TestUpdateConfig_DoesNotPersistEnvironment(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+	t.Setenv("PROOF_ENDPOINT", "https://env.example")
+	t.Setenv("PROOF_API_KEY", "env-key")
+
+	require.NoError(t, UpdateConfig(func(config *Config) error {
+		config.OrganizationID = "org_123"
+		return nil
+	}))
+
+	stored, err := LoadProfile(DefaultProfile)
+	require.NoError(t, err)
+	assert.Equal(t, "org_123", stored.OrganizationID)
+	assert.Equal(t, "https://api.proof.com", stored.APIEndpoint)
+	assert.Empty(t, stored.APIKey)
+}
+
+func TestSetConfigFile_YAML(t *testing.T) {
+	_, cleanup := setupTestConfigDir(t)
+	defer cleanup()
+	defer SetConfigFile("")
+
+	path := filepath.Join(t.TempDir(), "proof.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("api_endpoint: https://yaml.example\ntimeout: 25\nretry:\n  wait: 2s\n"), 0600))
+	SetConfigFile(path)
+
+	config, sources, err := ResolveConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "https://yaml.example", config.APIEndpoint)
+	assert.Equal(t, 25*time.Second, config.Timeout)
+	assert.Equal(t, 2*time.Second, config.RetryPolicy().Wait)
+	assert.Equal(t, "config file "+path, sources["timeout"])
+
+	require.NoError(t, UpdateConfig(func(config *Config) error {
+		config.Timeout = 90 * time.Second
+		return nil
+	}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "timeout: 1m30s")
 
-	return tempDir, cleanup
+	config, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, config.Timeout)
+	assert.Equal(t, 2*time.Second, config.RetryPolicy().Wait)
 }
 
-func TestLoadConfig_CreatesDefaultConfig(t *testing.T) {
+func TestConfigFilePath_MissingExplicitFile(t *testing.T) {
 	_, cleanup := setupTestConfigDir(t)
 	defer cleanup()
+	t.Setenv("PROOF_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
 
-	config, err := LoadConfig()
+	_, err := LoadConfig()
+
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
+	assert.Contains(t, err.Error(), "missing.json not found")
+}
+
+// ============================================================================
+// endpoints.go tests
+// ============================================================================
 
+// logsSpecServer is the only server the logs specification declares
+const logsSpecServer = "https://api.staging.proof.com"
+
+// specServers returns the server URLs declared by an API specification
+func specServers(t *testing.T, spec string) []string {
+	doc, err := openapi.Load(spec)
 	require.NoError(t, err)
-	assert.Equal(t, "https://api.proof.com", config.APIEndpoint)
-	assert.Equal(t, 30*time.Second, config.Timeout)
+	var servers []string
+	for _, server := range doc.Servers {
+		servers = append(servers, server.URL)
+	}
+	return servers
 }
 
-func TestSaveConfig_AndLoadConfig(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
-	defer cleanup()
+func TestConfig_Endpoint_DefaultsMatchSpecServers(t *testing.T) {
+	config := defaultConfig()
 
-	// Save config
-	config := &Config{
-		APIEndpoint: "https://custom.api.com",
-		Timeout:     60 * time.Second,
-		OAuth: &OAuthConfig{
-			Enabled:      true,
-			ClientID:     "my-client-id",
-			ClientSecret: "my-secret",
-			Scope:        "read",
-		},
-		APIKey: "test-api-key",
+	specs := map[string]string{
+		APIBusiness:     openapi.Business,
+		APIRealEstate:   openapi.RealEstate,
+		APISCIM:         openapi.SCIM,
+		APICertificates: openapi.Certificates,
+	}
+	for api, spec := range specs {
+		assert.Contains(t, specServers(t, spec), config.Endpoint(api), api)
 	}
-	err := SaveConfig(config)
-	require.NoError(t, err)
 
-	// Load config
-	loaded, err := LoadConfig()
+	// The logs specification only lists the staging host, which is reached by
+	// pointing api_endpoint at it like any other API
+	assert.Equal(t, config.APIEndpoint, config.Endpoint(APILogs), "logs follows api_endpoint")
+	assert.Contains(t, specServers(t, openapi.Logs), logsSpecServer)
+	staging := &Config{APIEndpoint: logsSpecServer}
+	assert.Equal(t, logsSpecServer, staging.Endpoint(APILogs))
+}
 
-	require.NoError(t, err)
-	assert.Equal(t, "https://custom.api.com", loaded.APIEndpoint)
-	assert.Equal(t, 60*time.Second, loaded.Timeout)
-	assert.True(t, loaded.OAuth.Enabled)
-	assert.Equal(t, "my-client-id", loaded.OAuth.ClientID)
-	assert.Equal(t, "my-secret", loaded.OAuth.ClientSecret)
-	assert.Equal(t, "read", loaded.OAuth.Scope)
-	assert.Equal(t, "test-api-key", loaded.APIKey)
+func TestConfig_Endpoint(t *testing.T) {
+	config := &Config{APIEndpoint: "https://api.fairfax.proof.com/"}
+
+	assert.Equal(t, "https://api.fairfax.proof.com", config.Endpoint(APIBusiness))
+	assert.Equal(t, "https://api.fairfax.proof.com/scim/v1/organizations", config.Endpoint(APISCIM))
+	assert.Equal(t, "https://api.fairfax.proof.com", config.Endpoint(APICertificates))
+	assert.Equal(t, "https://api.fairfax.proof.com", config.Endpoint(APILogs), "credentials are never sent to another host")
+
+	require.NoError(t, config.SetEndpoint(APILogs, "https://logs.example"))
+	assert.Equal(t, "https://logs.example", config.Endpoint(APILogs))
+
+	require.NoError(t, config.SetEndpoint(APILogs, ""))
+	assert.Nil(t, config.Endpoints)
+
+	err := config.SetEndpoint("billing", "https://billing.example")
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
 }
 
-func TestLoadConfig_FilePermissions(t *testing.T) {
+func TestResolveConfig_EndpointsPerProfile(t *testing.T) {
 	tempDir, cleanup := setupTestConfigDir(t)
 	defer cleanup()
+	writeTestConfigFile(t, tempDir, `{
+		"api_endpoint": "https://api.proof.com",
+		"endpoints": {"logs": "https://logs.example"},
+		"profiles": {"sandbox": {
+			"api_endpoint": "https://api.fairfax.proof.com",
+			"endpoints": {"scim": "https://scim.example/v1"}
+		}}
+	}`)
+	t.Setenv("PROOF_PROFILE", "sandbox")
+
+	config, sources, err := ResolveConfig()
 
-	// Load config (creates default)
-	_, err := LoadConfig()
 	require.NoError(t, err)
+	assert.Equal(t, "https://scim.example/v1", config.Endpoint(APISCIM))
+	assert.Equal(t, "profile sandbox", sources.EndpointSource(APISCIM))
+	assert.Equal(t, "https://logs.example", config.Endpoint(APILogs), "inherited from the top-level settings")
+	assert.Equal(t, "https://api.fairfax.proof.com", config.Endpoint(APICertificates))
+	assert.Equal(t, "profile sandbox", sources.EndpointSource(APICertificates))
+}
 
-	// Check file permissions
-	configFile := filepath.Join(tempDir, ".proof-cli", "config.json")
-	info, err := os.Stat(configFile)
-	require.NoError(t, err)
+// ============================================================================
+// credentials.go tests
+// ============================================================================
 
-	// File should have restricted permissions (0600)
-	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+// useFastCredentialFile makes new encrypted credential files cheap to unlock
+func useFastCredentialFile(t *testing.T) {
+	t.Helper()
+	iterations := fileStoreIterations
+	fileStoreIterations = 1000
+	t.Cleanup(func() { fileStoreIterations = iterations })
 }
 
-func TestGetAPIKey_FromEnvironment(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
-	defer cleanup()
+func TestFileStore(t *testing.T) {
+	useFastCredentialFile(t)
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store := &FileStore{Path: path, Passphrase: "correct horse"}
 
-	// Set environment variable
-	os.Setenv("PROOF_API_KEY", "env-api-key")
-	defer os.Unsetenv("PROOF_API_KEY")
+	_, err := store.Get("default/api_key")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
 
-	apiKey, err := GetAPIKey()
+	require.NoError(t, store.Set("default/api_key", "secret-key"))
+	value, err := store.Get("default/api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", value)
 
+	data, err := os.ReadFile(path)
 	require.NoError(t, err)
-	assert.Equal(t, "env-api-key", apiKey)
+	assert.NotContains(t, string(data), "secret-key")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// A new process derives the key from the passphrase again
+	value, err = (&FileStore{Path: path, Passphrase: "correct horse"}).Get("default/api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-key", value)
+
+	require.NoError(t, store.Delete("default/api_key"))
+	_, err = store.Get("default/api_key")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
 }
 
-func TestGetAPIKey_FromConfig(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
-	defer cleanup()
+func TestFileStore_Passphrase(t *testing.T) {
+	useFastCredentialFile(t)
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	require.NoError(t, (&FileStore{Path: path, Passphrase: "right"}).Set("default/api_key", "secret-key"))
 
-	// Ensure env var is not set
-	os.Unsetenv("PROOF_API_KEY")
+	tests := []struct {
+		name       string
+		passphrase string
+		kind       clierr.Kind
+	}{
+		{"wrong passphrase", "wrong", clierr.KindAuth},
+		{"missing passphrase", "", clierr.KindInput},
+	}
 
-	// Save config with API key
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		Timeout:     30 * time.Second,
-		APIKey:      "config-api-key",
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&FileStore{Path: path, Passphrase: tt.passphrase}).Get("default/api_key")
+
+			var cliErr *clierr.Error
+			require.ErrorAs(t, err, &cliErr)
+			assert.Equal(t, tt.kind, cliErr.Kind)
+		})
 	}
-	err := SaveConfig(config)
-	require.NoError(t, err)
+}
 
-	apiKey, err := GetAPIKey()
+func TestHelperStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper script needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "helper")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+f="`+dir+`/$(echo "$2" | tr / _)"
+case "$1" in
+get) if [ -f "$f" ]; then cat "$f"; fi ;;
+store) cat > "$f" ;;
+erase) rm -f "$f" ;;
+*) echo "unknown action $1" >&2; exit 1 ;;
+esac
+`), 0700))
+	store := &HelperStore{Command: script}
 
+	_, err := store.Get("sandbox/api_key")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	require.NoError(t, store.Set("sandbox/api_key", "helper-key"))
+	value, err := store.Get("sandbox/api_key")
 	require.NoError(t, err)
-	assert.Equal(t, "config-api-key", apiKey)
+	assert.Equal(t, "helper-key", value)
+
+	require.NoError(t, store.Delete("sandbox/api_key"))
+	_, err = store.Get("sandbox/api_key")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	_, err = (&HelperStore{Command: script + " extra"}).Get("sandbox/api_key")
+	assert.ErrorContains(t, err, "unknown action extra")
 }
 
-func TestGetAPIKey_NotFound(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
-	defer cleanup()
+func TestEnvStore(t *testing.T) {
+	t.Setenv("PROOF_API_KEY", "env-key")
+	store := &EnvStore{}
 
-	// Ensure env var is not set
-	os.Unsetenv("PROOF_API_KEY")
+	value, err := store.Get("sandbox/api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "env-key", value)
 
-	_, err := GetAPIKey()
+	_, err = store.Get("sandbox/oauth_client_secret")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "API key not found")
+	var cliErr *clierr.Error
+	require.ErrorAs(t, store.Set("sandbox/api_key", "other"), &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
+
+	require.NoError(t, store.Set("sandbox/oauth_token", `{"access_token":"memory"}`))
+	value, err = store.Get("sandbox/oauth_token")
+	require.NoError(t, err)
+	assert.Equal(t, `{"access_token":"memory"}`, value)
 }
 
-func TestSaveAPIKey(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
+func TestCredentialStore_KeepsSecretsOutOfConfigFile(t *testing.T) {
+	useFastCredentialFile(t)
+	tempDir, cleanup := setupTestConfigDir(t)
 	defer cleanup()
+	writeTestConfigFile(t, tempDir, `{"api_endpoint":"https://api.proof.com","credential_store":"file"}`)
+	t.Setenv("PROOF_CREDENTIAL_PASSPHRASE", "passphrase")
 
-	err := SaveAPIKey("new-api-key")
+	require.NoError(t, SaveAPIKey("stored-key"))
+	require.NoError(t, UpdateConfig(func(config *Config) error {
+		config.OAuth = &OAuthConfig{Enabled: true, ClientID: "client", ClientSecret: "client-secret"}
+		return nil
+	}))
+	require.NoError(t, SaveOAuthToken(&OAuthToken{AccessToken: "stored-token", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	data, err := os.ReadFile(filepath.Join(tempDir, ".proof-cli", "config.json"))
 	require.NoError(t, err)
+	for _, secret := range []string{"stored-key", "client-secret", "stored-token"} {
+		assert.NotContains(t, string(data), secret)
+	}
+	assert.Contains(t, string(data), `"client_id": "client"`)
 
-	// Verify by loading config
-	os.Unsetenv("PROOF_API_KEY")
-	apiKey, err := GetAPIKey()
+	config, sources, err := ResolveConfig()
 	require.NoError(t, err)
-	assert.Equal(t, "new-api-key", apiKey)
+	assert.Equal(t, StoreFile, config.Credentials)
+	assert.Equal(t, "stored-key", config.APIKey)
+	assert.Equal(t, "credential store file", sources["api_key"])
+	assert.Equal(t, "client-secret", config.OAuth.ClientSecret)
+	assert.Equal(t, "stored-token", config.OAuthToken.AccessToken)
 }
 
-func TestSaveOAuthToken_AndLoadOAuthToken(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
+func TestResolveConfig_FileStoreWithoutPassphrase(t *testing.T) {
+	useFastCredentialFile(t)
+	tempDir, cleanup := setupTestConfigDir(t)
 	defer cleanup()
+	writeTestConfigFile(t, tempDir, `{"api_endpoint":"https://api.proof.com","credential_store":"file"}`)
+	t.Setenv("PROOF_CREDENTIAL_PASSPHRASE", "")
+	t.Setenv("PROOF_API_KEY", "")
 
-	// First load config to create the file
-	_, err := LoadConfig()
+	// Nothing is stored yet, so there is nothing to decrypt
+	config, _, err := ResolveConfig()
 	require.NoError(t, err)
+	assert.Empty(t, config.APIKey)
 
-	token := &OAuthToken{
-		AccessToken: "test-token",
-		TokenType:   "Bearer",
-		ExpiresIn:   3600,
-		ExpiresAt:   time.Now().Add(1 * time.Hour),
-		Scope:       "read write",
-	}
-
-	err = SaveOAuthToken(token)
+	store, err := NewCredentialStore(StoreFile, "")
 	require.NoError(t, err)
-
-	loaded, err := LoadOAuthToken()
+	fileStore := store.(*FileStore)
+	fileStore.Passphrase = "passphrase"
+	require.NoError(t, SaveAPIKey("stored-key"))
+	fileStore.Passphrase = ""
+
+	// PROOF_API_KEY is used without unlocking the store
+	t.Setenv("PROOF_API_KEY", "env-key")
+	config, sources, err := ResolveConfig()
 	require.NoError(t, err)
-	assert.Equal(t, "test-token", loaded.AccessToken)
-	assert.Equal(t, "Bearer", loaded.TokenType)
-	assert.Equal(t, 3600, loaded.ExpiresIn)
+	assert.Equal(t, "env-key", config.APIKey)
+	assert.Equal(t, "env PROOF_API_KEY", sources["api_key"])
+
+	// A stored key that is still needed asks for the passphrase
+	t.Setenv("PROOF_API_KEY", "")
+	_, _, err = ResolveConfig()
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
 }
 
-func TestLoadOAuthToken_NotFound(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
+func TestMigrateCredentials(t *testing.T) {
+	useFastCredentialFile(t)
+	tempDir, cleanup := setupTestConfigDir(t)
 	defer cleanup()
+	writeTestConfigFile(t, tempDir, `{
+		"api_endpoint": "https://api.proof.com",
+		"api_key": "default-key",
+		"profiles": {"sandbox": {"oauth": {"enabled": true, "client_id": "client", "client_secret": "sandbox-secret"}}}
+	}`)
+	t.Setenv("PROOF_CREDENTIAL_PASSPHRASE", "passphrase")
+
+	moved, err := MigrateCredentials(StoreFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, moved)
 
-	_, err := LoadOAuthToken()
+	data, err := os.ReadFile(filepath.Join(tempDir, ".proof-cli", "config.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "default-key")
+	assert.NotContains(t, string(data), "sandbox-secret")
+	assert.Contains(t, string(data), `"credential_store": "file"`)
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "OAuth token not found")
+	apiKey, err := GetAPIKey()
+	require.NoError(t, err)
+	assert.Equal(t, "default-key", apiKey)
+
+	t.Setenv("PROOF_PROFILE", "sandbox")
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "sandbox-secret", config.OAuth.ClientSecret)
+
+	moved, err = MigrateCredentials(StoreConfig, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, moved)
+	data, err = os.ReadFile(filepath.Join(tempDir, ".proof-cli", "config.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "default-key")
+	assert.NotContains(t, string(data), "credential_store")
+
+	_, err = MigrateCredentials(StoreEnv, "")
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindInput, cliErr.Kind)
 }
 
-func TestShouldRefreshToken_OAuthDisabled(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
-	defer cleanup()
+// ============================================================================
+// lock.go and tokencache.go tests
+// ============================================================================
 
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		OAuth:       nil,
-	}
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
 
-	_, err := ShouldRefreshToken(config)
+	require.NoError(t, writeFileAtomic(path, []byte("new"), 0600))
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "OAuth not enabled")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
 }
 
-func TestShouldRefreshToken_NoExistingToken(t *testing.T) {
+func TestUpdateConfig_ConcurrentWritersKeepEveryChange(t *testing.T) {
 	_, cleanup := setupTestConfigDir(t)
 	defer cleanup()
+	_, err := LoadConfig()
+	require.NoError(t, err)
 
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		OAuth: &OAuthConfig{
-			Enabled: true,
-		},
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- AddProfile(fmt.Sprintf("p%02d", i), &Config{OrganizationID: fmt.Sprintf("org_%d", i)})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
 	}
 
-	needsRefresh, err := ShouldRefreshToken(config)
-
+	names, _, err := ListProfiles()
 	require.NoError(t, err)
-	assert.True(t, needsRefresh) // No token means we need to refresh
+	assert.Len(t, names, 21)
 }
 
-func TestShouldRefreshToken_ValidToken(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
+func TestSaveOAuthToken_UsesTokenCache(t *testing.T) {
+	tempDir, cleanup := setupTestConfigDir(t)
 	defer cleanup()
+	writeTestConfigFile(t, tempDir, `{"api_endpoint":"https://api.proof.com","oauth":{"enabled":true,"client_id":"client","client_secret":"secret"}}`)
 
-	// Create config with valid token
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		OAuth: &OAuthConfig{
-			Enabled: true,
-		},
-	}
-	err := SaveConfig(config)
-	require.NoError(t, err)
+	require.NoError(t, SaveOAuthToken(&OAuthToken{AccessToken: "cached-token", ExpiresAt: time.Now().Add(time.Hour)}))
 
-	// Save a valid token
-	token := &OAuthToken{
-		AccessToken: "valid-token",
-		ExpiresAt:   time.Now().Add(1 * time.Hour), // Valid for 1 hour
-	}
-	err = SaveOAuthToken(token)
+	data, err := os.ReadFile(filepath.Join(tempDir, ".proof-cli", "config.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "cached-token")
+	data, err = os.ReadFile(filepath.Join(tempDir, ".proof-cli", "tokens.json"))
 	require.NoError(t, err)
+	assert.Contains(t, string(data), "cached-token")
 
-	needsRefresh, err := ShouldRefreshToken(config)
+	token, err := LoadOAuthToken()
+	require.NoError(t, err)
+	assert.Equal(t, "cached-token", token.AccessToken)
 
+	// Another profile using the same OAuth client shares the token
+	require.NoError(t, AddProfile("same-client", &Config{OAuth: &OAuthConfig{Enabled: true, ClientID: "client", ClientSecret: "secret"}}))
+	t.Setenv("PROOF_PROFILE", "same-client")
+	token, err = LoadOAuthToken()
 	require.NoError(t, err)
-	assert.False(t, needsRefresh) // Token is valid, no refresh needed
+	assert.Equal(t, "cached-token", token.AccessToken)
 }
 
-func TestShouldRefreshToken_ExpiredToken(t *testing.T) {
-	_, cleanup := setupTestConfigDir(t)
+func TestProofClient_ConcurrentRefreshesShareOneToken(t *testing.T) {
+	tempDir, cleanup := setupTestConfigDir(t)
 	defer cleanup()
-
-	// Create config with expired token
-	config := &Config{
-		APIEndpoint: "https://api.proof.com",
-		OAuth: &OAuthConfig{
-			Enabled: true,
-		},
+	writeTestConfigFile(t, tempDir, `{"api_endpoint":"https://api.proof.com","oauth":{"enabled":true,"client_id":"client","client_secret":"secret"}}`)
+
+	var mu sync.Mutex
+	requests := 0
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		return mockJSONResponse(200, map[string]any{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		}), nil
+	})
+
+	var wg sync.WaitGroup
+	tokens := make(chan string, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config, err := LoadConfig()
+			if !assert.NoError(t, err) {
+				return
+			}
+			client := &ProofClient{config: config, httpClient: &http.Client{Transport: transport}}
+			token, err := client.getValidOAuthToken()
+			if assert.NoError(t, err) {
+				tokens <- token.AccessToken
+			}
+		}()
 	}
-	err := SaveConfig(config)
-	require.NoError(t, err)
+	wg.Wait()
+	close(tokens)
 
-	// Save an expired token
-	token := &OAuthToken{
-		AccessToken: "expired-token",
-		ExpiresAt:   time.Now().Add(-1 * time.Hour), // Expired 1 hour ago
+	assert.Equal(t, 1, requests, "only one process fetches a token")
+	for token := range tokens {
+		assert.Equal(t, "token-1", token)
 	}
-	err = SaveOAuthToken(token)
-	require.NoError(t, err)
-
-	needsRefresh, err := ShouldRefreshToken(config)
-
-	require.NoError(t, err)
-	assert.True(t, needsRefresh) // Token is expired, needs refresh
 }
 
 // ============================================================================
@@ -699,6 +2509,12 @@ func TestProofClient_Request_APIError(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "API error (status 400)")
+
+	var cliErr *clierr.Error
+	require.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierr.KindValidation, cliErr.Kind)
+	assert.Equal(t, 400, cliErr.StatusCode)
+	assert.Equal(t, []string{`{"error":"bad request"}`}, cliErr.Details)
 }
 
 func TestProofClient_HTTPMethods(t *testing.T) {
@@ -815,6 +2631,22 @@ func TestNewProofClient_WithAPIKey(t *testing.T) {
 	_ = tempDir // silence unused warning
 }
 
+func TestNewDryRunClient_NeedsNoCredential(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PROOF_API_KEY", "")
+
+	_, err := NewProofClient()
+	require.Error(t, err, "a real client needs an API key")
+
+	var buf bytes.Buffer
+	client, err := NewDryRunClient(&buf, true)
+	require.NoError(t, err)
+
+	_, err = client.Get("/v1/test")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `-H "Apikey: $PROOF_API_KEY"`)
+}
+
 func TestNewProofClient_WithOAuthEnabled(t *testing.T) {
 	_, cleanup := setupTestConfigDir(t)
 	defer cleanup()
@@ -1330,6 +3162,37 @@ func TestProofClient_Request_SuccessStatusCodes(t *testing.T) {
 	}
 }
 
+// ============================================================================
+// since.go tests
+// ============================================================================
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026-03-01T08:30:00Z", time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"24h", now.Add(-24 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"1d12h", now.Add(-36 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.value, now)
+		require.NoError(t, err, tt.value)
+		assert.True(t, tt.want.Equal(got), "%s: got %s", tt.value, got)
+	}
+
+	for _, value := range []string{"", "yesterday", "-24h", "xd", "3d2x"} {
+		_, err := ParseSince(value, now)
+		var cliErr *clierr.Error
+		require.ErrorAs(t, err, &cliErr, value)
+		assert.Equal(t, clierr.KindInput, cliErr.Kind)
+	}
+}
+
 // ============================================================================
 // Edge cases and additional coverage
 // ============================================================================